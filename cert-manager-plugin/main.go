@@ -1,18 +1,39 @@
 package main
 
 import (
+	context2 "context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/bundles"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/crds"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/events"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/gc"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks/gateways"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks/ingresses"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks/pods"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/imports"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/certificaterequests"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/certificates"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/challenges"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/clusterissuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/externalissuers"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/orders"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/secrets"
 	"github.com/loft-sh/vcluster-sdk/plugin"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 func init() {
 	// Add cert manager types to our plugin scheme
 	_ = certmanagerv1.AddToScheme(plugin.Scheme)
+	_ = cmacme.AddToScheme(plugin.Scheme)
+	_ = gatewayv1alpha2.AddToScheme(plugin.Scheme)
 }
 
 func main() {
@@ -22,33 +43,210 @@ func main() {
 		klog.Fatalf("Error initializing plugin: %v", err)
 	}
 
+	// load plugin config
+	cfg, err := config.Load()
+	if err != nil {
+		klog.Fatalf("Error loading plugin config: %v", err)
+	}
+
+	// register crd watcher, which stops the plugin through its context once the syncers have to change
+	ctx, stop := context2.WithCancel(registerCtx.Context)
+	registerCtx.Context = ctx
+	crdWatcher, err := crds.NewWatcher(registerCtx, stop)
+	if err != nil {
+		klog.Fatalf("Error creating crd watcher: %v", err)
+	}
+	err = plugin.Register(crdWatcher)
+	if err != nil {
+		klog.Fatalf("Error registering crd watcher: %v", err)
+	}
+
+	// register secret importer
+	err = plugin.Register(imports.New(cfg.SecretImports))
+	if err != nil {
+		klog.Fatalf("Error registering secret importer: %v", err)
+	}
+
+	// register bundle syncer
+	bundlesAvailable, err := crdWatcher.Available(bundles.GroupVersionKind)
+	if err != nil {
+		klog.Fatalf("Error checking trust-manager crds: %v", err)
+	} else if bundlesAvailable {
+		err = plugin.Register(bundles.New(cfg))
+		if err != nil {
+			klog.Fatalf("Error registering bundle syncer: %v", err)
+		}
+	}
+
+	// the syncers are only registered if cert-manager is installed within the host cluster, otherwise
+	// the plugin waits for the crd watcher to restart it once cert-manager was installed
+	certManagerAvailable, err := crdWatcher.Available(
+		certmanagerv1.SchemeGroupVersion.WithKind("Certificate"),
+		certmanagerv1.SchemeGroupVersion.WithKind("CertificateRequest"),
+		certmanagerv1.SchemeGroupVersion.WithKind("Issuer"),
+		certmanagerv1.SchemeGroupVersion.WithKind("ClusterIssuer"),
+	)
+	if err != nil {
+		klog.Fatalf("Error checking cert-manager crds: %v", err)
+	} else if certManagerAvailable {
+		registerSyncers(registerCtx, cfg, crdWatcher)
+	} else {
+		klog.Infof("cert-manager crds are missing in the host cluster, syncers are disabled until they are installed")
+	}
+
+	// start plugin
+	err = plugin.Start()
+	if err != nil {
+		klog.Fatalf("Error starting plugin: %v", err)
+	}
+
+	// exit with an error, so that the plugin container is restarted with the changed syncers
+	if crdWatcher.Err() != nil {
+		klog.Fatalf("Restarting plugin, because %v", crdWatcher.Err())
+	}
+}
+
+// registerSyncers registers the hooks and syncers of all cert-manager kinds that exist within the
+// host cluster
+func registerSyncers(registerCtx *context.RegisterContext, cfg *config.Config, crdWatcher *crds.Watcher) {
+	// only external issuers whose crds exist within the host cluster can be referenced, as the indices and
+	// watches of the other syncers would fail for missing kinds
+	availableExternalIssuers := config.ExternalIssuers{}
+	for _, externalIssuer := range cfg.ExternalIssuers {
+		available, err := crdWatcher.Available(externalIssuer.GroupVersionKind())
+		if err != nil {
+			klog.Fatalf("Error checking external issuer crd %s: %v", externalIssuer.GroupVersionKind().String(), err)
+		} else if !available {
+			klog.Infof("external issuer crd %s is missing in the host cluster, syncer is disabled until it is installed", externalIssuer.GroupVersionKind().String())
+			continue
+		}
+
+		availableExternalIssuers = append(availableExternalIssuers, externalIssuer)
+	}
+	cfg.ExternalIssuers = availableExternalIssuers
+
 	// register ingress hook
-	err = plugin.Register(ingresses.NewIngressHook())
+	err := plugin.Register(ingresses.NewIngressHook(registerCtx, cfg))
 	if err != nil {
 		klog.Fatalf("Error registering ingress hook: %v", err)
 	}
 
+	// register gateway hook, if gateways are synced to the host cluster
+	if cfg.Gateways.Synced {
+		err = plugin.Register(gateways.NewGatewayHook(registerCtx, cfg))
+		if err != nil {
+			klog.Fatalf("Error registering gateway hook: %v", err)
+		}
+	}
+
+	// register pod hook
+	err = plugin.Register(pods.NewPodHook(registerCtx, cfg))
+	if err != nil {
+		klog.Fatalf("Error registering pod hook: %v", err)
+	}
+
 	// register certificate syncer
-	err = plugin.Register(certificates.New(registerCtx))
+	certificateSyncer := certificates.New(registerCtx, cfg)
+	err = plugin.Register(certificateSyncer)
 	if err != nil {
 		klog.Fatalf("Error registering certificate syncer: %v", err)
 	}
 
+	// register certificate request syncer
+	certificateRequestSyncer := certificaterequests.New(registerCtx, cfg)
+	err = plugin.Register(certificateRequestSyncer)
+	if err != nil {
+		klog.Fatalf("Error registering certificate request syncer: %v", err)
+	}
+
+	// register order and challenge syncers
+	acmeAvailable, err := crdWatcher.Available(cmacme.SchemeGroupVersion.WithKind("Order"), cmacme.SchemeGroupVersion.WithKind("Challenge"))
+	if err != nil {
+		klog.Fatalf("Error checking acme crds: %v", err)
+	} else if acmeAvailable {
+		orderSyncer := orders.New(registerCtx, certificateRequestSyncer)
+		err = plugin.Register(orderSyncer)
+		if err != nil {
+			klog.Fatalf("Error registering order syncer: %v", err)
+		}
+
+		err = plugin.Register(challenges.New(registerCtx, orderSyncer))
+		if err != nil {
+			klog.Fatalf("Error registering challenge syncer: %v", err)
+		}
+	} else {
+		klog.Infof("acme crds are missing in the host cluster, order and challenge syncers are disabled until they are installed")
+	}
+
 	// register issuer syncer
-	err = plugin.Register(issuers.New(registerCtx))
+	issuerSyncer := issuers.New(registerCtx, cfg)
+	err = plugin.Register(issuerSyncer)
 	if err != nil {
 		klog.Fatalf("Error registering certificate syncer: %v", err)
 	}
 
+	// register external issuer syncers
+	garbageCollectedKinds := []gc.Kind{}
+	forwardedEventKinds := []events.Kind{}
+	for _, externalIssuer := range cfg.ExternalIssuers {
+		externalIssuerSyncer := externalissuers.New(registerCtx, cfg, externalIssuer)
+		err = plugin.Register(externalIssuerSyncer)
+		if err != nil {
+			klog.Fatalf("Error registering external issuer syncer for %s: %v", externalIssuer.GroupVersionKind().String(), err)
+		}
+
+		garbageCollectedKinds = append(garbageCollectedKinds, gc.Kind{Translator: externalIssuerSyncer, PhysicalList: externalissuers.NewList(externalIssuer), Virtual: externalissuers.NewObject(externalIssuer)})
+		forwardedEventKinds = append(forwardedEventKinds, events.Kind{Translator: externalIssuerSyncer, Physical: externalissuers.NewObject(externalIssuer), Virtual: externalissuers.NewObject(externalIssuer)})
+	}
+
+	// register cluster issuer syncer
+	err = plugin.Register(clusterissuers.New(registerCtx, cfg))
+	if err != nil {
+		klog.Fatalf("Error registering cluster issuer syncer: %v", err)
+	}
+
+	// register virtual cluster issuer syncer
+	clusterIssuerIssuerSyncer := clusterissuers.NewIssuerSyncer(registerCtx, cfg)
+	err = plugin.Register(clusterIssuerIssuerSyncer)
+	if err != nil {
+		klog.Fatalf("Error registering virtual cluster issuer syncer: %v", err)
+	}
+
 	// register secrets syncer
-	err = plugin.Register(secrets.New(registerCtx))
+	secretSyncer := secrets.New(registerCtx, cfg)
+	err = plugin.Register(secretSyncer)
 	if err != nil {
 		klog.Fatalf("Error registering secrets syncer: %v", err)
 	}
 
-	// start plugin
-	err = plugin.Start()
+	// register garbage collector
+	garbageCollectedKinds = append(garbageCollectedKinds,
+		gc.Kind{Translator: certificateSyncer, PhysicalList: &certmanagerv1.CertificateList{}, Virtual: &certmanagerv1.Certificate{}},
+		gc.Kind{Translator: issuerSyncer, PhysicalList: &certmanagerv1.IssuerList{}, Virtual: &certmanagerv1.Issuer{}},
+		gc.Kind{Translator: clusterIssuerIssuerSyncer, PhysicalList: &certmanagerv1.IssuerList{}, Virtual: &certmanagerv1.ClusterIssuer{}},
+		gc.Kind{Translator: secretSyncer, PhysicalList: &corev1.SecretList{}, Virtual: &corev1.Secret{}},
+	)
+	err = plugin.Register(gc.New(registerCtx, cfg.GarbageCollection, garbageCollectedKinds...))
 	if err != nil {
-		klog.Fatalf("Error starting plugin: %v", err)
+		klog.Fatalf("Error registering garbage collector: %v", err)
+	}
+
+	// register event forwarder
+	forwardedEventKinds = append(forwardedEventKinds,
+		events.Kind{Translator: certificateSyncer, Physical: &certmanagerv1.Certificate{}, Virtual: &certmanagerv1.Certificate{}},
+		events.Kind{Translator: certificateRequestSyncer, Physical: &certmanagerv1.CertificateRequest{}, Virtual: &certmanagerv1.CertificateRequest{}},
+		events.Kind{Translator: issuerSyncer, Physical: &certmanagerv1.Issuer{}, Virtual: &certmanagerv1.Issuer{}},
+		events.Kind{Translator: clusterIssuerIssuerSyncer, Physical: &certmanagerv1.Issuer{}, Virtual: &certmanagerv1.ClusterIssuer{}},
+		events.Kind{Translator: secretSyncer, Physical: &corev1.Secret{}, Virtual: &corev1.Secret{}},
+	)
+	err = plugin.Register(events.New(forwardedEventKinds...))
+	if err != nil {
+		klog.Fatalf("Error registering event forwarder: %v", err)
+	}
+
+	// register metrics server
+	err = plugin.Register(metrics.New(cfg.Metrics))
+	if err != nil {
+		klog.Fatalf("Error registering metrics server: %v", err)
 	}
 }
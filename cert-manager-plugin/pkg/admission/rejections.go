@@ -0,0 +1,109 @@
+package admission
+
+import (
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sync"
+	"time"
+)
+
+const (
+	// HostValidationFailedReason is the reason of the Ready condition of virtual objects the host
+	// cluster refused, e.g. because the cert-manager webhook rejected them
+	HostValidationFailedReason = "HostValidationFailed"
+
+	// initialBackoff and maxBackoff limit the interval rejected objects are retried in
+	initialBackoff = 10 * time.Second
+	maxBackoff     = 10 * time.Minute
+)
+
+// IsRejected returns true if the host cluster refused the object because it is invalid, either
+// through the api server validation or an admission webhook
+func IsRejected(err error) bool {
+	return kerrors.IsInvalid(err) || kerrors.IsBadRequest(err)
+}
+
+// Rejection is a generation of a virtual object the host cluster refused
+type Rejection struct {
+	// Generation is the generation of the virtual object that was refused
+	Generation int64
+
+	// Message is the reason the host cluster refused the object
+	Message string
+
+	// RetryAt is the time the object should be synced to the host cluster again
+	RetryAt time.Time
+
+	failures int
+}
+
+// Rejections remembers the virtual objects the host cluster refused, so that they are retried
+// with an exponential backoff instead of on every reconcile
+type Rejections struct {
+	rejectionsLock sync.Mutex
+	rejections     map[types.UID]Rejection
+}
+
+func NewRejections() *Rejections {
+	return &Rejections{
+		rejections: map[types.UID]Rejection{},
+	}
+}
+
+// Reject records that the host cluster refused the current generation of the virtual object and
+// returns the time to wait before the object is synced again
+func (r *Rejections) Reject(vObj client.Object, message string) time.Duration {
+	r.rejectionsLock.Lock()
+	defer r.rejectionsLock.Unlock()
+
+	r.prune()
+	rejection, ok := r.rejections[vObj.GetUID()]
+	if !ok || rejection.Generation != vObj.GetGeneration() {
+		rejection = Rejection{Generation: vObj.GetGeneration()}
+	}
+
+	backoff := initialBackoff << rejection.failures
+	if backoff > maxBackoff || backoff <= 0 {
+		backoff = maxBackoff
+	} else {
+		rejection.failures++
+	}
+
+	rejection.Message = message
+	rejection.RetryAt = time.Now().Add(backoff)
+	r.rejections[vObj.GetUID()] = rejection
+	return backoff
+}
+
+// Get returns the rejection of the current generation of the virtual object. If the current
+// generation was not refused, nil is returned.
+func (r *Rejections) Get(vObj client.Object) *Rejection {
+	r.rejectionsLock.Lock()
+	defer r.rejectionsLock.Unlock()
+
+	rejection, ok := r.rejections[vObj.GetUID()]
+	if !ok || rejection.Generation != vObj.GetGeneration() {
+		return nil
+	}
+
+	return &rejection
+}
+
+// Forget removes the rejection of the virtual object, e.g. after it was synced successfully
+func (r *Rejections) Forget(vObj client.Object) {
+	r.rejectionsLock.Lock()
+	defer r.rejectionsLock.Unlock()
+
+	delete(r.rejections, vObj.GetUID())
+}
+
+// prune removes rejections that were not retried within the maximum backoff after their retry time.
+// Rejected objects are requeued at their retry time, so these objects were deleted in the meantime.
+func (r *Rejections) prune() {
+	for uid, rejection := range r.rejections {
+		if time.Since(rejection.RetryAt) > maxBackoff {
+			delete(r.rejections, uid)
+		}
+	}
+}
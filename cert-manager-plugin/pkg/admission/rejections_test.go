@@ -0,0 +1,52 @@
+package admission
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+	"time"
+)
+
+func TestReject(t *testing.T) {
+	rejections := NewRejections()
+	vObj := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{UID: "uid", Generation: 1}}
+	for i, expected := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		backoff := rejections.Reject(vObj, "invalid")
+		if backoff != expected {
+			t.Errorf("Rejection %d: expected backoff %s, got %s", i, expected, backoff)
+		}
+	}
+
+	// a new generation starts with the initial backoff again
+	vObj.Generation = 2
+	if rejections.Get(vObj) != nil {
+		t.Errorf("expected no rejection of the new generation")
+	}
+	backoff := rejections.Reject(vObj, "invalid")
+	if backoff != initialBackoff {
+		t.Errorf("expected backoff %s of the new generation, got %s", initialBackoff, backoff)
+	}
+
+	rejections.Forget(vObj)
+	if rejections.Get(vObj) != nil {
+		t.Errorf("expected the rejection to be forgotten")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	rejections := NewRejections()
+	rejections.rejections[types.UID("deleted")] = Rejection{Generation: 1, RetryAt: time.Now().Add(-2 * maxBackoff)}
+	rejections.rejections[types.UID("pending")] = Rejection{Generation: 1, RetryAt: time.Now().Add(-time.Minute)}
+
+	rejections.Reject(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{UID: "new", Generation: 1}}, "invalid")
+	if _, ok := rejections.rejections["deleted"]; ok {
+		t.Errorf("expected the rejection of the deleted object to be pruned")
+	}
+	if _, ok := rejections.rejections["pending"]; !ok {
+		t.Errorf("expected the rejection of the pending object to be kept")
+	}
+	if _, ok := rejections.rejections["new"]; !ok {
+		t.Errorf("expected the new rejection to be recorded")
+	}
+}
@@ -0,0 +1,284 @@
+package bundles
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/records"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// TrustBundleLabel is the label trust-manager sets on the target config maps of a bundle
+	TrustBundleLabel = "trust.cert-manager.io/bundle"
+)
+
+// GroupVersionKind is the group version kind of trust-manager bundles
+var GroupVersionKind = schema.GroupVersionKind{Group: "trust.cert-manager.io", Version: "v1alpha1", Kind: "Bundle"}
+
+// New creates a syncer that copies the target config maps of host trust-manager bundles into
+// the virtual namespaces selected by the bundles, so that workloads within the vcluster can
+// trust certificates of private CA or Vault issuers
+func New(cfg *config.Config) syncer.Base {
+	return &bundleSyncer{
+		allowed: cfg.Bundles.Allowed,
+	}
+}
+
+type bundleSyncer struct {
+	allowed []string
+
+	// records holds the virtual config maps created by the syncer
+	records *records.Records
+
+	syncContext *context.SyncContext
+}
+
+func (s *bundleSyncer) Name() string {
+	return "bundle-syncer"
+}
+
+var _ syncer.ControllerStarter = &bundleSyncer{}
+
+func (s *bundleSyncer) Register(ctx *context.RegisterContext) error {
+	s.syncContext = context.ConvertContext(ctx, s.Name())
+	hostClient, err := client.New(ctx.PhysicalManager.GetConfig(), client.Options{Scheme: ctx.PhysicalManager.GetScheme()})
+	if err != nil {
+		return err
+	}
+	s.records = records.New(hostClient, "bundles")
+
+	// bundles are reconciled if trust-manager updates their target config map within the host
+	// namespace, a virtual namespace is created or relabeled or a virtual config map is changed,
+	// including the removal of the bundle label
+	isBundleConfigMap := func(label string) predicate.Predicate {
+		isLabeled := func(obj client.Object) bool {
+			return obj.GetLabels() != nil && obj.GetLabels()[label] != ""
+		}
+		return predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return isLabeled(e.Object) },
+			UpdateFunc:  func(e event.UpdateEvent) bool { return isLabeled(e.ObjectOld) || isLabeled(e.ObjectNew) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return isLabeled(e.Object) },
+			GenericFunc: func(e event.GenericEvent) bool { return isLabeled(e.Object) },
+		}
+	}
+	mapVirtualConfigMaps := func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetName()}}}
+	}
+	return ctrl.NewControllerManagedBy(ctx.PhysicalManager).
+		Named(s.Name()).
+		For(NewObject()).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(mapConfigMaps(TrustBundleLabel)), builder.WithPredicates(isBundleConfigMap(TrustBundleLabel))).
+		Watches(source.NewKindWithCache(&corev1.ConfigMap{}, ctx.VirtualManager.GetCache()), handler.EnqueueRequestsFromMapFunc(mapVirtualConfigMaps), builder.WithPredicates(isBundleConfigMap(constants.BundleLabel))).
+		Watches(source.NewKindWithCache(&corev1.Namespace{}, ctx.VirtualManager.GetCache()), handler.EnqueueRequestsFromMapFunc(s.mapNamespaces)).
+		Complete(s)
+}
+
+func (s *bundleSyncer) Reconcile(ctx context2.Context, req ctrl.Request) (ctrl.Result, error) {
+	syncContext := *s.syncContext
+	syncContext.Context = ctx
+	syncContext.Log = log.NewFromExisting(s.syncContext.Log.Base(), req.Name)
+
+	// get the physical bundle
+	pBundle := NewObject()
+	err := syncContext.PhysicalClient.Get(ctx, types.NamespacedName{Name: req.Name}, pBundle)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return ctrl.Result{}, s.deleteConfigMaps(&syncContext, req.Name, nil, "physical bundle got deleted")
+		}
+
+		return ctrl.Result{}, err
+	} else if !isAllowed(pBundle, s.allowed) {
+		return ctrl.Result{}, s.deleteConfigMaps(&syncContext, req.Name, nil, "physical bundle is not allowed")
+	}
+
+	// trust-manager writes the bundle into the host namespace of the vcluster, if it is targeted
+	key := targetKey(pBundle)
+	pConfigMap := &corev1.ConfigMap{}
+	err = syncContext.PhysicalClient.Get(ctx, types.NamespacedName{Namespace: syncContext.TargetNamespace, Name: pBundle.GetName()}, pConfigMap)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return ctrl.Result{}, s.deleteConfigMaps(&syncContext, req.Name, nil, "physical bundle does not target the vcluster namespace")
+		}
+
+		return ctrl.Result{}, err
+	} else if key == "" || pConfigMap.Labels == nil || pConfigMap.Labels[TrustBundleLabel] != pBundle.GetName() {
+		return ctrl.Result{}, s.deleteConfigMaps(&syncContext, req.Name, nil, "physical bundle does not target a config map")
+	}
+
+	// find the virtual namespaces the bundle is targeted at
+	selector, err := namespaceSelector(pBundle)
+	if err != nil {
+		syncContext.Log.Infof("skip bundle %s, because its namespace selector is invalid: %v", pBundle.GetName(), err)
+		return ctrl.Result{}, nil
+	}
+	vNamespaces := &corev1.NamespaceList{}
+	err = syncContext.VirtualClient.List(ctx, vNamespaces, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	targeted := map[string]bool{}
+	for _, vNamespace := range vNamespaces.Items {
+		if vNamespace.DeletionTimestamp != nil {
+			continue
+		}
+
+		targeted[vNamespace.Name] = true
+		err = s.syncConfigMap(&syncContext, pConfigMap, key, vNamespace.Name)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, s.deleteConfigMaps(&syncContext, req.Name, targeted, "namespace is not targeted by the physical bundle anymore")
+}
+
+// syncConfigMap creates or updates the virtual config map of the bundle within the given namespace.
+// Config maps that already exist and were not created for the bundle are never overwritten.
+func (s *bundleSyncer) syncConfigMap(ctx *context.SyncContext, pConfigMap *corev1.ConfigMap, key, namespace string) error {
+	vConfigMap := &corev1.ConfigMap{}
+	err := ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Namespace: namespace, Name: pConfigMap.Name}, vConfigMap)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		vConfigMap = translate(pConfigMap, key, namespace)
+		ctx.Log.Infof("create virtual config map %s/%s, because physical bundle targets the namespace", vConfigMap.Namespace, vConfigMap.Name)
+		err = metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+			return ctx.VirtualClient.Create(ctx.Context, vConfigMap)
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.records.Record(ctx, vConfigMap)
+	}
+
+	recorded, err := s.records.IsRecorded(ctx, vConfigMap)
+	if err != nil {
+		return err
+	} else if !recorded {
+		ctx.Log.Infof("skip bundle %s in namespace %s, because virtual config map %s/%s already exists", pConfigMap.Name, namespace, vConfigMap.Namespace, vConfigMap.Name)
+		return nil
+	}
+
+	updated := translateUpdate(pConfigMap, vConfigMap, key)
+	if updated == nil {
+		return nil
+	}
+
+	ctx.Log.Infof("update virtual config map %s/%s, because physical bundle has changed", vConfigMap.Namespace, vConfigMap.Name)
+	return metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+		return ctx.VirtualClient.Update(ctx.Context, updated)
+	})
+}
+
+// deleteConfigMaps deletes the recorded virtual config maps of the bundle within all namespaces that
+// are not targeted anymore. Config maps that were replaced within the vcluster are only forgotten.
+func (s *bundleSyncer) deleteConfigMaps(ctx *context.SyncContext, name string, targeted map[string]bool, reason string) error {
+	recorded, err := s.records.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for vName, uid := range recorded {
+		if vName.Name != name || targeted[vName.Namespace] {
+			continue
+		}
+
+		vConfigMap := &corev1.ConfigMap{}
+		err = ctx.VirtualClient.Get(ctx.Context, vName, vConfigMap)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		} else if err == nil && vConfigMap.UID == uid {
+			ctx.Log.Infof("delete virtual config map %s/%s, because %s", vConfigMap.Namespace, vConfigMap.Name, reason)
+			err = metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+				return ctx.VirtualClient.Delete(ctx.Context, vConfigMap)
+			})
+			if err != nil && !kerrors.IsNotFound(err) {
+				return err
+			}
+		}
+
+		err = s.records.Forget(ctx, vName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapNamespaces enqueues all bundles, as the namespace might be targeted by any of them
+func (s *bundleSyncer) mapNamespaces(obj client.Object) []reconcile.Request {
+	pBundles := NewList()
+	err := s.syncContext.PhysicalClient.List(context2.TODO(), pBundles)
+	if err != nil {
+		s.syncContext.Log.Infof("error listing bundles: %v", err)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, pBundle := range pBundles.Items {
+		if isAllowed(&pBundle, s.allowed) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: pBundle.GetName()}})
+		}
+	}
+
+	return requests
+}
+
+// isAllowed returns true if the host bundle is allowed by name or labeled with
+// constants.BundleSyncLabel
+func isAllowed(pBundle client.Object, allowed []string) bool {
+	if pBundle.GetLabels() != nil && pBundle.GetLabels()[constants.BundleSyncLabel] == "true" {
+		return true
+	}
+
+	for _, name := range allowed {
+		if name == pBundle.GetName() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapConfigMaps enqueues the bundle whose name is within the given label of the config map
+func mapConfigMaps(label string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetLabels()[label]}}}
+	}
+}
+
+// NewObject returns an empty trust-manager bundle
+func NewObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(GroupVersionKind)
+	return obj
+}
+
+// NewList returns an empty list of trust-manager bundles
+func NewList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(GroupVersionKind.GroupVersion().WithKind(GroupVersionKind.Kind + "List"))
+	return list
+}
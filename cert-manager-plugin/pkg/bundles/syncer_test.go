@@ -0,0 +1,176 @@
+package bundles
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/records"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const (
+	targetNamespace = "vcluster"
+	bundleName      = "private-ca-bundle"
+)
+
+func TestIsAllowed(t *testing.T) {
+	testCases := []struct {
+		name     string
+		labels   map[string]string
+		allowed  []string
+		expected bool
+	}{
+		{
+			name:     "allowed by name",
+			allowed:  []string{bundleName},
+			expected: true,
+		},
+		{
+			name:     "allowed by label",
+			labels:   map[string]string{constants.BundleSyncLabel: "true"},
+			expected: true,
+		},
+		{
+			name:     "cluster issuer label",
+			labels:   map[string]string{constants.ClusterIssuerSyncLabel: "true"},
+			expected: false,
+		},
+		{
+			name:     "not allowed",
+			allowed:  []string{"other-bundle"},
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		pBundle := NewObject()
+		pBundle.SetName(bundleName)
+		pBundle.SetLabels(testCase.labels)
+		if allowed := isAllowed(pBundle, testCase.allowed); allowed != testCase.expected {
+			t.Errorf("Test case %s: expected allowed %t, got %t", testCase.name, testCase.expected, allowed)
+		}
+	}
+}
+
+func virtualConfigMap(namespace string, uid types.UID) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      bundleName,
+			UID:       uid,
+			Labels:    map[string]string{constants.BundleLabel: bundleName},
+		},
+		Data: map[string]string{"ca.crt": "old"},
+	}
+}
+
+func newTestSyncer(t *testing.T, vObjs []client.Object, recorded map[string]string) (*bundleSyncer, *context.SyncContext) {
+	hostClient := fake.NewClientBuilder().WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: records.New(nil, "bundles").Name()},
+		Data:       recorded,
+	}).Build()
+	ctx := &context.SyncContext{
+		Context:         context2.TODO(),
+		VirtualClient:   fake.NewClientBuilder().WithObjects(vObjs...).Build(),
+		PhysicalClient:  hostClient,
+		TargetNamespace: targetNamespace,
+		Log:             log.New(t.Name()),
+	}
+
+	return &bundleSyncer{records: records.New(hostClient, "bundles")}, ctx
+}
+
+func TestSyncConfigMap(t *testing.T) {
+	pConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: bundleName, Labels: map[string]string{TrustBundleLabel: bundleName}},
+		Data:       map[string]string{"ca.crt": "new"},
+	}
+
+	testCases := []struct {
+		name     string
+		vObj     *corev1.ConfigMap
+		recorded map[string]string
+		expected string
+	}{
+		{
+			name:     "recorded config map",
+			vObj:     virtualConfigMap("default", "uid"),
+			recorded: map[string]string{"default." + bundleName: "uid"},
+			expected: "new",
+		},
+		{
+			name:     "labeled config map of the tenant",
+			vObj:     virtualConfigMap("default", "uid"),
+			expected: "old",
+		},
+		{
+			name:     "replaced config map",
+			vObj:     virtualConfigMap("default", "new-uid"),
+			recorded: map[string]string{"default." + bundleName: "uid"},
+			expected: "old",
+		},
+	}
+
+	for _, testCase := range testCases {
+		s, ctx := newTestSyncer(t, []client.Object{testCase.vObj}, testCase.recorded)
+		err := s.syncConfigMap(ctx, pConfigMap, "ca.crt", "default")
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		vConfigMap := &corev1.ConfigMap{}
+		err = ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Namespace: "default", Name: bundleName}, vConfigMap)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if vConfigMap.Data["ca.crt"] != testCase.expected {
+			t.Errorf("Test case %s: expected data %q, got %q", testCase.name, testCase.expected, vConfigMap.Data["ca.crt"])
+		}
+	}
+}
+
+func TestDeleteConfigMaps(t *testing.T) {
+	vObjs := []client.Object{
+		virtualConfigMap("targeted", "targeted-uid"),
+		virtualConfigMap("recorded", "recorded-uid"),
+		virtualConfigMap("replaced", "new-uid"),
+		virtualConfigMap("tenant", "tenant-uid"),
+	}
+	recorded := map[string]string{
+		"targeted." + bundleName: "targeted-uid",
+		"recorded." + bundleName: "recorded-uid",
+		"replaced." + bundleName: "old-uid",
+		"deleted." + bundleName:  "deleted-uid",
+	}
+	s, ctx := newTestSyncer(t, vObjs, recorded)
+
+	err := s.deleteConfigMaps(ctx, bundleName, map[string]bool{"targeted": true}, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]bool{"targeted": true, "recorded": false, "replaced": true, "tenant": true}
+	for namespace, exists := range expected {
+		err = ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Namespace: namespace, Name: bundleName}, &corev1.ConfigMap{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			t.Fatalf("Unexpected error: %v", err)
+		} else if (err == nil) != exists {
+			t.Errorf("Expected config map in namespace %s to exist %t", namespace, exists)
+		}
+	}
+
+	remaining, err := s.records.List(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(remaining) != 1 || remaining[types.NamespacedName{Namespace: "targeted", Name: bundleName}] != "targeted-uid" {
+		t.Errorf("Expected only the targeted config map to stay recorded, got %v", remaining)
+	}
+}
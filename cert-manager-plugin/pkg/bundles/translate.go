@@ -0,0 +1,83 @@
+package bundles
+
+import (
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// translate creates the virtual config map of the bundle within the given namespace. Only the
+// key of the bundle target is copied.
+func translate(pConfigMap *corev1.ConfigMap, key, namespace string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pConfigMap.Name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				constants.BackwardSyncAnnotation: "true",
+			},
+			Labels: map[string]string{
+				constants.BundleLabel: pConfigMap.Name,
+			},
+		},
+		Data: map[string]string{
+			key: pConfigMap.Data[key],
+		},
+	}
+}
+
+// translateUpdate returns an updated virtual config map if the bundle has changed or the virtual
+// config map was edited. Labels and annotations added within the vcluster are preserved, while
+// the bundle label and the backward sync annotation are restored.
+func translateUpdate(pConfigMap, vConfigMap *corev1.ConfigMap, key string) *corev1.ConfigMap {
+	data := map[string]string{
+		key: pConfigMap.Data[key],
+	}
+	if equality.Semantic.DeepEqual(vConfigMap.Data, data) &&
+		vConfigMap.Labels[constants.BundleLabel] == pConfigMap.Name &&
+		vConfigMap.Annotations[constants.BackwardSyncAnnotation] == "true" {
+		return nil
+	}
+
+	updated := vConfigMap.DeepCopy()
+	updated.Data = data
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+	updated.Labels[constants.BundleLabel] = pConfigMap.Name
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[constants.BackwardSyncAnnotation] = "true"
+	return updated
+}
+
+// targetKey returns the config map key the bundle is written to. If the bundle does not target
+// config maps, an empty string is returned.
+func targetKey(pBundle *unstructured.Unstructured) string {
+	key, _, _ := unstructured.NestedString(pBundle.Object, "spec", "target", "configMap", "key")
+	return key
+}
+
+// namespaceSelector returns the selector of the namespaces the bundle targets. Bundles without
+// a namespace selector target all namespaces.
+func namespaceSelector(pBundle *unstructured.Unstructured) (labels.Selector, error) {
+	rawSelector, found, err := unstructured.NestedMap(pBundle.Object, "spec", "target", "namespaceSelector")
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return labels.Everything(), nil
+	}
+
+	selector := &metav1.LabelSelector{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(rawSelector, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	return metav1.LabelSelectorAsSelector(selector)
+}
@@ -0,0 +1,124 @@
+package bundles
+
+import (
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"reflect"
+	"testing"
+)
+
+func TestTranslateUpdate(t *testing.T) {
+	pConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vcluster", Name: "private-ca-bundle"},
+		Data:       map[string]string{"ca.crt": "new", "other": "value"},
+	}
+	synced := translate(pConfigMap, "ca.crt", "default")
+
+	testCases := []struct {
+		name       string
+		vConfigMap *corev1.ConfigMap
+		expected   *corev1.ConfigMap
+	}{
+		{
+			name:       "unchanged",
+			vConfigMap: synced,
+		},
+		{
+			name: "changed data",
+			vConfigMap: &corev1.ConfigMap{
+				ObjectMeta: synced.ObjectMeta,
+				Data:       map[string]string{"ca.crt": "old", "added": "value"},
+			},
+			expected: synced,
+		},
+		{
+			name: "removed label and annotation",
+			vConfigMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "private-ca-bundle", Labels: map[string]string{"app": "test"}},
+				Data:       synced.Data,
+			},
+			expected: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "default",
+					Name:        "private-ca-bundle",
+					Labels:      map[string]string{"app": "test", constants.BundleLabel: "private-ca-bundle"},
+					Annotations: map[string]string{constants.BackwardSyncAnnotation: "true"},
+				},
+				Data: synced.Data,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		updated := translateUpdate(pConfigMap, testCase.vConfigMap, "ca.crt")
+		if !reflect.DeepEqual(testCase.expected, updated) {
+			t.Errorf("Test case %s: expected %#+v, got %#+v", testCase.name, testCase.expected, updated)
+		}
+	}
+}
+
+func TestNamespaceSelector(t *testing.T) {
+	testCases := []struct {
+		name      string
+		spec      map[string]interface{}
+		labels    labels.Set
+		expected  bool
+		expectErr bool
+	}{
+		{
+			name:     "no selector",
+			spec:     map[string]interface{}{"target": map[string]interface{}{"configMap": map[string]interface{}{"key": "ca.crt"}}},
+			labels:   labels.Set{},
+			expected: true,
+		},
+		{
+			name:     "matching selector",
+			spec:     map[string]interface{}{"target": map[string]interface{}{"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"trust": "enabled"}}}},
+			labels:   labels.Set{"trust": "enabled"},
+			expected: true,
+		},
+		{
+			name:     "not matching selector",
+			spec:     map[string]interface{}{"target": map[string]interface{}{"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"trust": "enabled"}}}},
+			labels:   labels.Set{},
+			expected: false,
+		},
+		{
+			name:      "invalid selector",
+			spec:      map[string]interface{}{"target": map[string]interface{}{"namespaceSelector": map[string]interface{}{"matchExpressions": []interface{}{map[string]interface{}{"key": "trust", "operator": "Invalid"}}}}},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		pBundle := NewObject()
+		pBundle.Object["spec"] = testCase.spec
+		selector, err := namespaceSelector(pBundle)
+		if testCase.expectErr {
+			if err == nil {
+				t.Errorf("Test case %s: expected error, got none", testCase.name)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if selector.Matches(testCase.labels) != testCase.expected {
+			t.Errorf("Test case %s: expected match %t, got %t", testCase.name, testCase.expected, !testCase.expected)
+		}
+	}
+}
+
+func TestTargetKey(t *testing.T) {
+	pBundle := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if key := targetKey(pBundle); key != "" {
+		t.Errorf("Expected no key for bundle without target, got %q", key)
+	}
+
+	pBundle.Object["spec"] = map[string]interface{}{"target": map[string]interface{}{"configMap": map[string]interface{}{"key": "ca.crt"}}}
+	if key := targetKey(pBundle); key != "ca.crt" {
+		t.Errorf("Expected key ca.crt, got %q", key)
+	}
+}
@@ -0,0 +1,60 @@
+package conditions
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition is one of the condition types of the cert-manager objects the plugin sets conditions on
+type Condition interface {
+	certmanagerv1.CertificateCondition | certmanagerv1.CertificateRequestCondition | certmanagerv1.IssuerCondition
+}
+
+// Set replaces the condition of the same type within conditions or appends it. The last transition
+// time of the replaced condition is kept as long as its status does not change.
+func Set[T Condition](conditions []T, condition T) []T {
+	return SetFrom(conditions, conditions, condition)
+}
+
+// SetFrom replaces the condition of the same type within conditions or appends it. The last transition
+// time is taken from the condition of the same type within current, so that a condition can be set on
+// a translated status without flapping between the physical and the virtual condition.
+func SetFrom[T Condition](conditions, current []T, condition T) []T {
+	conditionType, conditionStatus, lastTransitionTime := fields(&condition)
+	now := metav1.Now()
+	*lastTransitionTime = &now
+	for i := range current {
+		currentType, currentStatus, currentTransitionTime := fields(&current[i])
+		if currentType == conditionType && currentStatus == conditionStatus && *currentTransitionTime != nil {
+			*lastTransitionTime = *currentTransitionTime
+			break
+		}
+	}
+
+	for i := range conditions {
+		existingType, _, _ := fields(&conditions[i])
+		if existingType == conditionType {
+			conditions[i] = condition
+			return conditions
+		}
+	}
+
+	return append(conditions, condition)
+}
+
+// fields returns the type, status and a pointer to the last transition time of the condition
+func fields(condition interface{}) (string, cmmeta.ConditionStatus, **metav1.Time) {
+	switch c := condition.(type) {
+	case *certmanagerv1.CertificateCondition:
+		return string(c.Type), c.Status, &c.LastTransitionTime
+	case *certmanagerv1.CertificateRequestCondition:
+		return string(c.Type), c.Status, &c.LastTransitionTime
+	case *certmanagerv1.IssuerCondition:
+		return string(c.Type), c.Status, &c.LastTransitionTime
+	}
+
+	// not reachable, as the condition types are restricted by the Condition constraint
+	var lastTransitionTime *metav1.Time
+	return "", "", &lastTransitionTime
+}
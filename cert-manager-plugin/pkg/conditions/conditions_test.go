@@ -0,0 +1,98 @@
+package conditions
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestSetFrom(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	testCases := []struct {
+		name       string
+		conditions []certmanagerv1.IssuerCondition
+		current    []certmanagerv1.IssuerCondition
+		condition  certmanagerv1.IssuerCondition
+
+		expectedLength     int
+		expectedTransition bool
+	}{
+		{
+			name:               "Append condition",
+			conditions:         []certmanagerv1.IssuerCondition{},
+			condition:          certmanagerv1.IssuerCondition{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse},
+			expectedLength:     1,
+			expectedTransition: true,
+		},
+		{
+			name:               "Keep transition time of unchanged status",
+			conditions:         []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse, LastTransitionTime: &before}},
+			current:            []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse, LastTransitionTime: &before}},
+			condition:          certmanagerv1.IssuerCondition{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse, Message: "changed"},
+			expectedLength:     1,
+			expectedTransition: false,
+		},
+		{
+			name:               "Update transition time of changed status",
+			conditions:         []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionTrue, LastTransitionTime: &before}},
+			current:            []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionTrue, LastTransitionTime: &before}},
+			condition:          certmanagerv1.IssuerCondition{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse},
+			expectedLength:     1,
+			expectedTransition: true,
+		},
+		{
+			name:               "Take transition time from current conditions",
+			conditions:         []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionTrue, LastTransitionTime: &before}},
+			current:            []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse, LastTransitionTime: &before}},
+			condition:          certmanagerv1.IssuerCondition{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse},
+			expectedLength:     1,
+			expectedTransition: false,
+		},
+		{
+			name:               "Keep conditions of other types",
+			conditions:         []certmanagerv1.IssuerCondition{{Type: "Other", Status: cmmeta.ConditionTrue, LastTransitionTime: &before}},
+			current:            []certmanagerv1.IssuerCondition{{Type: "Other", Status: cmmeta.ConditionFalse, LastTransitionTime: &before}},
+			condition:          certmanagerv1.IssuerCondition{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse},
+			expectedLength:     2,
+			expectedTransition: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		conditions := SetFrom(testCase.conditions, testCase.current, testCase.condition)
+		if len(conditions) != testCase.expectedLength {
+			t.Errorf("Test case %s: expected %d conditions, got %d", testCase.name, testCase.expectedLength, len(conditions))
+			continue
+		}
+
+		var condition *certmanagerv1.IssuerCondition
+		for i := range conditions {
+			if conditions[i].Type == testCase.condition.Type {
+				condition = &conditions[i]
+			}
+		}
+		if condition == nil {
+			t.Errorf("Test case %s: expected condition %s, got none", testCase.name, testCase.condition.Type)
+		} else if condition.Status != testCase.condition.Status || condition.Message != testCase.condition.Message {
+			t.Errorf("Test case %s: expected condition %#+v, got %#+v", testCase.name, testCase.condition, *condition)
+		} else if condition.LastTransitionTime == nil || condition.LastTransitionTime.Equal(&before) == testCase.expectedTransition {
+			t.Errorf("Test case %s: expected transition %t, got last transition time %v", testCase.name, testCase.expectedTransition, condition.LastTransitionTime)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions := []certmanagerv1.CertificateCondition{{Type: certmanagerv1.CertificateConditionReady, Status: cmmeta.ConditionTrue, LastTransitionTime: &before}}
+	conditions = Set(conditions, certmanagerv1.CertificateCondition{Type: certmanagerv1.CertificateConditionReady, Status: cmmeta.ConditionTrue, Reason: "Ready"})
+	if len(conditions) != 1 || conditions[0].Reason != "Ready" || !conditions[0].LastTransitionTime.Equal(&before) {
+		t.Errorf("expected the ready condition to be replaced and its transition time kept, got %#+v", conditions)
+	}
+
+	requestConditions := Set([]certmanagerv1.CertificateRequestCondition{}, certmanagerv1.CertificateRequestCondition{Type: certmanagerv1.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse})
+	if len(requestConditions) != 1 || requestConditions[0].LastTransitionTime == nil {
+		t.Errorf("expected the ready condition to be appended with a transition time, got %#+v", requestConditions)
+	}
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"sigs.k8s.io/yaml"
+	"strings"
+	"time"
+)
+
+const (
+	// ConfigEnv is the environment variable the plugin configuration is read from.
+	// It is set through the env section of the plugin values in plugin.yaml
+	ConfigEnv = "PLUGIN_CONFIG"
+)
+
+// Config holds the plugin configuration
+type Config struct {
+	// ClusterIssuers configures which host cluster issuers are mirrored into the vcluster
+	ClusterIssuers ClusterIssuers `json:"clusterIssuers,omitempty"`
+
+	// HTTP01 configures how http01 solvers of issuers are translated to the host cluster
+	HTTP01 HTTP01 `json:"http01,omitempty"`
+
+	// Ingresses configures how ingress-shim annotations of ingresses are translated to the host cluster
+	Ingresses Ingresses `json:"ingresses,omitempty"`
+
+	// Gateways configures how gateway-shim annotations of gateways are translated to the host cluster
+	Gateways Gateways `json:"gateways,omitempty"`
+
+	// ExternalIssuers are out-of-tree issuers that should be synced to the host cluster
+	ExternalIssuers ExternalIssuers `json:"externalIssuers,omitempty"`
+
+	// GarbageCollection configures the removal of host objects whose virtual objects are gone
+	GarbageCollection GarbageCollection `json:"garbageCollection,omitempty"`
+
+	// Quotas limits the certificates that can be issued for the vcluster
+	Quotas Quotas `json:"quotas,omitempty"`
+
+	// DomainPolicy restricts the domains certificates of the vcluster can be issued for
+	DomainPolicy DomainPolicy `json:"domainPolicy,omitempty"`
+
+	// IssuerPolicy restricts the issuers that can be created within the vcluster
+	IssuerPolicy IssuerPolicy `json:"issuerPolicy,omitempty"`
+
+	// Metrics configures the prometheus metrics endpoint of the plugin
+	Metrics Metrics `json:"metrics,omitempty"`
+
+	// SecretImports configures host secrets that are imported read-only into the vcluster
+	SecretImports SecretImports `json:"secretImports,omitempty"`
+
+	// Bundles configures which trust-manager bundles of the host cluster are distributed into the vcluster
+	Bundles Bundles `json:"bundles,omitempty"`
+}
+
+type ClusterIssuers struct {
+	// Allowed are the names of host cluster issuers that should be mirrored into the vcluster.
+	// Host cluster issuers labeled with constants.ClusterIssuerSyncLabel are mirrored as well.
+	Allowed []string `json:"allowed,omitempty"`
+
+	// ClusterResourceNamespace is the virtual namespace secrets referenced by cluster issuers
+	// created within the vcluster are looked up in. Defaults to cert-manager
+	ClusterResourceNamespace string `json:"clusterResourceNamespace,omitempty"`
+}
+
+type HTTP01 struct {
+	// IngressClasses maps ingress classes used by http01 solvers within the vcluster to
+	// ingress classes of the host cluster. Classes that are not mapped are used as is.
+	IngressClasses map[string]string `json:"ingressClasses,omitempty"`
+}
+
+type Ingresses struct {
+	// DefaultIssuer is the issuer within the vcluster that is used for ingresses annotated with
+	// kubernetes.io/tls-acme: "true" that do not reference an issuer. If empty, no certificates
+	// are issued for these ingresses, as the host cluster default issuer is never used.
+	DefaultIssuer cmmeta.ObjectReference `json:"defaultIssuer,omitempty"`
+}
+
+type Gateways struct {
+	// Synced has to be set, if gateways of the vcluster are synced to the host cluster by another
+	// plugin. Neither vcluster nor this plugin sync gateways, so their gateway-shim annotations are
+	// only translated and certificates of gateways only synced back if this is true.
+	Synced bool `json:"synced,omitempty"`
+}
+
+type GarbageCollection struct {
+	// Interval is the time between two garbage collection passes. Defaults to 10m, 0 disables
+	// the garbage collection.
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// GracePeriod is the time a host object has to be orphaned before it is deleted. Defaults to 10m
+	GracePeriod metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// DryRun only records events for orphaned host objects instead of deleting them
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type Quotas struct {
+	// MaxCertificates is the maximum number of certificates synced to the host cluster. 0 means unlimited
+	MaxCertificates int `json:"maxCertificates,omitempty"`
+
+	// MaxDNSNames is the maximum number of dns names of a single certificate. 0 means unlimited
+	MaxDNSNames int `json:"maxDNSNames,omitempty"`
+
+	// MaxIssuancesPerHour is the maximum number of certificate requests created within the host
+	// namespace per hour, including renewals. 0 means unlimited
+	MaxIssuancesPerHour int `json:"maxIssuancesPerHour,omitempty"`
+
+	// AllowedDomainSuffixes are the domains certificates may be issued for, including their
+	// subdomains. If empty, all domains are allowed.
+	AllowedDomainSuffixes []string `json:"allowedDomainSuffixes,omitempty"`
+}
+
+type DomainPolicy struct {
+	// DNSNames are the allowed dns names, either exact names or wildcards like *.example.com,
+	// which allow all subdomains of example.com. Hosts of uris and domains of email addresses
+	// have to match them as well.
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// IPRanges are the CIDRs ip addresses of certificates have to be within
+	IPRanges []string `json:"ipRanges,omitempty"`
+
+	// ConfigMap is the name of a config map within the host namespace of the vcluster that holds
+	// additional dnsNames and ipRanges, separated by new lines or commas
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// AuditOnly only logs violations instead of refusing to sync the certificates
+	AuditOnly bool `json:"auditOnly,omitempty"`
+}
+
+type IssuerPolicy struct {
+	// AllowedTypes are the issuer types that can be created within the vcluster, which are ACME,
+	// CA, SelfSigned, Vault and Venafi, or the kinds of external issuers. If empty, all types are allowed.
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+
+	// AllowedACMEServers are the server urls acme issuers can use. If empty, all servers are allowed.
+	AllowedACMEServers []string `json:"allowedACMEServers,omitempty"`
+}
+
+type Metrics struct {
+	// BindAddress is the address the prometheus metrics are served on, e.g. :8081. If empty,
+	// no metrics are served.
+	BindAddress string `json:"bindAddress,omitempty"`
+}
+
+type SecretImports struct {
+	// Secrets are the host secrets that are imported into the vcluster
+	Secrets []SecretImport `json:"secrets,omitempty"`
+
+	// ConfigMap is the name of a config map within the host namespace of the vcluster that holds
+	// additional imports, one per line or separated by commas as <from>=<to>
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// AllowedNamespaces are the host namespaces secrets can be imported from. Secrets of other
+	// namespaces are only imported if they are annotated with constants.ImportToAnnotation
+	// listing this vcluster as <host namespace>/<vcluster name>.
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
+	// Interval is the time between two checks of the host secrets for renewals. Defaults to 1m
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+type SecretImport struct {
+	// From is the host secret as namespace/name
+	From string `json:"from,omitempty"`
+
+	// To is the virtual secret as namespace/name
+	To string `json:"to,omitempty"`
+}
+
+// NamespacedNames returns the host and virtual secret of the import
+func (s SecretImport) NamespacedNames() (types.NamespacedName, types.NamespacedName, error) {
+	from, err := parseNamespacedName(s.From)
+	if err != nil {
+		return types.NamespacedName{}, types.NamespacedName{}, fmt.Errorf("secret import from: %v", err)
+	}
+	to, err := parseNamespacedName(s.To)
+	if err != nil {
+		return types.NamespacedName{}, types.NamespacedName{}, fmt.Errorf("secret import to: %v", err)
+	}
+
+	return from, to, nil
+}
+
+func parseNamespacedName(name string) (types.NamespacedName, error) {
+	splitted := strings.Split(name, "/")
+	if len(splitted) != 2 || splitted[0] == "" || splitted[1] == "" {
+		return types.NamespacedName{}, fmt.Errorf("%q is not in the format namespace/name", name)
+	}
+
+	return types.NamespacedName{Namespace: splitted[0], Name: splitted[1]}, nil
+}
+
+type Bundles struct {
+	// Allowed are the names of host bundles that are copied into the virtual namespaces they target.
+	// Host bundles labeled with constants.BundleSyncLabel are copied as well.
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+type ExternalIssuer struct {
+	// Group, Version and Kind of the namespaced issuer, e.g. awspca.cert-manager.io, v1beta1 and AWSPCAIssuer.
+	// Cluster scoped issuers, such as the AWSPCAClusterIssuer, are refused when the plugin starts, as they
+	// cannot be translated into the host namespace of the vcluster.
+	Group   string `json:"group,omitempty"`
+	Version string `json:"version,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+
+	// SecretRefs are the dot separated paths of secret names within the issuer, e.g.
+	// spec.secretRef.name. The referenced secrets are synced to the host cluster.
+	SecretRefs []string `json:"secretRefs,omitempty"`
+}
+
+// GroupVersionKind returns the group version kind of the external issuer
+func (e ExternalIssuer) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: e.Group, Version: e.Version, Kind: e.Kind}
+}
+
+type ExternalIssuers []ExternalIssuer
+
+// Contains returns true if the given group and kind is a configured external issuer
+func (e ExternalIssuers) Contains(group, kind string) bool {
+	for _, externalIssuer := range e {
+		if externalIssuer.Group == group && externalIssuer.Kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Load parses the plugin configuration from the environment
+func Load() (*Config, error) {
+	config := &Config{
+		ClusterIssuers: ClusterIssuers{
+			ClusterResourceNamespace: "cert-manager",
+		},
+		GarbageCollection: GarbageCollection{
+			Interval:    metav1.Duration{Duration: 10 * time.Minute},
+			GracePeriod: metav1.Duration{Duration: 10 * time.Minute},
+		},
+		SecretImports: SecretImports{
+			Interval: metav1.Duration{Duration: time.Minute},
+		},
+	}
+	raw := os.Getenv(ConfigEnv)
+	if raw == "" {
+		return config, nil
+	}
+
+	err := yaml.Unmarshal([]byte(raw), config)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %v", ConfigEnv, err)
+	}
+	for _, externalIssuer := range config.ExternalIssuers {
+		if externalIssuer.Group == "" || externalIssuer.Version == "" || externalIssuer.Kind == "" {
+			return nil, fmt.Errorf("parse %s: external issuer %s is missing group, version or kind", ConfigEnv, externalIssuer.GroupVersionKind().String())
+		}
+	}
+	for _, secretImport := range config.SecretImports.Secrets {
+		_, _, err := secretImport.NamespacedNames()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %v", ConfigEnv, err)
+		}
+	}
+
+	return config, nil
+}
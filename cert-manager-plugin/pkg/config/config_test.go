@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name        string
+		raw         string
+		expectError bool
+	}{
+		{
+			name: "Empty config",
+		},
+		{
+			name: "Secret import",
+			raw:  "secretImports:\n  secrets:\n    - from: platform/wildcard-tls\n      to: default/wildcard-tls",
+		},
+		{
+			name:        "Invalid secret import",
+			raw:         "secretImports:\n  secrets:\n    - from: wildcard-tls\n      to: default/wildcard-tls",
+			expectError: true,
+		},
+		{
+			name:        "External issuer without kind",
+			raw:         "externalIssuers:\n  - group: awspca.cert-manager.io\n    version: v1beta1",
+			expectError: true,
+		},
+	}
+
+	defer os.Unsetenv(ConfigEnv)
+	for _, testCase := range testCases {
+		err := os.Setenv(ConfigEnv, testCase.raw)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		cfg, err := Load()
+		if testCase.expectError && err == nil {
+			t.Errorf("Test case %s: expected error, got config %v", testCase.name, cfg)
+		} else if !testCase.expectError && err != nil {
+			t.Errorf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+	}
+}
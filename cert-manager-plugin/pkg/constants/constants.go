@@ -5,6 +5,29 @@ const (
 
 	BackwardSyncAnnotation = "cert-manager.vcluster.loft.sh/sync-backward"
 
-	IssuerAnnotation        = "cert-manager.io/issuer"
-	ClusterIssuerAnnotation = "cert-manager.io/cluster-issuer"
+	TemplateAnnotationsAnnotation = "cert-manager.vcluster.loft.sh/template-annotations"
+	TemplateLabelsAnnotation      = "cert-manager.vcluster.loft.sh/template-labels"
+
+	ClusterIssuerSyncLabel = "cert-manager.vcluster.loft.sh/sync-to-vcluster"
+
+	ImportedLabel          = "cert-manager.vcluster.loft.sh/imported"
+	ImportedFromAnnotation = "cert-manager.vcluster.loft.sh/imported-from"
+	ImportToAnnotation     = "cert-manager.vcluster.loft.sh/import-to"
+
+	BundleLabel     = "cert-manager.vcluster.loft.sh/bundle"
+	BundleSyncLabel = "cert-manager.vcluster.loft.sh/sync-bundle-to-vcluster"
+
+	ReferencesAnnotation = "cert-manager.vcluster.loft.sh/references"
+	SyncedLabel          = "cert-manager.vcluster.loft.sh/synced"
+
+	OrphanedSinceAnnotation = "cert-manager.vcluster.loft.sh/orphaned-since"
+
+	RequesterAnnotation = "cert-manager.vcluster.loft.sh/requester-namespace"
+
+	IssuerAnnotation             = "cert-manager.io/issuer"
+	ClusterIssuerAnnotation      = "cert-manager.io/cluster-issuer"
+	IssuerKindAnnotation         = "cert-manager.io/issuer-kind"
+	IssuerGroupAnnotation        = "cert-manager.io/issuer-group"
+	TLSACMEAnnotation            = "kubernetes.io/tls-acme"
+	HTTP01IngressClassAnnotation = "acme.cert-manager.io/http01-ingress-class"
 )
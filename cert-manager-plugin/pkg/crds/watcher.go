@@ -0,0 +1,249 @@
+package crds
+
+import (
+	context2 "context"
+	"fmt"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1clientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apiextensionsv1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusConfigMapPrefix is the name prefix of the config map within the host namespace that
+	// holds the availability of the crds the syncers depend on
+	StatusConfigMapPrefix = "cert-manager-plugin-status"
+
+	// StatusAvailable and StatusMissing are the values of the status config map
+	StatusAvailable = "Available"
+	StatusMissing   = "Missing"
+
+	// syncInterval is the interval the host crds are checked for changes
+	syncInterval = time.Minute
+)
+
+// Watcher checks which crds exist within the host cluster and keeps their copies within the vcluster
+// up to date. Syncers of missing crds are not registered, once the availability of a crd changes the
+// plugin is stopped, so that it is restarted with the syncers registered or removed.
+type Watcher struct {
+	physicalCRDs apiextensionsv1client.CustomResourceDefinitionInterface
+	virtualCRDs  apiextensionsv1client.CustomResourceDefinitionInterface
+
+	// kindExists and kindToResource look up kinds through the discovery of the host cluster
+	kindExists     func(gvk schema.GroupVersionKind) (bool, error)
+	kindToResource func(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error)
+
+	// stop cancels the context of the plugin
+	stop context2.CancelFunc
+
+	// available holds the host availability of all kinds asked for
+	available     map[schema.GroupVersionKind]bool
+	availableLock sync.Mutex
+
+	// err is the reason the plugin was stopped
+	err     error
+	errLock sync.Mutex
+}
+
+// NewWatcher creates a crd watcher, which calls stop once the availability of a watched kind
+// changes. stop has to cancel the context of the plugin.
+func NewWatcher(ctx *context.RegisterContext, stop context2.CancelFunc) (*Watcher, error) {
+	physicalConfig := ctx.PhysicalManager.GetConfig()
+	pClient, err := apiextensionsv1clientset.NewForConfig(physicalConfig)
+	if err != nil {
+		return nil, err
+	}
+	vClient, err := apiextensionsv1clientset.NewForConfig(ctx.VirtualManager.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		physicalCRDs: pClient.ApiextensionsV1().CustomResourceDefinitions(),
+		virtualCRDs:  vClient.ApiextensionsV1().CustomResourceDefinitions(),
+
+		kindExists: func(gvk schema.GroupVersionKind) (bool, error) {
+			return translate.KindExists(physicalConfig, gvk)
+		},
+		kindToResource: func(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+			return translate.ConvertKindToResource(physicalConfig, gvk)
+		},
+
+		stop:      stop,
+		available: map[schema.GroupVersionKind]bool{},
+	}, nil
+}
+
+// Available returns true if all given kinds exist within the host cluster. The kinds are watched
+// from then on.
+func (w *Watcher) Available(gvks ...schema.GroupVersionKind) (bool, error) {
+	w.availableLock.Lock()
+	defer w.availableLock.Unlock()
+
+	allAvailable := true
+	for _, gvk := range gvks {
+		available, err := w.kindExists(gvk)
+		if err != nil {
+			return false, fmt.Errorf("check host cluster kind %s: %v", gvk.String(), err)
+		}
+
+		w.available[gvk] = available
+		allAvailable = allAvailable && available
+	}
+
+	return allAvailable, nil
+}
+
+func (w *Watcher) Name() string {
+	return "crd-watcher"
+}
+
+var _ syncer.ControllerStarter = &Watcher{}
+
+func (w *Watcher) Register(ctx *context.RegisterContext) error {
+	syncContext := context.ConvertContext(ctx, w.Name())
+	go wait.Until(func() {
+		err := w.sync(syncContext)
+		if err != nil {
+			syncContext.Log.Infof("error syncing crds: %v", err)
+		}
+	}, syncInterval, ctx.Context.Done())
+	return nil
+}
+
+// Err returns the reason the watcher stopped the plugin or nil if it did not stop the plugin
+func (w *Watcher) Err() error {
+	w.errLock.Lock()
+	defer w.errLock.Unlock()
+
+	return w.err
+}
+
+// sync stops the plugin if the availability of a kind has changed and updates the crds within the
+// vcluster whose host crds have changed
+func (w *Watcher) sync(ctx *context.SyncContext) error {
+	w.availableLock.Lock()
+	defer w.availableLock.Unlock()
+
+	for gvk, wasAvailable := range w.available {
+		available, err := w.kindExists(gvk)
+		if err != nil {
+			return err
+		} else if available != wasAvailable {
+			ctx.Log.Infof("kind %s is %s within the host cluster now, stopping the plugin to update the syncers", gvk.String(), availability(available))
+			w.errLock.Lock()
+			w.err = fmt.Errorf("kind %s is %s within the host cluster", gvk.String(), availability(available))
+			w.errLock.Unlock()
+			w.stop()
+			return nil
+		} else if !available {
+			continue
+		}
+
+		err = w.updateCRD(ctx, gvk)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.updateStatus(ctx)
+}
+
+// updateCRD copies the spec of the host crd into the vcluster if it has changed
+func (w *Watcher) updateCRD(ctx *context.SyncContext, gvk schema.GroupVersionKind) error {
+	gvr, err := w.kindToResource(gvk)
+	if err != nil {
+		return err
+	}
+
+	pCRD, err := w.physicalCRDs.Get(ctx.Context, gvr.GroupResource().String(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// crds that do not exist within the vcluster yet are created by the syncers
+	vCRD, err := w.virtualCRDs.Get(ctx.Context, gvr.GroupResource().String(), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	} else if equality.Semantic.DeepEqual(pCRD.Spec, vCRD.Spec) {
+		return nil
+	}
+
+	newCRD := vCRD.DeepCopy()
+	newCRD.Spec = pCRD.Spec
+	ctx.Log.Infof("update virtual crd %s, because physical crd has changed", vCRD.Name)
+	_, err = w.virtualCRDs.Update(ctx.Context, newCRD, metav1.UpdateOptions{})
+	return err
+}
+
+// updateStatus writes the availability of the kinds into the status config map within the host namespace
+func (w *Watcher) updateStatus(ctx *context.SyncContext) error {
+	data := map[string]string{}
+	for gvk, available := range w.available {
+		data[gvk.GroupKind().String()] = availability(available)
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: ctx.TargetNamespace, Name: StatusConfigMapName()}, configMap)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		configMap.Namespace = ctx.TargetNamespace
+		configMap.Name = StatusConfigMapName()
+		configMap.Data = data
+		ctx.Log.Infof("create physical config map %s/%s", configMap.Namespace, configMap.Name)
+		return ctx.PhysicalClient.Create(ctx.Context, configMap)
+	} else if equality.Semantic.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+
+	newConfigMap := configMap.DeepCopy()
+	newConfigMap.Data = data
+	ctx.Log.Infof("update physical config map %s/%s, because crd availability has changed: %s", configMap.Namespace, configMap.Name, missingKinds(w.available))
+	return ctx.PhysicalClient.Update(ctx.Context, newConfigMap)
+}
+
+// StatusConfigMapName returns the name of the status config map of the vcluster
+func StatusConfigMapName() string {
+	return translate.SafeConcatName(StatusConfigMapPrefix, translate.Suffix)
+}
+
+func availability(available bool) string {
+	if available {
+		return StatusAvailable
+	}
+
+	return StatusMissing
+}
+
+func missingKinds(available map[schema.GroupVersionKind]bool) string {
+	missing := []string{}
+	for gvk, isAvailable := range available {
+		if !isAvailable {
+			missing = append(missing, gvk.GroupKind().String())
+		}
+	}
+	if len(missing) == 0 {
+		return "all kinds are available"
+	}
+
+	sort.Strings(missing)
+	return fmt.Sprintf("missing kinds %v", missing)
+}
@@ -0,0 +1,233 @@
+package crds
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1client "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const targetNamespace = "vcluster"
+
+var (
+	certificateKind     = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	certificateResource = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	bundleKind          = schema.GroupVersionKind{Group: "trust.cert-manager.io", Version: "v1alpha1", Kind: "Bundle"}
+)
+
+// testCRDs holds crds in memory and counts their updates
+type testCRDs struct {
+	apiextensionsv1client.CustomResourceDefinitionInterface
+
+	crds    map[string]*apiextensionsv1.CustomResourceDefinition
+	updates int
+}
+
+func (c *testCRDs) Get(ctx context2.Context, name string, opts metav1.GetOptions) (*apiextensionsv1.CustomResourceDefinition, error) {
+	crd, ok := c.crds[name]
+	if !ok {
+		return nil, kerrors.NewNotFound(apiextensionsv1.Resource("customresourcedefinitions"), name)
+	}
+
+	return crd.DeepCopy(), nil
+}
+
+func (c *testCRDs) Update(ctx context2.Context, crd *apiextensionsv1.CustomResourceDefinition, opts metav1.UpdateOptions) (*apiextensionsv1.CustomResourceDefinition, error) {
+	c.crds[crd.Name] = crd.DeepCopy()
+	c.updates++
+	return crd, nil
+}
+
+func newTestCRDs(crds ...*apiextensionsv1.CustomResourceDefinition) *testCRDs {
+	c := &testCRDs{crds: map[string]*apiextensionsv1.CustomResourceDefinition{}}
+	for _, crd := range crds {
+		c.crds[crd.Name] = crd
+	}
+	return c
+}
+
+func certificateCRD(versions ...string) *apiextensionsv1.CustomResourceDefinition {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: certificateResource.GroupResource().String()},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: certificateResource.Group,
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Kind: certificateKind.Kind, Plural: certificateResource.Resource},
+			Scope: apiextensionsv1.NamespaceScoped,
+		},
+	}
+	for _, version := range versions {
+		crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: version, Served: true})
+	}
+	return crd
+}
+
+func newTestWatcher(physicalCRDs, virtualCRDs *testCRDs, existing map[schema.GroupVersionKind]bool) (*Watcher, *bool) {
+	stopped := false
+	return &Watcher{
+		physicalCRDs: physicalCRDs,
+		virtualCRDs:  virtualCRDs,
+
+		kindExists: func(gvk schema.GroupVersionKind) (bool, error) {
+			return existing[gvk], nil
+		},
+		kindToResource: func(gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+			return certificateResource, nil
+		},
+
+		stop:      func() { stopped = true },
+		available: map[schema.GroupVersionKind]bool{},
+	}, &stopped
+}
+
+func newTestSyncContext(t *testing.T) *context.SyncContext {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	return &context.SyncContext{
+		Context:         context2.TODO(),
+		PhysicalClient:  fake.NewClientBuilder().WithScheme(scheme).Build(),
+		TargetNamespace: targetNamespace,
+		Log:             log.New(t.Name()),
+	}
+}
+
+func TestUpdateCRD(t *testing.T) {
+	testCases := []struct {
+		name        string
+		physicalCRD *apiextensionsv1.CustomResourceDefinition
+		virtualCRD  *apiextensionsv1.CustomResourceDefinition
+
+		expectedUpdates int
+	}{
+		{
+			name:            "Changed host crd",
+			physicalCRD:     certificateCRD("v1", "v2"),
+			virtualCRD:      certificateCRD("v1"),
+			expectedUpdates: 1,
+		},
+		{
+			name:        "Unchanged host crd",
+			physicalCRD: certificateCRD("v1"),
+			virtualCRD:  certificateCRD("v1"),
+		},
+		{
+			name:        "Crd missing within the vcluster",
+			physicalCRD: certificateCRD("v1"),
+		},
+	}
+
+	for _, testCase := range testCases {
+		virtualCRDs := newTestCRDs()
+		if testCase.virtualCRD != nil {
+			virtualCRDs = newTestCRDs(testCase.virtualCRD)
+		}
+		w, _ := newTestWatcher(newTestCRDs(testCase.physicalCRD), virtualCRDs, nil)
+
+		err := w.updateCRD(newTestSyncContext(t), certificateKind)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if virtualCRDs.updates != testCase.expectedUpdates {
+			t.Errorf("Test case %s: expected %d updates, got %d", testCase.name, testCase.expectedUpdates, virtualCRDs.updates)
+		}
+		if testCase.virtualCRD != nil && !reflect.DeepEqual(virtualCRDs.crds[testCase.virtualCRD.Name].Spec, testCase.physicalCRD.Spec) {
+			t.Errorf("Test case %s: expected spec of the host crd, got %v", testCase.name, virtualCRDs.crds[testCase.virtualCRD.Name].Spec)
+		}
+	}
+
+	// missing host crds are an error
+	w, _ := newTestWatcher(newTestCRDs(), newTestCRDs(certificateCRD("v1")), nil)
+	err := w.updateCRD(newTestSyncContext(t), certificateKind)
+	if !kerrors.IsNotFound(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestUpdateStatus(t *testing.T) {
+	ctx := newTestSyncContext(t)
+	w, _ := newTestWatcher(newTestCRDs(), newTestCRDs(), nil)
+	w.available[certificateKind] = true
+	w.available[bundleKind] = false
+
+	getConfigMap := func() *corev1.ConfigMap {
+		configMap := &corev1.ConfigMap{}
+		err := ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: targetNamespace, Name: StatusConfigMapName()}, configMap)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return configMap
+	}
+
+	// the config map is created
+	err := w.updateStatus(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	created := getConfigMap()
+	expected := map[string]string{"Certificate.cert-manager.io": StatusAvailable, "Bundle.trust.cert-manager.io": StatusMissing}
+	if !reflect.DeepEqual(created.Data, expected) {
+		t.Errorf("Expected data %v, got %v", expected, created.Data)
+	}
+
+	// the unchanged config map is not updated
+	err = w.updateStatus(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if unchanged := getConfigMap(); unchanged.ResourceVersion != created.ResourceVersion {
+		t.Errorf("Expected config map not to be updated")
+	}
+
+	// the config map is updated once the availability changes
+	w.available[bundleKind] = true
+	err = w.updateStatus(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected["Bundle.trust.cert-manager.io"] = StatusAvailable
+	if updated := getConfigMap(); !reflect.DeepEqual(updated.Data, expected) {
+		t.Errorf("Expected data %v, got %v", expected, updated.Data)
+	}
+}
+
+func TestSync(t *testing.T) {
+	existing := map[schema.GroupVersionKind]bool{certificateKind: true}
+	virtualCRDs := newTestCRDs(certificateCRD("v1"))
+	w, stopped := newTestWatcher(newTestCRDs(certificateCRD("v1", "v2")), virtualCRDs, existing)
+	available, err := w.Available(certificateKind, bundleKind)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if available {
+		t.Errorf("Expected bundles to be missing")
+	}
+
+	// crds are updated while the availability is unchanged
+	err = w.sync(newTestSyncContext(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *stopped || w.Err() != nil || virtualCRDs.updates != 1 {
+		t.Errorf("Expected crd to be updated without stopping the plugin, got stopped %t, error %v and %d updates", *stopped, w.Err(), virtualCRDs.updates)
+	}
+
+	// the plugin is stopped once a crd is installed
+	existing[bundleKind] = true
+	err = w.sync(newTestSyncContext(t))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !*stopped || w.Err() == nil {
+		t.Errorf("Expected plugin to be stopped with an error, got stopped %t and error %v", *stopped, w.Err())
+	}
+}
@@ -0,0 +1,254 @@
+package events
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"strings"
+)
+
+// Kind is a host object kind whose events are forwarded to the virtual objects
+type Kind struct {
+	// Translator maps the physical objects to their virtual objects, usually the syncer of the kind
+	Translator translator.NameTranslator
+
+	// Physical is an empty physical object
+	Physical client.Object
+
+	// Virtual is an empty virtual object
+	Virtual client.Object
+}
+
+// New creates an event forwarder that re-emits events of host objects, such as the failure
+// reasons of cert-manager, on the virtual objects within the vcluster
+func New(kinds ...Kind) syncer.Base {
+	return &forwarder{
+		kinds: kinds,
+	}
+}
+
+type forwarder struct {
+	kinds []Kind
+
+	// groupKinds holds the group kind of each physical object kind
+	groupKinds []schema.GroupKind
+
+	syncContext *context.SyncContext
+}
+
+func (f *forwarder) Name() string {
+	return "event-forwarder"
+}
+
+var _ syncer.ControllerStarter = &forwarder{}
+
+func (f *forwarder) Register(ctx *context.RegisterContext) error {
+	for _, kind := range f.kinds {
+		gvk, err := apiutil.GVKForObject(kind.Physical, ctx.PhysicalManager.GetScheme())
+		if err != nil {
+			return err
+		}
+
+		f.groupKinds = append(f.groupKinds, gvk.GroupKind())
+	}
+
+	f.syncContext = context.ConvertContext(ctx, f.Name())
+	return ctrl.NewControllerManagedBy(ctx.PhysicalManager).
+		Named(f.Name()).
+		For(&corev1.Event{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			pEvent, ok := obj.(*corev1.Event)
+			return ok && len(f.kindsOf(pEvent)) > 0
+		}))).
+		Complete(f)
+}
+
+func (f *forwarder) Reconcile(ctx context2.Context, req ctrl.Request) (ctrl.Result, error) {
+	syncContext := *f.syncContext
+	syncContext.Context = ctx
+	syncContext.Log = log.NewFromExisting(f.syncContext.Log.Base(), req.Name)
+
+	// get physical event
+	pEvent := &corev1.Event{}
+	err := syncContext.PhysicalClient.Get(ctx, req.NamespacedName, pEvent)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	// find the virtual object the event belongs to
+	pObj, vObj, err := f.involvedObjects(&syncContext, pEvent)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if vObj == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, f.forward(&syncContext, pEvent, pObj, vObj)
+}
+
+// forward creates or updates the virtual event of the physical event. Events are deduplicated
+// by their count, so repeated events only increase the count of the virtual event.
+func (f *forwarder) forward(ctx *context.SyncContext, pEvent *corev1.Event, pObj, vObj client.Object) error {
+	vEvent := &corev1.Event{}
+	err := ctx.VirtualClient.Get(ctx.Context, virtualEventName(pEvent, vObj), vEvent)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		vEvent = translate(pEvent, pObj, vObj)
+		ctx.Log.Infof("create virtual event %s/%s for %s %s", vEvent.Namespace, vEvent.Name, vEvent.InvolvedObject.Kind, vObj.GetName())
+		err = ctx.VirtualClient.Create(ctx.Context, vEvent)
+		if kerrors.IsAlreadyExists(err) {
+			return nil
+		}
+
+		return err
+	} else if vEvent.Count >= pEvent.Count {
+		return nil
+	}
+
+	updated := translate(pEvent, pObj, vObj)
+	newEvent := vEvent.DeepCopy()
+	newEvent.Count = updated.Count
+	newEvent.Message = updated.Message
+	newEvent.LastTimestamp = updated.LastTimestamp
+	newEvent.Series = updated.Series
+	ctx.Log.Infof("update virtual event %s/%s, because physical event count has changed", vEvent.Namespace, vEvent.Name)
+	return ctx.VirtualClient.Update(ctx.Context, newEvent)
+}
+
+// involvedObjects returns the physical and virtual object of the physical event. If the physical
+// object was not synced by the plugin, nil is returned.
+func (f *forwarder) involvedObjects(ctx *context.SyncContext, pEvent *corev1.Event) (client.Object, client.Object, error) {
+	for _, i := range f.kindsOf(pEvent) {
+		kind := f.kinds[i]
+
+		pObj := kind.Physical.DeepCopyObject().(client.Object)
+		err := ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: pEvent.InvolvedObject.Namespace, Name: pEvent.InvolvedObject.Name}, pObj)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, nil, err
+		} else if pEvent.InvolvedObject.UID != "" && pEvent.InvolvedObject.UID != pObj.GetUID() {
+			continue
+		}
+
+		managed, err := kind.Translator.IsManaged(pObj)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// physical objects that were not created by the plugin are only resolved through their
+		// namespaced virtual objects synced back into the vcluster
+		vName := kind.Translator.PhysicalToVirtual(pObj)
+		if vName.Name == "" || (!managed && vName.Namespace == "") {
+			continue
+		}
+
+		vObj := kind.Virtual.DeepCopyObject().(client.Object)
+		err = ctx.VirtualClient.Get(ctx.Context, vName, vObj)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, nil, err
+		} else if !managed && (vObj.GetAnnotations() == nil || vObj.GetAnnotations()[constants.BackwardSyncAnnotation] != "true") {
+			continue
+		}
+
+		return pObj, vObj, nil
+	}
+
+	return nil, nil, nil
+}
+
+// kindsOf returns the indices of the kinds the involved object of the event could belong to
+func (f *forwarder) kindsOf(pEvent *corev1.Event) []int {
+	gv, err := schema.ParseGroupVersion(pEvent.InvolvedObject.APIVersion)
+	if err != nil {
+		return nil
+	}
+
+	indices := []int{}
+	for i, groupKind := range f.groupKinds {
+		if groupKind == gv.WithKind(pEvent.InvolvedObject.Kind).GroupKind() {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+// translate creates the virtual event of the physical event with the physical names replaced
+func translate(pEvent *corev1.Event, pObj, vObj client.Object) *corev1.Event {
+	vName := virtualEventName(pEvent, vObj)
+	t := status.NewTranslator()
+	if vObj.GetNamespace() != "" {
+		t.AddNamespacedName(types.NamespacedName{Namespace: pObj.GetNamespace(), Name: pObj.GetName()}, types.NamespacedName{Namespace: vObj.GetNamespace(), Name: vObj.GetName()})
+	} else {
+		t.AddName(pObj.GetNamespace()+"/"+pObj.GetName(), vObj.GetName())
+		t.AddName(pObj.GetName(), vObj.GetName())
+	}
+
+	vEvent := &corev1.Event{}
+	vEvent.Name = vName.Name
+	vEvent.Namespace = vName.Namespace
+	vEvent.InvolvedObject = corev1.ObjectReference{
+		APIVersion:      pEvent.InvolvedObject.APIVersion,
+		Kind:            pEvent.InvolvedObject.Kind,
+		Namespace:       vObj.GetNamespace(),
+		Name:            vObj.GetName(),
+		UID:             vObj.GetUID(),
+		ResourceVersion: vObj.GetResourceVersion(),
+		FieldPath:       pEvent.InvolvedObject.FieldPath,
+	}
+	vEvent.Reason = pEvent.Reason
+	vEvent.Message = t.Translate(pEvent.Message)
+	vEvent.Source = pEvent.Source
+	vEvent.FirstTimestamp = pEvent.FirstTimestamp
+	vEvent.LastTimestamp = pEvent.LastTimestamp
+	vEvent.EventTime = pEvent.EventTime
+	vEvent.Series = pEvent.Series.DeepCopy()
+	vEvent.Count = pEvent.Count
+	vEvent.Type = pEvent.Type
+	vEvent.Action = pEvent.Action
+	vEvent.ReportingController = pEvent.ReportingController
+	vEvent.ReportingInstance = pEvent.ReportingInstance
+	return vEvent
+}
+
+// virtualEventName returns the name of the virtual event, which keeps the unique suffix of the
+// physical event. Events of cluster scoped objects are created within the default namespace.
+func virtualEventName(pEvent *corev1.Event, vObj client.Object) types.NamespacedName {
+	namespace := vObj.GetNamespace()
+	if namespace == "" {
+		namespace = corev1.NamespaceDefault
+	}
+
+	suffix := pEvent.Name
+	if i := strings.LastIndex(pEvent.Name, "."); i >= 0 {
+		suffix = pEvent.Name[i+1:]
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: vObj.GetName() + "." + suffix}
+}
@@ -0,0 +1,196 @@
+package events
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const targetNamespace = "vcluster"
+
+var (
+	vCertificate = &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "tls", UID: "virtual"}}
+	pCertificate = &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "tls-x-team-a-x-suffix", UID: "physical"}}
+
+	vClusterIssuer = &certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt", UID: "virtual"}}
+	pIssuer        = &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "letsencrypt-x-vcluster-x-suffix", UID: "physical"}}
+)
+
+// physicalEvent returns an event of the given physical object with the given count
+func physicalEvent(pObj client.Object, kind, message string, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pObj.GetNamespace(), Name: pObj.GetName() + ".16f1a2b3c4d5e6f7"},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: certmanagerv1.SchemeGroupVersion.String(),
+			Kind:       kind,
+			Namespace:  pObj.GetNamespace(),
+			Name:       pObj.GetName(),
+			UID:        pObj.GetUID(),
+		},
+		Reason:  "Failed",
+		Message: message,
+		Type:    corev1.EventTypeWarning,
+		Count:   count,
+	}
+}
+
+func TestVirtualEventName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pEvent   string
+		vObj     client.Object
+		expected types.NamespacedName
+	}{
+		{
+			name:     "Namespaced object",
+			pEvent:   pCertificate.Name + ".16f1a2b3c4d5e6f7",
+			vObj:     vCertificate,
+			expected: types.NamespacedName{Namespace: "team-a", Name: "tls.16f1a2b3c4d5e6f7"},
+		},
+		{
+			name:     "Cluster scoped object",
+			pEvent:   pIssuer.Name + ".16f1a2b3c4d5e6f7",
+			vObj:     vClusterIssuer,
+			expected: types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "letsencrypt.16f1a2b3c4d5e6f7"},
+		},
+		{
+			name:     "Event name without suffix",
+			pEvent:   "event",
+			vObj:     vCertificate,
+			expected: types.NamespacedName{Namespace: "team-a", Name: "tls.event"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		pEvent := &corev1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: testCase.pEvent}}
+		name := virtualEventName(pEvent, testCase.vObj)
+		if name != testCase.expected {
+			t.Errorf("Test case %s: expected %s, got %s", testCase.name, testCase.expected.String(), name.String())
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		pEvent *corev1.Event
+		pObj   client.Object
+		vObj   client.Object
+
+		expectedName    types.NamespacedName
+		expectedMessage string
+	}{
+		{
+			name:            "Namespaced object",
+			pEvent:          physicalEvent(pCertificate, "Certificate", "Issuing certificate as Secret "+pCertificate.Name+" does not exist", 1),
+			pObj:            pCertificate,
+			vObj:            vCertificate,
+			expectedName:    types.NamespacedName{Namespace: "team-a", Name: "tls.16f1a2b3c4d5e6f7"},
+			expectedMessage: "Issuing certificate as Secret tls does not exist",
+		},
+		{
+			name:            "Cluster scoped object",
+			pEvent:          physicalEvent(pIssuer, "ClusterIssuer", "Failed to verify ACME account of issuer "+targetNamespace+"/"+pIssuer.Name, 1),
+			pObj:            pIssuer,
+			vObj:            vClusterIssuer,
+			expectedName:    types.NamespacedName{Namespace: corev1.NamespaceDefault, Name: "letsencrypt.16f1a2b3c4d5e6f7"},
+			expectedMessage: "Failed to verify ACME account of issuer letsencrypt",
+		},
+	}
+
+	for _, testCase := range testCases {
+		vEvent := translate(testCase.pEvent, testCase.pObj, testCase.vObj)
+		if vEvent.Namespace != testCase.expectedName.Namespace || vEvent.Name != testCase.expectedName.Name {
+			t.Errorf("Test case %s: expected event %s, got %s/%s", testCase.name, testCase.expectedName.String(), vEvent.Namespace, vEvent.Name)
+		}
+		if vEvent.Message != testCase.expectedMessage {
+			t.Errorf("Test case %s: expected message %q, got %q", testCase.name, testCase.expectedMessage, vEvent.Message)
+		}
+		if vEvent.InvolvedObject.Namespace != testCase.vObj.GetNamespace() || vEvent.InvolvedObject.Name != testCase.vObj.GetName() || vEvent.InvolvedObject.UID != testCase.vObj.GetUID() {
+			t.Errorf("Test case %s: expected the virtual object as involved object, got %v", testCase.name, vEvent.InvolvedObject)
+		}
+		if vEvent.Reason != testCase.pEvent.Reason || vEvent.Type != testCase.pEvent.Type || vEvent.Count != testCase.pEvent.Count {
+			t.Errorf("Test case %s: expected reason, type and count of the physical event, got %v", testCase.name, vEvent)
+		}
+	}
+}
+
+func TestForward(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = certmanagerv1.AddToScheme(scheme)
+	ctx := &context.SyncContext{
+		Context:         context2.TODO(),
+		VirtualClient:   fake.NewClientBuilder().WithScheme(scheme).Build(),
+		PhysicalClient:  fake.NewClientBuilder().WithScheme(scheme).Build(),
+		TargetNamespace: targetNamespace,
+		Log:             log.New(t.Name()),
+	}
+	f := &forwarder{}
+	vName := types.NamespacedName{Namespace: "team-a", Name: "tls.16f1a2b3c4d5e6f7"}
+
+	testCases := []struct {
+		name    string
+		count   int32
+		message string
+
+		expectedCount   int32
+		expectedMessage string
+	}{
+		{
+			name:            "New event",
+			count:           1,
+			message:         "Secret " + pCertificate.Name + " does not exist",
+			expectedCount:   1,
+			expectedMessage: "Secret tls does not exist",
+		},
+		{
+			name:            "Repeated event",
+			count:           3,
+			message:         "Secret " + pCertificate.Name + " does not exist yet",
+			expectedCount:   3,
+			expectedMessage: "Secret tls does not exist yet",
+		},
+		{
+			name:            "Outdated event",
+			count:           2,
+			message:         "outdated",
+			expectedCount:   3,
+			expectedMessage: "Secret tls does not exist yet",
+		},
+	}
+
+	for _, testCase := range testCases {
+		pEvent := physicalEvent(pCertificate, "Certificate", testCase.message, testCase.count)
+		err := f.forward(ctx, pEvent, pCertificate, vCertificate)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		vEvent := &corev1.Event{}
+		err = ctx.VirtualClient.Get(ctx.Context, vName, vEvent)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if vEvent.Count != testCase.expectedCount || vEvent.Message != testCase.expectedMessage {
+			t.Errorf("Test case %s: expected count %d and message %q, got %d and %q", testCase.name, testCase.expectedCount, testCase.expectedMessage, vEvent.Count, vEvent.Message)
+		}
+	}
+
+	events := &corev1.EventList{}
+	err := ctx.VirtualClient.List(ctx.Context, events)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if len(events.Items) != 1 {
+		t.Errorf("Expected a single deduplicated virtual event, got %d", len(events.Items))
+	}
+}
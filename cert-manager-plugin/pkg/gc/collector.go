@@ -0,0 +1,241 @@
+package gc
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"time"
+)
+
+// Kind is a host object kind the garbage collector looks for orphans of
+type Kind struct {
+	// Translator maps the physical objects to their virtual objects, usually the syncer of the kind
+	Translator translator.NameTranslator
+
+	// PhysicalList is an empty list of the physical objects
+	PhysicalList client.ObjectList
+
+	// Virtual is an empty virtual object
+	Virtual client.Object
+}
+
+// UsageChecker can be implemented by translators of kinds whose physical objects are not needed
+// anymore, even though the virtual object still exists
+type UsageChecker interface {
+	IsUsed(ctx context2.Context, vObj client.Object) (bool, error)
+}
+
+// OwnerChecker can be implemented by translators of kinds whose physical objects are also synced by
+// vcluster itself, so that only physical objects created by the plugin are collected
+type OwnerChecker interface {
+	IsOwned(pObj client.Object) bool
+}
+
+// New creates a garbage collector that periodically deletes host objects of the given kinds
+// whose virtual objects are gone, e.g. because the plugin was not running while they were deleted
+func New(ctx *context.RegisterContext, cfg config.GarbageCollection, kinds ...Kind) syncer.Base {
+	return &collector{
+		kinds:       kinds,
+		interval:    cfg.Interval.Duration,
+		gracePeriod: cfg.GracePeriod.Duration,
+		dryRun:      cfg.DryRun,
+
+		eventRecorder: ctx.PhysicalManager.GetEventRecorderFor("garbage-collector"),
+	}
+}
+
+type collector struct {
+	kinds       []Kind
+	interval    time.Duration
+	gracePeriod time.Duration
+	dryRun      bool
+
+	eventRecorder record.EventRecorder
+}
+
+func (c *collector) Name() string {
+	return "garbage-collector"
+}
+
+var _ syncer.ControllerStarter = &collector{}
+
+func (c *collector) Register(ctx *context.RegisterContext) error {
+	if c.interval <= 0 {
+		return nil
+	}
+
+	syncContext := context.ConvertContext(ctx, c.Name())
+	go wait.Until(func() {
+		err := c.collect(syncContext)
+		if err != nil {
+			syncContext.Log.Infof("error collecting orphaned objects: %v", err)
+		}
+	}, c.interval, ctx.Context.Done())
+	return nil
+}
+
+// collect deletes all host objects that were orphaned for longer than the grace period. The time objects
+// were first found orphaned is recorded in an annotation on them, so that the grace period holds across
+// restarts of the plugin.
+func (c *collector) collect(ctx *context.SyncContext) error {
+	for _, kind := range c.kinds {
+		pList := kind.PhysicalList.DeepCopyObject().(client.ObjectList)
+		err := ctx.PhysicalClient.List(ctx.Context, pList, client.InNamespace(ctx.TargetNamespace))
+		if err != nil {
+			return err
+		}
+
+		pObjs, err := meta.ExtractList(pList)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range pObjs {
+			pObj, ok := obj.(client.Object)
+			if !ok {
+				continue
+			}
+
+			isOrphan, err := c.isOrphan(ctx, kind, pObj)
+			if err != nil {
+				return err
+			} else if !isOrphan {
+				// forget objects that are not orphaned anymore
+				err = c.setOrphanedSince(ctx, pObj, "")
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			orphanedSince, err := time.Parse(time.RFC3339, pObj.GetAnnotations()[constants.OrphanedSinceAnnotation])
+			if err != nil {
+				err = c.setOrphanedSince(ctx, pObj, time.Now().UTC().Format(time.RFC3339))
+				if err != nil {
+					return err
+				}
+
+				continue
+			} else if time.Since(orphanedSince) < c.gracePeriod {
+				continue
+			}
+
+			err = c.delete(ctx, pObj)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setOrphanedSince records the time the physical object was first found orphaned or removes the
+// annotation if the time is empty
+func (c *collector) setOrphanedSince(ctx *context.SyncContext, pObj client.Object, orphanedSince string) error {
+	if pObj.GetAnnotations()[constants.OrphanedSinceAnnotation] == orphanedSince {
+		return nil
+	}
+
+	patch := client.MergeFrom(pObj.DeepCopyObject().(client.Object))
+	annotations := pObj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if orphanedSince == "" {
+		delete(annotations, constants.OrphanedSinceAnnotation)
+	} else {
+		annotations[constants.OrphanedSinceAnnotation] = orphanedSince
+	}
+	pObj.SetAnnotations(annotations)
+
+	err := ctx.PhysicalClient.Patch(ctx.Context, pObj, patch)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// isOrphan returns true if the physical object was synced by the plugin and its virtual object is either
+// gone or does not need the physical object anymore
+func (c *collector) isOrphan(ctx *context.SyncContext, kind Kind, pObj client.Object) (bool, error) {
+	if pObj.GetDeletionTimestamp() != nil {
+		return false, nil
+	}
+
+	managed, err := kind.Translator.IsManaged(pObj)
+	if err != nil {
+		return false, err
+	}
+
+	// physical objects synced by vcluster itself are left to vcluster
+	ownerChecker, ok := kind.Translator.(OwnerChecker)
+	if managed && ok && !ownerChecker.IsOwned(pObj) {
+		return false, nil
+	}
+
+	vName := kind.Translator.PhysicalToVirtual(pObj)
+	if vName.Name == "" {
+		return managed, nil
+	}
+
+	vObj := kind.Virtual.DeepCopyObject().(client.Object)
+	err = ctx.VirtualClient.Get(ctx.Context, vName, vObj)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return managed, nil
+		}
+
+		return false, err
+	}
+
+	// physical objects that were not created by the plugin are only collected through their
+	// virtual objects synced back into the vcluster
+	if !managed && (vObj.GetAnnotations() == nil || vObj.GetAnnotations()[constants.BackwardSyncAnnotation] != "true") {
+		return false, nil
+	}
+
+	usageChecker, ok := kind.Translator.(UsageChecker)
+	if !ok {
+		return false, nil
+	}
+
+	used, err := usageChecker.IsUsed(ctx.Context, vObj)
+	return !used, err
+}
+
+func (c *collector) delete(ctx *context.SyncContext, pObj client.Object) error {
+	kind := pObj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := apiutil.GVKForObject(pObj, ctx.PhysicalClient.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+
+	if c.dryRun {
+		ctx.Log.Infof("would delete orphaned %s %s/%s, but dry run is enabled", kind, pObj.GetNamespace(), pObj.GetName())
+		c.eventRecorder.Eventf(pObj, "Normal", "OrphanDetected", "Orphaned %s would be deleted, but garbage collection dry run is enabled", kind)
+		return nil
+	}
+
+	ctx.Log.Infof("delete physical %s %s/%s, because it is orphaned", kind, pObj.GetNamespace(), pObj.GetName())
+	err := metrics.Write(c.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+		return ctx.PhysicalClient.Delete(ctx.Context, pObj)
+	})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	c.eventRecorder.Eventf(pObj, "Normal", "OrphanDeleted", "Deleted orphaned %s", kind)
+	return nil
+}
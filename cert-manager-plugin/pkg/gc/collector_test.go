@@ -0,0 +1,255 @@
+package gc
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+const (
+	virtualNamespace = "default"
+	virtualName      = "tls"
+)
+
+// translatorWithUsage is a name translator that also checks the usage of virtual objects
+type translatorWithUsage interface {
+	IsManaged(pObj client.Object) (bool, error)
+	VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName
+	PhysicalToVirtual(pObj client.Object) types.NamespacedName
+	UsageChecker
+}
+
+// testTranslator maps every physical secret to the same virtual secret
+type testTranslator struct {
+	used bool
+}
+
+func (t *testTranslator) IsManaged(pObj client.Object) (bool, error) {
+	return translate.IsManaged(pObj), nil
+}
+
+func (t *testTranslator) VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName {
+	return types.NamespacedName{Namespace: "host", Name: translate.PhysicalName(req.Name, req.Namespace)}
+}
+
+func (t *testTranslator) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
+	return types.NamespacedName{Namespace: virtualNamespace, Name: virtualName}
+}
+
+func (t *testTranslator) IsUsed(ctx context2.Context, vObj client.Object) (bool, error) {
+	return t.used, nil
+}
+
+// ownerTestTranslator only owns physical secrets created by the plugin
+type ownerTestTranslator struct {
+	testTranslator
+}
+
+func (t *ownerTestTranslator) IsOwned(pObj client.Object) bool {
+	return pObj.GetLabels()[constants.SyncedLabel] == "true"
+}
+
+func physicalSecret(labels map[string]string, deleting bool) *corev1.Secret {
+	pSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "host", Name: translate.PhysicalName(virtualName, virtualNamespace), Labels: labels}}
+	if deleting {
+		now := metav1.Now()
+		pSecret.DeletionTimestamp = &now
+	}
+	return pSecret
+}
+
+func TestIsOrphan(t *testing.T) {
+	managed := map[string]string{translate.MarkerLabel: translate.Suffix}
+	owned := map[string]string{translate.MarkerLabel: translate.Suffix, constants.SyncedLabel: "true"}
+	vSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: virtualNamespace, Name: virtualName}}
+	vBackwardSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: virtualNamespace, Name: virtualName, Annotations: map[string]string{constants.BackwardSyncAnnotation: "true"}}}
+
+	testCases := []struct {
+		name       string
+		translator translatorWithUsage
+		pObj       client.Object
+		vObj       client.Object
+		expected   bool
+	}{
+		{
+			name:       "managed and virtual object gone",
+			translator: &testTranslator{},
+			pObj:       physicalSecret(managed, false),
+			expected:   true,
+		},
+		{
+			name:       "managed and being deleted",
+			translator: &testTranslator{},
+			pObj:       physicalSecret(managed, true),
+			expected:   false,
+		},
+		{
+			name:       "not managed and virtual object gone",
+			translator: &testTranslator{},
+			pObj:       physicalSecret(nil, false),
+			expected:   false,
+		},
+		{
+			name:       "managed and virtual object used",
+			translator: &testTranslator{used: true},
+			pObj:       physicalSecret(managed, false),
+			vObj:       vSecret,
+			expected:   false,
+		},
+		{
+			name:       "managed and virtual object not used",
+			translator: &testTranslator{},
+			pObj:       physicalSecret(managed, false),
+			vObj:       vSecret,
+			expected:   true,
+		},
+		{
+			name:       "not managed and virtual object not synced back",
+			translator: &testTranslator{},
+			pObj:       physicalSecret(nil, false),
+			vObj:       vSecret,
+			expected:   false,
+		},
+		{
+			name:       "not managed and virtual object synced back but not used",
+			translator: &testTranslator{},
+			pObj:       physicalSecret(nil, false),
+			vObj:       vBackwardSecret,
+			expected:   true,
+		},
+		{
+			name:       "synced by vcluster and virtual object gone",
+			translator: &ownerTestTranslator{},
+			pObj:       physicalSecret(managed, false),
+			expected:   false,
+		},
+		{
+			name:       "synced by vcluster and virtual object not used",
+			translator: &ownerTestTranslator{},
+			pObj:       physicalSecret(managed, false),
+			vObj:       vBackwardSecret,
+			expected:   false,
+		},
+		{
+			name:       "synced by the plugin and virtual object gone",
+			translator: &ownerTestTranslator{},
+			pObj:       physicalSecret(owned, false),
+			expected:   true,
+		},
+		{
+			name:       "synced by the plugin and virtual object used",
+			translator: &ownerTestTranslator{testTranslator{used: true}},
+			pObj:       physicalSecret(owned, false),
+			vObj:       vSecret,
+			expected:   false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		clientBuilder := fake.NewClientBuilder()
+		if testCase.vObj != nil {
+			clientBuilder = clientBuilder.WithObjects(testCase.vObj)
+		}
+		ctx := &context.SyncContext{Context: context2.TODO(), VirtualClient: clientBuilder.Build()}
+		kind := Kind{Translator: testCase.translator, PhysicalList: &corev1.SecretList{}, Virtual: &corev1.Secret{}}
+
+		isOrphan, err := (&collector{}).isOrphan(ctx, kind, testCase.pObj)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if isOrphan != testCase.expected {
+			t.Errorf("Test case %s: expected orphan %t, got %t", testCase.name, testCase.expected, isOrphan)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	managed := map[string]string{translate.MarkerLabel: translate.Suffix}
+	orphanedSince := func(age time.Duration) map[string]string {
+		return map[string]string{constants.OrphanedSinceAnnotation: time.Now().Add(-age).UTC().Format(time.RFC3339)}
+	}
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		used        bool
+
+		expectDeleted  bool
+		expectRecorded bool
+	}{
+		{
+			name:           "orphan found for the first time",
+			expectRecorded: true,
+		},
+		{
+			name:           "orphan with invalid timestamp",
+			annotations:    map[string]string{constants.OrphanedSinceAnnotation: "yesterday"},
+			expectRecorded: true,
+		},
+		{
+			name:           "orphan within the grace period",
+			annotations:    orphanedSince(time.Minute),
+			expectRecorded: true,
+		},
+		{
+			name:          "orphan after the grace period",
+			annotations:   orphanedSince(time.Hour),
+			expectDeleted: true,
+		},
+		{
+			name:        "object that is not orphaned anymore",
+			annotations: orphanedSince(time.Hour),
+			used:        true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		pSecret := physicalSecret(managed, false)
+		pSecret.Namespace = "host"
+		pSecret.Annotations = testCase.annotations
+		vSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: virtualNamespace, Name: virtualName}}
+		ctx := &context.SyncContext{
+			Context:         context2.TODO(),
+			VirtualClient:   fake.NewClientBuilder().WithObjects(vSecret).Build(),
+			PhysicalClient:  fake.NewClientBuilder().WithObjects(pSecret).Build(),
+			TargetNamespace: "host",
+			Log:             log.New(t.Name()),
+		}
+		c := &collector{
+			kinds:         []Kind{{Translator: &testTranslator{used: testCase.used}, PhysicalList: &corev1.SecretList{}, Virtual: &corev1.Secret{}}},
+			gracePeriod:   10 * time.Minute,
+			eventRecorder: record.NewFakeRecorder(10),
+		}
+
+		err := c.collect(ctx)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		collected := &corev1.Secret{}
+		err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: pSecret.Namespace, Name: pSecret.Name}, collected)
+		if kerrors.IsNotFound(err) != testCase.expectDeleted {
+			t.Fatalf("Test case %s: expected physical secret deleted %t, got error %v", testCase.name, testCase.expectDeleted, err)
+		} else if testCase.expectDeleted {
+			continue
+		}
+
+		recorded, err := time.Parse(time.RFC3339, collected.Annotations[constants.OrphanedSinceAnnotation])
+		if testCase.expectRecorded && (err != nil || time.Since(recorded) > c.gracePeriod) {
+			t.Errorf("Test case %s: expected orphaned since to be recorded, got %v", testCase.name, collected.Annotations)
+		} else if !testCase.expectRecorded && collected.Annotations[constants.OrphanedSinceAnnotation] != "" {
+			t.Errorf("Test case %s: expected orphaned since to be removed, got %v", testCase.name, collected.Annotations)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package gateways
+
+import (
+	"context"
+	"fmt"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks"
+	"github.com/loft-sh/vcluster-sdk/hook"
+	synccontext "github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func NewGatewayHook(ctx *synccontext.RegisterContext, cfg *config.Config) hook.ClientHook {
+	return &gatewayHook{
+		issuerTranslator: hooks.NewIssuerTranslator(ctx, cfg),
+	}
+}
+
+type gatewayHook struct {
+	issuerTranslator *hooks.IssuerTranslator
+}
+
+func (p *gatewayHook) Name() string {
+	return "gateway-hook"
+}
+
+func (p *gatewayHook) Resource() client.Object {
+	return &gatewayv1alpha2.Gateway{}
+}
+
+var _ hook.MutateCreatePhysical = &gatewayHook{}
+
+func (p *gatewayHook) MutateCreatePhysical(ctx context.Context, obj client.Object) (client.Object, error) {
+	gateway, ok := obj.(*gatewayv1alpha2.Gateway)
+	if !ok {
+		return nil, fmt.Errorf("object %v is not a gateway", obj)
+	}
+
+	err := p.mutateGateway(ctx, gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	return gateway, nil
+}
+
+var _ hook.MutateUpdatePhysical = &gatewayHook{}
+
+func (p *gatewayHook) MutateUpdatePhysical(ctx context.Context, obj client.Object) (client.Object, error) {
+	gateway, ok := obj.(*gatewayv1alpha2.Gateway)
+	if !ok {
+		return nil, fmt.Errorf("object %v is not a gateway", obj)
+	}
+
+	err := p.mutateGateway(ctx, gateway)
+	if err != nil {
+		return nil, err
+	}
+
+	return gateway, nil
+}
+
+// mutateGateway translates the gateway-shim annotations and the tls secrets of the virtual gateway,
+// so that the host cluster cert-manager issues the certificates with the translated issuer
+func (p *gatewayHook) mutateGateway(ctx context.Context, gateway *gatewayv1alpha2.Gateway) error {
+	if gateway.Annotations == nil {
+		return nil
+	}
+
+	issuerRef, ok := hooks.IssuerRef(gateway.Annotations)
+	if !ok {
+		return nil
+	}
+
+	// the gateway-shim creates certificates with the secret names of the listeners
+	namespace := gateway.Annotations[translator.NamespaceAnnotation]
+	dnsNames := []string{}
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+
+		for _, certificateRef := range listener.TLS.CertificateRefs {
+			if !IsSecretRef(certificateRef, namespace) {
+				continue
+			}
+			if listener.Hostname != nil && *listener.Hostname != "" {
+				dnsNames = append(dnsNames, string(*listener.Hostname))
+			}
+
+			certificateRef.Name = gatewayv1alpha2.ObjectName(translate.PhysicalName(string(certificateRef.Name), namespace))
+			certificateRef.Namespace = nil
+		}
+	}
+
+	return p.issuerTranslator.TranslateAnnotations(ctx, gateway, issuerRef, dnsNames)
+}
+
+// IsSecretRef returns true if the given certificate reference of a gateway within the given
+// namespace references a secret in the same namespace
+func IsSecretRef(certificateRef *gatewayv1alpha2.SecretObjectReference, namespace string) bool {
+	if certificateRef == nil || certificateRef.Name == "" {
+		return false
+	} else if certificateRef.Group != nil && *certificateRef.Group != "" {
+		return false
+	} else if certificateRef.Kind != nil && *certificateRef.Kind != "Secret" {
+		return false
+	} else if certificateRef.Namespace != nil && string(*certificateRef.Namespace) != namespace {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,202 @@
+package gateways
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	"testing"
+)
+
+func newTestHook(cfg *config.Config) *gatewayHook {
+	virtualClient := testingutil.NewFakeClient()
+	physicalClient := testingutil.NewFakeClient(
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "private"}},
+	)
+	return NewGatewayHook(testingutil.NewRegisterContext(virtualClient, physicalClient), cfg).(*gatewayHook)
+}
+
+func secretRef(name string, modify func(ref *gatewayv1alpha2.SecretObjectReference)) *gatewayv1alpha2.SecretObjectReference {
+	ref := &gatewayv1alpha2.SecretObjectReference{Name: gatewayv1alpha2.ObjectName(name)}
+	if modify != nil {
+		modify(ref)
+	}
+	return ref
+}
+
+func TestIsSecretRef(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ref      *gatewayv1alpha2.SecretObjectReference
+		expected bool
+	}{
+		{
+			name:     "Secret",
+			ref:      secretRef("tls", nil),
+			expected: true,
+		},
+		{
+			name: "Secret with kind and testingutil.Namespace",
+			ref: secretRef("tls", func(ref *gatewayv1alpha2.SecretObjectReference) {
+				kind := gatewayv1alpha2.Kind("Secret")
+				ns := gatewayv1alpha2.Namespace(testingutil.Namespace)
+				group := gatewayv1alpha2.Group("")
+				ref.Kind, ref.Namespace, ref.Group = &kind, &ns, &group
+			}),
+			expected: true,
+		},
+		{
+			name: "Secret of another testingutil.Namespace",
+			ref: secretRef("tls", func(ref *gatewayv1alpha2.SecretObjectReference) {
+				ns := gatewayv1alpha2.Namespace("team-b")
+				ref.Namespace = &ns
+			}),
+		},
+		{
+			name: "Other kind",
+			ref: secretRef("tls", func(ref *gatewayv1alpha2.SecretObjectReference) {
+				kind := gatewayv1alpha2.Kind("ConfigMap")
+				ref.Kind = &kind
+			}),
+		},
+		{
+			name: "Other group",
+			ref: secretRef("tls", func(ref *gatewayv1alpha2.SecretObjectReference) {
+				group := gatewayv1alpha2.Group("example.com")
+				ref.Group = &group
+			}),
+		},
+		{
+			name: "Empty name",
+			ref:  secretRef("", nil),
+		},
+		{
+			name: "Missing reference",
+		},
+	}
+
+	for _, testCase := range testCases {
+		isSecretRef := IsSecretRef(testCase.ref, testingutil.Namespace)
+		if isSecretRef != testCase.expected {
+			t.Errorf("Test case %s: expected %t, got %t", testCase.name, testCase.expected, isSecretRef)
+		}
+	}
+}
+
+// physicalGateway returns a gateway synced from the virtual namespace with the given annotations and certificate refs
+func physicalGateway(annotations map[string]string, certificateRefs ...*gatewayv1alpha2.SecretObjectReference) *gatewayv1alpha2.Gateway {
+	pAnnotations := map[string]string{
+		translator.NameAnnotation:      "gateway",
+		translator.NamespaceAnnotation: testingutil.Namespace,
+	}
+	for k, v := range annotations {
+		pAnnotations[k] = v
+	}
+
+	hostname := gatewayv1alpha2.Hostname("example.com")
+	return &gatewayv1alpha2.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.TargetNamespace, Name: translate.PhysicalName("gateway", testingutil.Namespace), Annotations: pAnnotations},
+		Spec: gatewayv1alpha2.GatewaySpec{
+			Listeners: []gatewayv1alpha2.Listener{
+				{Name: "https", Hostname: &hostname, TLS: &gatewayv1alpha2.GatewayTLSConfig{CertificateRefs: certificateRefs}},
+				{Name: "http"},
+			},
+		},
+	}
+}
+
+func TestMutateGateway(t *testing.T) {
+	otherNamespace := func(ref *gatewayv1alpha2.SecretObjectReference) {
+		ns := gatewayv1alpha2.Namespace("team-b")
+		ref.Namespace = &ns
+	}
+	sameNamespace := func(ref *gatewayv1alpha2.SecretObjectReference) {
+		ns := gatewayv1alpha2.Namespace(testingutil.Namespace)
+		ref.Namespace = &ns
+	}
+
+	testCases := []struct {
+		name            string
+		cfg             *config.Config
+		annotations     map[string]string
+		certificateRefs []*gatewayv1alpha2.SecretObjectReference
+
+		expectedAnnotations     map[string]string
+		expectedCertificateRefs []*gatewayv1alpha2.SecretObjectReference
+	}{
+		{
+			name:                    "Issuer",
+			annotations:             map[string]string{constants.IssuerAnnotation: "letsencrypt"},
+			certificateRefs:         []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", nil), secretRef("same-testingutil.Namespace", sameNamespace)},
+			expectedAnnotations:     map[string]string{constants.IssuerAnnotation: translate.PhysicalName("letsencrypt", testingutil.Namespace)},
+			expectedCertificateRefs: []*gatewayv1alpha2.SecretObjectReference{secretRef(translate.PhysicalName("tls", testingutil.Namespace), nil), secretRef(translate.PhysicalName("same-testingutil.Namespace", testingutil.Namespace), nil)},
+		},
+		{
+			name:                    "Secret of another testingutil.Namespace",
+			annotations:             map[string]string{constants.IssuerAnnotation: "letsencrypt"},
+			certificateRefs:         []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", otherNamespace)},
+			expectedAnnotations:     map[string]string{constants.IssuerAnnotation: translate.PhysicalName("letsencrypt", testingutil.Namespace)},
+			expectedCertificateRefs: []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", otherNamespace)},
+		},
+		{
+			name:                    "Host cluster issuer that is not shared",
+			annotations:             map[string]string{constants.ClusterIssuerAnnotation: "private"},
+			certificateRefs:         []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", nil)},
+			expectedAnnotations:     map[string]string{},
+			expectedCertificateRefs: []*gatewayv1alpha2.SecretObjectReference{secretRef(translate.PhysicalName("tls", testingutil.Namespace), nil)},
+		},
+		{
+			name:                    "Hostname violating the domain policy",
+			cfg:                     &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"*.team-a.example.com"}}},
+			annotations:             map[string]string{constants.IssuerAnnotation: "letsencrypt"},
+			certificateRefs:         []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", nil)},
+			expectedAnnotations:     map[string]string{},
+			expectedCertificateRefs: []*gatewayv1alpha2.SecretObjectReference{secretRef(translate.PhysicalName("tls", testingutil.Namespace), nil)},
+		},
+		{
+			name: "Common name violating the domain policy",
+			cfg:  &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"example.com"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:            "letsencrypt",
+				certmanagerv1.CommonNameAnnotationKey: "other.com",
+			},
+			certificateRefs:         []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", nil)},
+			expectedAnnotations:     map[string]string{certmanagerv1.CommonNameAnnotationKey: "other.com"},
+			expectedCertificateRefs: []*gatewayv1alpha2.SecretObjectReference{secretRef(translate.PhysicalName("tls", testingutil.Namespace), nil)},
+		},
+		{
+			name:                    "Gateway without issuer",
+			annotations:             map[string]string{"a": "b"},
+			certificateRefs:         []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", nil)},
+			expectedAnnotations:     map[string]string{"a": "b"},
+			expectedCertificateRefs: []*gatewayv1alpha2.SecretObjectReference{secretRef("tls", nil)},
+		},
+	}
+
+	for _, testCase := range testCases {
+		cfg := testCase.cfg
+		if cfg == nil {
+			cfg = &config.Config{}
+		}
+
+		gateway := physicalGateway(testCase.annotations, testCase.certificateRefs...)
+		mutated, err := newTestHook(cfg).MutateCreatePhysical(context.TODO(), gateway)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		expected := physicalGateway(testCase.expectedAnnotations, testCase.expectedCertificateRefs...)
+		if !reflect.DeepEqual(mutated.GetAnnotations(), expected.Annotations) {
+			t.Errorf("Test case %s: expected annotations %v, got %v", testCase.name, expected.Annotations, mutated.GetAnnotations())
+		}
+		if !reflect.DeepEqual(mutated.(*gatewayv1alpha2.Gateway).Spec, expected.Spec) {
+			t.Errorf("Test case %s: expected spec %v, got %v", testCase.name, expected.Spec, mutated.(*gatewayv1alpha2.Gateway).Spec)
+		}
+	}
+}
@@ -3,19 +3,31 @@ package ingresses
 import (
 	"context"
 	"fmt"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks"
 	"github.com/loft-sh/vcluster-sdk/hook"
-	"github.com/loft-sh/vcluster-sdk/syncer/translator"
-	"github.com/loft-sh/vcluster-sdk/translate"
+	synccontext "github.com/loft-sh/vcluster-sdk/syncer/context"
 	networkingv1 "k8s.io/api/networking/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func NewIngressHook() hook.ClientHook {
-	return &ingressHook{}
+func NewIngressHook(ctx *synccontext.RegisterContext, cfg *config.Config) hook.ClientHook {
+	return &ingressHook{
+		issuerTranslator: hooks.NewIssuerTranslator(ctx, cfg),
+
+		defaultIssuer:  cfg.Ingresses.DefaultIssuer,
+		ingressClasses: cfg.HTTP01.IngressClasses,
+	}
 }
 
-type ingressHook struct{}
+type ingressHook struct {
+	issuerTranslator *hooks.IssuerTranslator
+
+	defaultIssuer  cmmeta.ObjectReference
+	ingressClasses map[string]string
+}
 
 func (p *ingressHook) Name() string {
 	return "ingress-hook"
@@ -33,7 +45,11 @@ func (p *ingressHook) MutateCreatePhysical(ctx context.Context, obj client.Objec
 		return nil, fmt.Errorf("object %v is not an ingress", obj)
 	}
 
-	mutateIngress(ingress)
+	err := p.mutateIngress(ctx, ingress)
+	if err != nil {
+		return nil, err
+	}
+
 	return ingress, nil
 }
 
@@ -45,12 +61,57 @@ func (p *ingressHook) MutateUpdatePhysical(ctx context.Context, obj client.Objec
 		return nil, fmt.Errorf("object %v is not an ingress", obj)
 	}
 
-	mutateIngress(ingress)
+	err := p.mutateIngress(ctx, ingress)
+	if err != nil {
+		return nil, err
+	}
+
 	return ingress, nil
 }
 
-func mutateIngress(ingress *networkingv1.Ingress) {
-	if ingress.Annotations != nil && ingress.Annotations[constants.IssuerAnnotation] != "" {
-		ingress.Annotations[constants.IssuerAnnotation] = translate.PhysicalName(ingress.Annotations[constants.IssuerAnnotation], ingress.Annotations[translator.NamespaceAnnotation])
+// mutateIngress translates the ingress-shim annotations of the virtual ingress, so that the
+// host cluster cert-manager issues the certificate with the translated issuer
+func (p *ingressHook) mutateIngress(ctx context.Context, ingress *networkingv1.Ingress) error {
+	if ingress.Annotations == nil {
+		return nil
+	}
+
+	// map the ingress class of the http01 solver
+	ingressClass := ingress.Annotations[constants.HTTP01IngressClassAnnotation]
+	if ingressClass != "" && p.ingressClasses[ingressClass] != "" {
+		ingress.Annotations[constants.HTTP01IngressClassAnnotation] = p.ingressClasses[ingressClass]
+	}
+
+	// the host cluster default issuer is not subject to the policies of the vcluster
+	issuerRef, ok := p.issuerRef(ingress.Annotations)
+	if !ok {
+		delete(ingress.Annotations, constants.TLSACMEAnnotation)
+		return nil
+	}
+
+	return p.issuerTranslator.TranslateAnnotations(ctx, ingress, issuerRef, dnsNames(ingress))
+}
+
+// dnsNames returns the dns names the ingress-shim issues certificates for
+func dnsNames(ingress *networkingv1.Ingress) []string {
+	names := []string{}
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName != "" {
+			names = append(names, tls.Hosts...)
+		}
 	}
+
+	return names
+}
+
+// issuerRef returns the issuer the ingress-shim would use for the given virtual ingress annotations
+func (p *ingressHook) issuerRef(annotations map[string]string) (cmmeta.ObjectReference, bool) {
+	issuerRef, ok := hooks.IssuerRef(annotations)
+	if ok {
+		return issuerRef, true
+	} else if annotations[constants.TLSACMEAnnotation] == "true" && p.defaultIssuer.Name != "" {
+		return p.defaultIssuer, true
+	}
+
+	return cmmeta.ObjectReference{}, false
 }
@@ -0,0 +1,261 @@
+package ingresses
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func newTestHook(cfg *config.Config) *ingressHook {
+	virtualClient := testingutil.NewFakeClient(
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "virtual"}},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "shared", Annotations: map[string]string{constants.BackwardSyncAnnotation: "true"}}},
+	)
+	physicalClient := testingutil.NewFakeClient(
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "shared", Labels: map[string]string{constants.ClusterIssuerSyncLabel: "true"}}},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "private"}},
+	)
+	return NewIngressHook(testingutil.NewRegisterContext(virtualClient, physicalClient), cfg).(*ingressHook)
+}
+
+// physicalIngress returns an ingress synced by vcluster from the virtual namespace with a tls secret for example.com
+func physicalIngress(annotations map[string]string) *networkingv1.Ingress {
+	pAnnotations := map[string]string{
+		translator.NameAnnotation:      "web",
+		translator.NamespaceAnnotation: testingutil.Namespace,
+	}
+	for k, v := range annotations {
+		pAnnotations[k] = v
+	}
+
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.TargetNamespace, Name: translate.PhysicalName("web", testingutil.Namespace), Annotations: pAnnotations},
+		Spec: networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: translate.PhysicalName("web-tls", testingutil.Namespace)}},
+		},
+	}
+}
+
+func TestMutateIngress(t *testing.T) {
+	testCases := []struct {
+		name        string
+		cfg         *config.Config
+		annotations map[string]string
+
+		expectedAnnotations map[string]string
+	}{
+		{
+			name: "Issuer",
+			annotations: map[string]string{
+				constants.IssuerAnnotation:          "letsencrypt",
+				certmanagerv1.DurationAnnotationKey: "2160h",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:          translate.PhysicalName("letsencrypt", testingutil.Namespace),
+				certmanagerv1.DurationAnnotationKey: "2160h",
+			},
+		},
+		{
+			name: "External issuer",
+			cfg:  &config.Config{ExternalIssuers: config.ExternalIssuers{{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:      "pca",
+				constants.IssuerKindAnnotation:  "AWSPCAIssuer",
+				constants.IssuerGroupAnnotation: "awspca.cert-manager.io",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:      translate.PhysicalName("pca", testingutil.Namespace),
+				constants.IssuerKindAnnotation:  "AWSPCAIssuer",
+				constants.IssuerGroupAnnotation: "awspca.cert-manager.io",
+			},
+		},
+		{
+			name:                "Cluster issuer of the vcluster",
+			annotations:         map[string]string{constants.ClusterIssuerAnnotation: "virtual"},
+			expectedAnnotations: map[string]string{constants.IssuerAnnotation: translate.PhysicalNameClusterScoped("virtual", testingutil.TargetNamespace)},
+		},
+		{
+			name:                "Shared host cluster issuer",
+			annotations:         map[string]string{constants.ClusterIssuerAnnotation: "shared"},
+			expectedAnnotations: map[string]string{constants.ClusterIssuerAnnotation: "shared"},
+		},
+		{
+			name:                "Host cluster issuer that is not shared",
+			annotations:         map[string]string{constants.ClusterIssuerAnnotation: "private"},
+			expectedAnnotations: map[string]string{},
+		},
+		{
+			name: "External issuer",
+			cfg:  &config.Config{ExternalIssuers: config.ExternalIssuers{{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:      "pca",
+				constants.IssuerKindAnnotation:  "AWSPCAIssuer",
+				constants.IssuerGroupAnnotation: "awspca.cert-manager.io",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:      translate.PhysicalName("pca", testingutil.Namespace),
+				constants.IssuerKindAnnotation:  "AWSPCAIssuer",
+				constants.IssuerGroupAnnotation: "awspca.cert-manager.io",
+			},
+		},
+		{
+			name: "External cluster issuer that is not configured",
+			cfg:  &config.Config{ExternalIssuers: config.ExternalIssuers{{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:      "pca",
+				constants.IssuerKindAnnotation:  "AWSPCAClusterIssuer",
+				constants.IssuerGroupAnnotation: "awspca.cert-manager.io",
+			},
+			expectedAnnotations: map[string]string{},
+		},
+		{
+			name: "tls-acme with default issuer",
+			cfg:  &config.Config{Ingresses: config.Ingresses{DefaultIssuer: cmmeta.ObjectReference{Name: "shared", Kind: "ClusterIssuer"}}},
+			annotations: map[string]string{
+				constants.TLSACMEAnnotation: "true",
+			},
+			expectedAnnotations: map[string]string{constants.ClusterIssuerAnnotation: "shared"},
+		},
+		{
+			name: "tls-acme with issuer",
+			cfg:  &config.Config{Ingresses: config.Ingresses{DefaultIssuer: cmmeta.ObjectReference{Name: "shared", Kind: "ClusterIssuer"}}},
+			annotations: map[string]string{
+				constants.TLSACMEAnnotation: "true",
+				constants.IssuerAnnotation:  "letsencrypt",
+			},
+			expectedAnnotations: map[string]string{constants.IssuerAnnotation: translate.PhysicalName("letsencrypt", testingutil.Namespace)},
+		},
+		{
+			name:                "tls-acme without default issuer",
+			annotations:         map[string]string{constants.TLSACMEAnnotation: "true"},
+			expectedAnnotations: map[string]string{},
+		},
+		{
+			name: "Mapped http01 ingress class",
+			cfg:  &config.Config{HTTP01: config.HTTP01{IngressClasses: map[string]string{"nginx": "host-nginx"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:             "letsencrypt",
+				constants.HTTP01IngressClassAnnotation: "nginx",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:             translate.PhysicalName("letsencrypt", testingutil.Namespace),
+				constants.HTTP01IngressClassAnnotation: "host-nginx",
+			},
+		},
+		{
+			name: "Unmapped http01 ingress class",
+			cfg:  &config.Config{HTTP01: config.HTTP01{IngressClasses: map[string]string{"nginx": "host-nginx"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:             "letsencrypt",
+				constants.HTTP01IngressClassAnnotation: "traefik",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:             translate.PhysicalName("letsencrypt", testingutil.Namespace),
+				constants.HTTP01IngressClassAnnotation: "traefik",
+			},
+		},
+		{
+			name:                "Dns name violating the domain policy",
+			cfg:                 &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"*.team-a.example.com"}}},
+			annotations:         map[string]string{constants.IssuerAnnotation: "letsencrypt"},
+			expectedAnnotations: map[string]string{},
+		},
+		{
+			name: "Common name violating the domain policy",
+			cfg:  &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"example.com"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:            "letsencrypt",
+				certmanagerv1.CommonNameAnnotationKey: "other.com",
+			},
+			expectedAnnotations: map[string]string{certmanagerv1.CommonNameAnnotationKey: "other.com"},
+		},
+		{
+			name: "Common name allowed by the domain policy",
+			cfg:  &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"example.com", "*.example.com"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:            "letsencrypt",
+				certmanagerv1.CommonNameAnnotationKey: "www.example.com",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:            translate.PhysicalName("letsencrypt", testingutil.Namespace),
+				certmanagerv1.CommonNameAnnotationKey: "www.example.com",
+			},
+		},
+		{
+			name: "Ip address violating the domain policy",
+			cfg:  &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"example.com"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:       "letsencrypt",
+				certmanagerv1.IPSANAnnotationKey: "10.0.0.1",
+			},
+			expectedAnnotations: map[string]string{certmanagerv1.IPSANAnnotationKey: "10.0.0.1"},
+		},
+		{
+			name: "Subject alternative names allowed by the domain policy",
+			cfg:  &config.Config{DomainPolicy: config.DomainPolicy{DNSNames: []string{"*.example.com", "example.com"}, IPRanges: []string{"10.0.0.0/8"}}},
+			annotations: map[string]string{
+				constants.IssuerAnnotation:          "letsencrypt",
+				certmanagerv1.AltNamesAnnotationKey: "a.example.com, b.example.com",
+				certmanagerv1.IPSANAnnotationKey:    "10.0.0.1",
+				certmanagerv1.URISANAnnotationKey:   "spiffe://example.com/web",
+			},
+			expectedAnnotations: map[string]string{
+				constants.IssuerAnnotation:          translate.PhysicalName("letsencrypt", testingutil.Namespace),
+				certmanagerv1.AltNamesAnnotationKey: "a.example.com, b.example.com",
+				certmanagerv1.IPSANAnnotationKey:    "10.0.0.1",
+				certmanagerv1.URISANAnnotationKey:   "spiffe://example.com/web",
+			},
+		},
+		{
+			name:                "Ingress without annotations for cert-manager",
+			annotations:         map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+			expectedAnnotations: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		cfg := testCase.cfg
+		if cfg == nil {
+			cfg = &config.Config{}
+		}
+
+		ingress := physicalIngress(testCase.annotations)
+		mutated, err := newTestHook(cfg).MutateCreatePhysical(context.TODO(), ingress)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		annotations := physicalIngress(testCase.expectedAnnotations).Annotations
+		if !reflect.DeepEqual(mutated.GetAnnotations(), annotations) {
+			t.Errorf("Test case %s: expected annotations %v, got %v", testCase.name, annotations, mutated.GetAnnotations())
+		}
+	}
+}
+
+func TestIssuerRef(t *testing.T) {
+	p := &ingressHook{defaultIssuer: cmmeta.ObjectReference{Name: "default", Kind: "ClusterIssuer"}}
+	issuerRef, ok := p.issuerRef(map[string]string{constants.TLSACMEAnnotation: "false"})
+	if ok {
+		t.Errorf("Expected no issuer for tls-acme set to false, got %v", issuerRef)
+	}
+
+	issuerRef, ok = p.issuerRef(map[string]string{constants.TLSACMEAnnotation: "true"})
+	if !ok || issuerRef != p.defaultIssuer {
+		t.Errorf("Expected default issuer, got %v", issuerRef)
+	}
+
+	issuerRef, ok = hooks.IssuerRef(map[string]string{constants.IssuerAnnotation: "issuer", constants.ClusterIssuerAnnotation: "cluster-issuer"})
+	if !ok || issuerRef.Name != "issuer" || issuerRef.Kind != "" {
+		t.Errorf("Expected the issuer annotation to take precedence, got %v", issuerRef)
+	}
+}
@@ -0,0 +1,175 @@
+package hooks
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/clusterissuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	synccontext "github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// IssuerTranslator translates the issuer annotations the cert-manager ingress- and gateway-shim
+// use to create certificates for ingresses and gateways
+type IssuerTranslator struct {
+	virtualClient   client.Client
+	physicalClient  client.Client
+	targetNamespace string
+
+	allowedClusterIssuers []string
+	externalIssuers       config.ExternalIssuers
+	domainPolicy          *policy.DomainPolicy
+
+	eventRecorder record.EventRecorder
+}
+
+func NewIssuerTranslator(ctx *synccontext.RegisterContext, cfg *config.Config) *IssuerTranslator {
+	return &IssuerTranslator{
+		virtualClient:   ctx.VirtualManager.GetClient(),
+		physicalClient:  ctx.PhysicalManager.GetClient(),
+		targetNamespace: ctx.TargetNamespace,
+
+		allowedClusterIssuers: cfg.ClusterIssuers.Allowed,
+		externalIssuers:       cfg.ExternalIssuers,
+		domainPolicy:          policy.NewDomainPolicy(ctx, cfg),
+
+		eventRecorder: ctx.VirtualManager.GetEventRecorderFor("cert-manager-shim-hook"),
+	}
+}
+
+// IssuerRef returns the issuer referenced by the given annotations
+func IssuerRef(annotations map[string]string) (cmmeta.ObjectReference, bool) {
+	if annotations[constants.IssuerAnnotation] != "" {
+		return cmmeta.ObjectReference{
+			Name:  annotations[constants.IssuerAnnotation],
+			Kind:  annotations[constants.IssuerKindAnnotation],
+			Group: annotations[constants.IssuerGroupAnnotation],
+		}, true
+	} else if annotations[constants.ClusterIssuerAnnotation] != "" {
+		return cmmeta.ObjectReference{
+			Name: annotations[constants.ClusterIssuerAnnotation],
+			Kind: "ClusterIssuer",
+		}, true
+	}
+
+	return cmmeta.ObjectReference{}, false
+}
+
+// annotationNames returns the names the common name and subject alternative name annotations of
+// the given object add to its certificate
+func annotationNames(annotations map[string]string) policy.Names {
+	return policy.Names{
+		DNSNames:    append(splitList(annotations[certmanagerv1.CommonNameAnnotationKey]), splitList(annotations[certmanagerv1.AltNamesAnnotationKey])...),
+		IPAddresses: splitList(annotations[certmanagerv1.IPSANAnnotationKey]),
+		URIs:        splitList(annotations[certmanagerv1.URISANAnnotationKey]),
+	}
+}
+
+// TranslateAnnotations replaces the issuer annotations of the physical object, which must not be
+// nil, with the translated issuer reference. If the object is not allowed to use the referenced
+// host cluster issuer or any of the dns names the certificate would be issued for, including the
+// names of the common name and subject alternative name annotations, violates the domain policy,
+// the issuer annotations are removed. kubernetes.io/tls-acme is removed in any case,
+// so that the host cluster cert-manager never falls back to its default issuer.
+func (t *IssuerTranslator) TranslateAnnotations(ctx context.Context, obj client.Object, issuerRef cmmeta.ObjectReference, dnsNames []string) error {
+	annotations := obj.GetAnnotations()
+	delete(annotations, constants.TLSACMEAnnotation)
+	delete(annotations, constants.IssuerAnnotation)
+	delete(annotations, constants.ClusterIssuerAnnotation)
+	delete(annotations, constants.IssuerKindAnnotation)
+	delete(annotations, constants.IssuerGroupAnnotation)
+
+	names := annotationNames(annotations)
+	names.DNSNames = append(dnsNames, names.DNSNames...)
+	pIssuerRef, allowed, err := t.TranslateRef(ctx, obj, issuerRef, names)
+	if err != nil || !allowed {
+		return err
+	}
+
+	if pIssuerRef.Kind == "ClusterIssuer" && (pIssuerRef.Group == "" || pIssuerRef.Group == certmanagerv1.SchemeGroupVersion.Group) {
+		annotations[constants.ClusterIssuerAnnotation] = pIssuerRef.Name
+		return nil
+	}
+
+	annotations[constants.IssuerAnnotation] = pIssuerRef.Name
+	if pIssuerRef.Kind != "" && pIssuerRef.Kind != "Issuer" {
+		annotations[constants.IssuerKindAnnotation] = pIssuerRef.Kind
+	}
+	if pIssuerRef.Group != "" {
+		annotations[constants.IssuerGroupAnnotation] = pIssuerRef.Group
+	}
+	return nil
+}
+
+// TranslateRef returns the physical issuer reference of the issuer the physical object references
+// within its virtual namespace. If the object references an issuer kind that is not supported, is not
+// allowed to use the referenced host cluster issuer or any of the requested names violates the domain
+// policy, false is returned.
+func (t *IssuerTranslator) TranslateRef(ctx context.Context, obj client.Object, issuerRef cmmeta.ObjectReference, names policy.Names) (cmmeta.ObjectReference, bool, error) {
+	annotations := obj.GetAnnotations()
+	namespace := annotations[translator.NamespaceAnnotation]
+
+	// is the referenced issuer kind synced from the vcluster?
+	if !issuers.IsSupportedRef(issuerRef, t.externalIssuers) {
+		kind := schema.GroupKind{Group: issuerRef.Group, Kind: issuerRef.Kind}
+		klog.Infof("remove issuer from %s/%s, because issuer kind %s is not supported", namespace, annotations[translator.NameAnnotation], kind.String())
+		t.eventRecorder.Eventf(virtualObject(obj), "Warning", "IssuerNotSupported", "No certificate is issued, because issuer kind %s is not supported within this vcluster", kind.String())
+		return cmmeta.ObjectReference{}, false, nil
+	}
+
+	pIssuerRef := issuers.TranslateIssuerRef(ctx, t.virtualClient, issuerRef, namespace, t.targetNamespace, t.externalIssuers)
+
+	// are the requested names allowed by the domain policy?
+	message, err := t.domainPolicy.Check(ctx, names)
+	if err != nil {
+		return cmmeta.ObjectReference{}, false, err
+	} else if message != "" && t.domainPolicy.AuditOnly() {
+		klog.Infof("%s/%s violates the domain policy: %s", namespace, annotations[translator.NameAnnotation], message)
+	} else if message != "" {
+		klog.Infof("remove issuer from %s/%s, because it violates the domain policy: %s", namespace, annotations[translator.NameAnnotation], message)
+		t.eventRecorder.Eventf(virtualObject(obj), "Warning", "DomainPolicyViolated", "No certificate is issued, because %s", message)
+		return cmmeta.ObjectReference{}, false, nil
+	}
+
+	// is the object allowed to use the referenced cluster issuer?
+	allowed, err := clusterissuers.IsAllowedRef(ctx, t.physicalClient, pIssuerRef, t.allowedClusterIssuers)
+	if err != nil {
+		return cmmeta.ObjectReference{}, false, err
+	} else if !allowed {
+		klog.Infof("remove issuer from %s/%s, because cluster issuer %s is not allowed", namespace, annotations[translator.NameAnnotation], issuerRef.Name)
+		return cmmeta.ObjectReference{}, false, nil
+	}
+
+	return pIssuerRef, true, nil
+}
+
+// virtualObject returns a reference to the virtual object of the given physical object for events
+func virtualObject(pObj client.Object) client.Object {
+	vObj := pObj.DeepCopyObject().(client.Object)
+	vObj.SetName(pObj.GetAnnotations()[translator.NameAnnotation])
+	vObj.SetNamespace(pObj.GetAnnotations()[translator.NamespaceAnnotation])
+	vObj.SetUID("")
+	vObj.SetResourceVersion("")
+	return vObj
+}
+
+// splitList returns the values of a comma separated annotation
+func splitList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,167 @@
+package pods
+
+import (
+	"context"
+	"fmt"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-sdk/hook"
+	synccontext "github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+const (
+	// CSIDriverName is the name of the cert-manager csi driver
+	CSIDriverName = "csi.cert-manager.io"
+
+	// volume attributes of the cert-manager csi driver
+	IssuerNameAttribute  = "csi.cert-manager.io/issuer-name"
+	IssuerKindAttribute  = "csi.cert-manager.io/issuer-kind"
+	IssuerGroupAttribute = "csi.cert-manager.io/issuer-group"
+	CommonNameAttribute  = "csi.cert-manager.io/common-name"
+	DNSNamesAttribute    = "csi.cert-manager.io/dns-names"
+	IPSANsAttribute      = "csi.cert-manager.io/ip-sans"
+	URISANsAttribute     = "csi.cert-manager.io/uri-sans"
+
+	// annotations vcluster sets on the physical pod
+	UIDAnnotation                = "vcluster.loft.sh/object-uid"
+	ServiceAccountNameAnnotation = "vcluster.loft.sh/service-account-name"
+)
+
+func NewPodHook(ctx *synccontext.RegisterContext, cfg *config.Config) hook.ClientHook {
+	return &podHook{
+		issuerTranslator: hooks.NewIssuerTranslator(ctx, cfg),
+	}
+}
+
+type podHook struct {
+	issuerTranslator *hooks.IssuerTranslator
+}
+
+func (p *podHook) Name() string {
+	return "pod-hook"
+}
+
+func (p *podHook) Resource() client.Object {
+	return &corev1.Pod{}
+}
+
+var _ hook.MutateCreatePhysical = &podHook{}
+
+func (p *podHook) MutateCreatePhysical(ctx context.Context, obj client.Object) (client.Object, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("object %v is not a pod", obj)
+	}
+
+	for i := range pod.Spec.Volumes {
+		csi := pod.Spec.Volumes[i].CSI
+		if csi == nil || csi.Driver != CSIDriverName || csi.VolumeAttributes == nil {
+			continue
+		}
+
+		err := p.mutateVolumeAttributes(ctx, pod, csi.VolumeAttributes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pod, nil
+}
+
+// mutateVolumeAttributes translates the issuer of the csi volume, so that the csi driver of the
+// host cluster creates the certificate request for the physical issuer. Pod variables within the
+// requested names are replaced with the virtual pod name and namespace, as the csi driver only
+// knows the physical pod.
+func (p *podHook) mutateVolumeAttributes(ctx context.Context, pod *corev1.Pod, attributes map[string]string) error {
+	for _, attribute := range []string{CommonNameAttribute, DNSNamesAttribute, URISANsAttribute} {
+		if attributes[attribute] != "" {
+			attributes[attribute] = replacePodVariables(attributes[attribute], pod)
+		}
+	}
+
+	issuerRef := cmmeta.ObjectReference{
+		Name:  attributes[IssuerNameAttribute],
+		Kind:  attributes[IssuerKindAttribute],
+		Group: attributes[IssuerGroupAttribute],
+	}
+	if issuerRef.Name == "" {
+		return nil
+	}
+
+	// the csi driver refuses volumes without issuer, so the pod does not start with an
+	// issuer it is not allowed to use
+	dnsNames := splitList(attributes[DNSNamesAttribute])
+	if attributes[CommonNameAttribute] != "" {
+		dnsNames = append(dnsNames, attributes[CommonNameAttribute])
+	}
+	pIssuerRef, allowed, err := p.issuerTranslator.TranslateRef(ctx, pod, issuerRef, policy.Names{
+		DNSNames:    dnsNames,
+		IPAddresses: splitList(attributes[IPSANsAttribute]),
+		URIs:        splitList(attributes[URISANsAttribute]),
+	})
+	if err != nil {
+		return err
+	} else if !allowed {
+		delete(attributes, IssuerNameAttribute)
+		delete(attributes, IssuerKindAttribute)
+		delete(attributes, IssuerGroupAttribute)
+		return nil
+	}
+
+	attributes[IssuerNameAttribute] = pIssuerRef.Name
+	if pIssuerRef.Kind != "" {
+		attributes[IssuerKindAttribute] = pIssuerRef.Kind
+	}
+	if pIssuerRef.Group != "" {
+		attributes[IssuerGroupAttribute] = pIssuerRef.Group
+	}
+	return nil
+}
+
+// replacePodVariables replaces the pod variables the csi driver supports with the virtual name,
+// namespace, uid and service account of the pod. Variables whose virtual value is unknown are kept.
+func replacePodVariables(value string, pod *corev1.Pod) string {
+	name := pod.Annotations[translator.NameAnnotation]
+	namespace := pod.Annotations[translator.NamespaceAnnotation]
+	if name == "" || namespace == "" {
+		return value
+	}
+
+	variables := []struct {
+		name  string
+		value string
+	}{
+		// $POD_NAMESPACE has to be replaced before its prefix $POD_NAME
+		{name: "POD_NAMESPACE", value: namespace},
+		{name: "POD_NAME", value: name},
+		{name: "POD_UID", value: pod.Annotations[UIDAnnotation]},
+		{name: "SERVICE_ACCOUNT_NAME", value: pod.Annotations[ServiceAccountNameAnnotation]},
+	}
+
+	replacements := []string{}
+	for _, variable := range variables {
+		if variable.value != "" {
+			replacements = append(replacements, "${"+variable.name+"}", variable.value, "$"+variable.name, variable.value)
+		}
+	}
+
+	return strings.NewReplacer(replacements...).Replace(value)
+}
+
+func splitList(value string) []string {
+	values := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			values = append(values, item)
+		}
+	}
+
+	return values
+}
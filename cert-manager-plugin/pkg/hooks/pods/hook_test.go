@@ -0,0 +1,117 @@
+package pods
+
+import (
+	"context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestReplacePodVariables(t *testing.T) {
+	annotations := map[string]string{
+		translator.NameAnnotation:      "app-0",
+		translator.NamespaceAnnotation: "team-a",
+		UIDAnnotation:                  "1234",
+		ServiceAccountNameAnnotation:   "app",
+	}
+
+	testCases := []struct {
+		name        string
+		value       string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			name:        "name and namespace",
+			value:       "${POD_NAME}.${POD_NAMESPACE}.svc.cluster.local,$POD_NAME.$POD_NAMESPACE",
+			annotations: annotations,
+			expected:    "app-0.team-a.svc.cluster.local,app-0.team-a",
+		},
+		{
+			name:        "uid and service account",
+			value:       "spiffe://cluster.local/ns/$POD_NAMESPACE/sa/${SERVICE_ACCOUNT_NAME}/pod/$POD_UID",
+			annotations: annotations,
+			expected:    "spiffe://cluster.local/ns/team-a/sa/app/pod/1234",
+		},
+		{
+			name:  "unknown uid and service account",
+			value: "${POD_NAME}/${POD_UID}/${SERVICE_ACCOUNT_NAME}",
+			annotations: map[string]string{
+				translator.NameAnnotation:      "app-0",
+				translator.NamespaceAnnotation: "team-a",
+			},
+			expected: "app-0/${POD_UID}/${SERVICE_ACCOUNT_NAME}",
+		},
+		{
+			name:     "pod not synced by vcluster",
+			value:    "${POD_NAME}.${POD_NAMESPACE}",
+			expected: "${POD_NAME}.${POD_NAMESPACE}",
+		},
+	}
+
+	for _, testCase := range testCases {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: testCase.annotations}}
+		replaced := replacePodVariables(testCase.value, pod)
+		if replaced != testCase.expected {
+			t.Errorf("Test case %s: expected %q, got %q", testCase.name, testCase.expected, replaced)
+		}
+	}
+}
+
+func TestMutateCreatePhysical(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app-0-x-team-a-x-vcluster",
+			Namespace: "vcluster",
+			Annotations: map[string]string{
+				translator.NameAnnotation:      "app-0",
+				translator.NamespaceAnnotation: "team-a",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "tls",
+					VolumeSource: corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{
+						Driver: CSIDriverName,
+						VolumeAttributes: map[string]string{
+							DNSNamesAttribute:   "${POD_NAME}.${POD_NAMESPACE}.svc.cluster.local",
+							CommonNameAttribute: "$POD_NAME",
+						},
+					}},
+				},
+				{
+					Name: "other",
+					VolumeSource: corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{
+						Driver: "other.csi.k8s.io",
+						VolumeAttributes: map[string]string{
+							DNSNamesAttribute: "${POD_NAME}",
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	mutated, err := (&podHook{}).MutateCreatePhysical(context.TODO(), pod)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []map[string]string{
+		{
+			DNSNamesAttribute:   "app-0.team-a.svc.cluster.local",
+			CommonNameAttribute: "app-0",
+		},
+		{
+			DNSNamesAttribute: "${POD_NAME}",
+		},
+	}
+	for i, volume := range mutated.(*corev1.Pod).Spec.Volumes {
+		if !reflect.DeepEqual(expected[i], volume.CSI.VolumeAttributes) {
+			t.Errorf("Expected attributes %v of volume %s, got %v", expected[i], volume.Name, volume.CSI.VolumeAttributes)
+		}
+	}
+}
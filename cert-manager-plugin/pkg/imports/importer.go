@@ -0,0 +1,310 @@
+package imports
+
+import (
+	context2 "context"
+	"fmt"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/records"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	vclustertranslate "github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"strings"
+	"time"
+)
+
+// New creates an importer that copies host secrets, such as a shared wildcard certificate of the
+// platform team, read-only into the vcluster and keeps them in sync with their renewals
+func New(cfg config.SecretImports) syncer.Base {
+	return &importer{
+		secrets:           cfg.Secrets,
+		configMap:         cfg.ConfigMap,
+		allowedNamespaces: cfg.AllowedNamespaces,
+		interval:          cfg.Interval.Duration,
+	}
+}
+
+type importer struct {
+	secrets           []config.SecretImport
+	configMap         string
+	allowedNamespaces []string
+	interval          time.Duration
+
+	// hostClient reads secrets of all host namespaces, which are not within the cache of the
+	// physical manager
+	hostClient client.Client
+
+	// records holds the virtual secrets created by the importer
+	records *records.Records
+
+	syncContext *context.SyncContext
+}
+
+func (i *importer) Name() string {
+	return "secret-importer"
+}
+
+var _ syncer.ControllerStarter = &importer{}
+
+func (i *importer) Register(ctx *context.RegisterContext) error {
+	if len(i.secrets) == 0 && i.configMap == "" {
+		return nil
+	}
+
+	var err error
+	i.hostClient, err = client.New(ctx.PhysicalManager.GetConfig(), client.Options{Scheme: ctx.PhysicalManager.GetScheme()})
+	if err != nil {
+		return err
+	}
+	i.records = records.New(i.hostClient, "imports")
+
+	// host secrets are checked periodically for renewals
+	i.syncContext = context.ConvertContext(ctx, i.Name())
+	if i.interval > 0 {
+		go wait.Until(func() {
+			err := i.syncAll(i.syncContext)
+			if err != nil {
+				i.syncContext.Log.Infof("error importing secrets: %v", err)
+			}
+		}, i.interval, ctx.Context.Done())
+	}
+
+	// changes to the imported secrets within the vcluster are reverted right away, including the removal
+	// of the imported label
+	isLabeled := func(obj client.Object) bool {
+		return obj.GetLabels() != nil && obj.GetLabels()[constants.ImportedLabel] == "true"
+	}
+	return ctrl.NewControllerManagedBy(ctx.VirtualManager).
+		Named(i.Name()).
+		For(&corev1.Secret{}, builder.WithPredicates(predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return isLabeled(e.Object) },
+			UpdateFunc:  func(e event.UpdateEvent) bool { return isLabeled(e.ObjectOld) || isLabeled(e.ObjectNew) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return isLabeled(e.Object) },
+			GenericFunc: func(e event.GenericEvent) bool { return isLabeled(e.Object) },
+		})).
+		Complete(i)
+}
+
+func (i *importer) Reconcile(ctx context2.Context, req ctrl.Request) (ctrl.Result, error) {
+	syncContext := *i.syncContext
+	syncContext.Context = ctx
+	syncContext.Log = log.NewFromExisting(i.syncContext.Log.Base(), req.Name)
+
+	secretImports, err := i.imports(&syncContext)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, secretImport := range secretImports {
+		from, to, _ := secretImport.NamespacedNames()
+		if to == req.NamespacedName {
+			return ctrl.Result{}, i.sync(&syncContext, from, to)
+		}
+	}
+
+	return ctrl.Result{}, i.deleteOrphan(&syncContext, req.NamespacedName)
+}
+
+// syncAll syncs all imports and deletes imported secrets whose import was removed
+func (i *importer) syncAll(ctx *context.SyncContext) error {
+	secretImports, err := i.imports(ctx)
+	if err != nil {
+		return err
+	}
+
+	imported := map[types.NamespacedName]bool{}
+	for _, secretImport := range secretImports {
+		from, to, _ := secretImport.NamespacedNames()
+		imported[to] = true
+		err = i.sync(ctx, from, to)
+		if err != nil {
+			ctx.Log.Infof("error importing secret %s into %s: %v", from.String(), to.String(), err)
+		}
+	}
+
+	recorded, err := i.records.List(ctx)
+	if err != nil {
+		return err
+	}
+	for to := range recorded {
+		if imported[to] {
+			continue
+		}
+
+		err = i.deleteOrphan(ctx, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sync creates or updates the virtual secret from the host secret. Virtual secrets that already
+// exist and were not imported are never overwritten.
+func (i *importer) sync(ctx *context.SyncContext, from, to types.NamespacedName) error {
+	pSecret := &corev1.Secret{}
+	err := i.hostClient.Get(ctx.Context, from, pSecret)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return i.deleteImported(ctx, to, fmt.Sprintf("host secret %s is gone", from.String()))
+		}
+
+		return err
+	} else if !i.isAllowed(ctx, pSecret) {
+		return i.deleteImported(ctx, to, fmt.Sprintf("host secret %s may not be imported into this vcluster", from.String()))
+	}
+
+	vSecret := &corev1.Secret{}
+	err = ctx.VirtualClient.Get(ctx.Context, to, vSecret)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return err
+		}
+
+		vSecret = translate(pSecret, from, to)
+		ctx.Log.Infof("create virtual secret %s/%s, because it is imported from host secret %s", vSecret.Namespace, vSecret.Name, from.String())
+		err = ctx.VirtualClient.Create(ctx.Context, vSecret)
+		if err != nil {
+			return err
+		}
+
+		return i.records.Record(ctx, vSecret)
+	}
+
+	imported, err := i.records.IsRecorded(ctx, vSecret)
+	if err != nil {
+		return err
+	} else if !imported {
+		ctx.Log.Infof("skip importing host secret %s, because virtual secret %s/%s already exists and was not imported", from.String(), vSecret.Namespace, vSecret.Name)
+		return nil
+	}
+
+	// the secret type is immutable, so we have to recreate the secret
+	if vSecret.Type != pSecret.Type {
+		return i.deleteImported(ctx, to, "its type differs from the host secret")
+	}
+
+	updated := translateUpdate(pSecret, vSecret, from)
+	if updated == nil {
+		return nil
+	}
+
+	ctx.Log.Infof("update virtual secret %s/%s, because it differs from the imported host secret %s", vSecret.Namespace, vSecret.Name, from.String())
+	return ctx.VirtualClient.Update(ctx.Context, updated)
+}
+
+// deleteOrphan deletes the imported virtual secret whose import was removed
+func (i *importer) deleteOrphan(ctx *context.SyncContext, to types.NamespacedName) error {
+	return i.deleteImported(ctx, to, "it is not imported anymore")
+}
+
+// deleteImported deletes the virtual secret if it was imported
+func (i *importer) deleteImported(ctx *context.SyncContext, to types.NamespacedName, reason string) error {
+	vSecret := &corev1.Secret{}
+	err := ctx.VirtualClient.Get(ctx.Context, to, vSecret)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return i.records.Forget(ctx, to)
+		}
+
+		return err
+	}
+
+	imported, err := i.records.IsRecorded(ctx, vSecret)
+	if err != nil {
+		return err
+	} else if !imported {
+		return nil
+	}
+
+	ctx.Log.Infof("delete virtual secret %s/%s, because %s", vSecret.Namespace, vSecret.Name, reason)
+	err = ctx.VirtualClient.Delete(ctx.Context, vSecret)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+
+	return i.records.Forget(ctx, to)
+}
+
+// isAllowed returns true if the host secret may be imported into this vcluster, either because its
+// namespace is allowed by the plugin config or because it is annotated with the vcluster as
+// <host namespace>/<vcluster name>
+func (i *importer) isAllowed(ctx *context.SyncContext, pSecret *corev1.Secret) bool {
+	for _, namespace := range i.allowedNamespaces {
+		if namespace == pSecret.Namespace {
+			return true
+		}
+	}
+
+	for _, vcluster := range splitList(pSecret.Annotations[constants.ImportToAnnotation]) {
+		if vcluster == ctx.TargetNamespace+"/"+vclustertranslate.Suffix {
+			return true
+		}
+	}
+
+	return false
+}
+
+// imports returns the imports of the plugin config and the import config map. Invalid imports of
+// the config map are skipped.
+func (i *importer) imports(ctx *context.SyncContext) ([]config.SecretImport, error) {
+	secretImports := append([]config.SecretImport{}, i.secrets...)
+	if i.configMap == "" {
+		return secretImports, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: ctx.TargetNamespace, Name: i.configMap}, configMap)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return secretImports, nil
+		}
+
+		return nil, err
+	}
+
+	for _, value := range configMap.Data {
+		for _, line := range splitList(value) {
+			splitted := strings.Split(line, "=")
+			secretImport := config.SecretImport{From: strings.TrimSpace(splitted[0])}
+			if len(splitted) == 2 {
+				secretImport.To = strings.TrimSpace(splitted[1])
+			}
+
+			_, _, err := secretImport.NamespacedNames()
+			if err != nil {
+				ctx.Log.Infof("skip invalid import %q of config map %s/%s: %v", line, configMap.Namespace, configMap.Name, err)
+				continue
+			}
+
+			secretImports = append(secretImports, secretImport)
+		}
+	}
+
+	return secretImports, nil
+}
+
+func splitList(value string) []string {
+	values := []string{}
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == ','
+	}) {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			values = append(values, line)
+		}
+	}
+
+	return values
+}
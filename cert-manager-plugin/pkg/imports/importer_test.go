@@ -0,0 +1,303 @@
+package imports
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/records"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	vclustertranslate "github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const targetNamespace = "vcluster"
+
+var (
+	from = types.NamespacedName{Namespace: "platform", Name: "wildcard-tls"}
+	to   = types.NamespacedName{Namespace: "default", Name: "wildcard-tls"}
+)
+
+// uidClient assigns uids to created objects like the api server, which the fake client does not
+type uidClient struct {
+	client.Client
+}
+
+func (c *uidClient) Create(ctx context2.Context, obj client.Object, opts ...client.CreateOption) error {
+	if obj.GetUID() == "" {
+		obj.SetUID(uuid.NewUUID())
+	}
+
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// newTestImporter returns an importer of the secret from into to and a sync context with fake clients
+func newTestImporter(t *testing.T, cfg config.SecretImports, vObjs, pObjs []client.Object) (*importer, *context.SyncContext) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	ctx := &context.SyncContext{
+		Context:         context2.TODO(),
+		VirtualClient:   &uidClient{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(vObjs...).Build()},
+		PhysicalClient:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(pObjs...).Build(),
+		TargetNamespace: targetNamespace,
+		Log:             log.New(t.Name()),
+	}
+
+	i := New(cfg).(*importer)
+	i.hostClient = ctx.PhysicalClient
+	i.records = records.New(i.hostClient, "imports")
+	return i, ctx
+}
+
+func hostSecret(annotations map[string]string, data string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: from.Namespace, Name: from.Name, Annotations: annotations},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte(data)},
+		Type:       corev1.SecretTypeTLS,
+	}
+}
+
+func getVirtualSecret(t *testing.T, ctx *context.SyncContext) *corev1.Secret {
+	vSecret := &corev1.Secret{}
+	err := ctx.VirtualClient.Get(ctx.Context, to, vSecret)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return vSecret
+}
+
+func TestIsAllowed(t *testing.T) {
+	testCases := []struct {
+		name              string
+		allowedNamespaces []string
+		annotations       map[string]string
+
+		expected bool
+	}{
+		{
+			name: "Not allowed",
+		},
+		{
+			name:              "Allowed namespace",
+			allowedNamespaces: []string{"other", from.Namespace},
+			expected:          true,
+		},
+		{
+			name:              "Namespace that is not allowed",
+			allowedNamespaces: []string{"other"},
+		},
+		{
+			name:        "Annotated with the vcluster",
+			annotations: map[string]string{constants.ImportToAnnotation: "other/vcluster, " + targetNamespace + "/" + vclustertranslate.Suffix},
+			expected:    true,
+		},
+		{
+			name:        "Annotated with another vcluster",
+			annotations: map[string]string{constants.ImportToAnnotation: "other/" + vclustertranslate.Suffix + "\n" + targetNamespace + "/other"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		i, ctx := newTestImporter(t, config.SecretImports{AllowedNamespaces: testCase.allowedNamespaces}, nil, nil)
+		allowed := i.isAllowed(ctx, hostSecret(testCase.annotations, "cert"))
+		if allowed != testCase.expected {
+			t.Errorf("Test case %s: expected %t, got %t", testCase.name, testCase.expected, allowed)
+		}
+	}
+}
+
+func TestImports(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "imports"},
+		Data: map[string]string{
+			"imports": "platform/ca = default/ca\ninvalid\nplatform/a=b, platform/b = team-a/b\n=default/c\nplatform/d=default/d=e",
+		},
+	}
+	cfg := config.SecretImports{
+		Secrets:   []config.SecretImport{{From: from.String(), To: to.String()}},
+		ConfigMap: configMap.Name,
+	}
+
+	i, ctx := newTestImporter(t, cfg, nil, []client.Object{configMap})
+	secretImports, err := i.imports(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []config.SecretImport{
+		{From: from.String(), To: to.String()},
+		{From: "platform/ca", To: "default/ca"},
+		{From: "platform/b", To: "team-a/b"},
+	}
+	if !reflect.DeepEqual(secretImports, expected) {
+		t.Errorf("Expected imports %v, got %v", expected, secretImports)
+	}
+
+	// a missing config map only leaves the imports of the plugin config
+	i, ctx = newTestImporter(t, cfg, nil, nil)
+	secretImports, err = i.imports(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if !reflect.DeepEqual(secretImports, expected[:1]) {
+		t.Errorf("Expected imports %v, got %v", expected[:1], secretImports)
+	}
+}
+
+func TestSync(t *testing.T) {
+	cfg := config.SecretImports{
+		Secrets:           []config.SecretImport{{From: from.String(), To: to.String()}},
+		AllowedNamespaces: []string{from.Namespace},
+	}
+	i, ctx := newTestImporter(t, cfg, nil, []client.Object{hostSecret(nil, "cert")})
+
+	// the host secret is imported
+	err := i.syncAll(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vSecret := getVirtualSecret(t, ctx)
+	if vSecret == nil || string(vSecret.Data[corev1.TLSCertKey]) != "cert" || vSecret.Labels[constants.ImportedLabel] != "true" || vSecret.Annotations[constants.ImportedFromAnnotation] != from.String() {
+		t.Fatalf("Expected imported virtual secret, got %v", vSecret)
+	}
+	if recorded, err := i.records.IsRecorded(ctx, vSecret); err != nil || !recorded {
+		t.Errorf("Expected virtual secret to be recorded, got %t and %v", recorded, err)
+	}
+
+	// edits within the vcluster are reverted, while added labels are kept
+	vSecret.Data[corev1.TLSCertKey] = []byte("edited")
+	vSecret.Labels = map[string]string{"app": "web"}
+	err = ctx.VirtualClient.Update(ctx.Context, vSecret)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = i.sync(ctx, from, to)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	vSecret = getVirtualSecret(t, ctx)
+	expectedLabels := map[string]string{"app": "web", constants.ImportedLabel: "true"}
+	if string(vSecret.Data[corev1.TLSCertKey]) != "cert" || !reflect.DeepEqual(vSecret.Labels, expectedLabels) {
+		t.Errorf("Expected edits to be reverted, got data %q and labels %v", string(vSecret.Data[corev1.TLSCertKey]), vSecret.Labels)
+	}
+
+	// the virtual secret is deleted once the host secret is gone
+	err = ctx.PhysicalClient.Delete(ctx.Context, hostSecret(nil, "cert"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = i.sync(ctx, from, to)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vSecret := getVirtualSecret(t, ctx); vSecret != nil {
+		t.Errorf("Expected virtual secret to be deleted, got %v", vSecret)
+	}
+	if recorded, err := i.records.List(ctx); err != nil || len(recorded) != 0 {
+		t.Errorf("Expected records to be empty, got %v and %v", recorded, err)
+	}
+}
+
+func TestSyncRemovedImport(t *testing.T) {
+	cfg := config.SecretImports{
+		Secrets:           []config.SecretImport{{From: from.String(), To: to.String()}},
+		AllowedNamespaces: []string{from.Namespace},
+	}
+	i, ctx := newTestImporter(t, cfg, nil, []client.Object{hostSecret(nil, "cert")})
+	err := i.syncAll(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if getVirtualSecret(t, ctx) == nil {
+		t.Fatalf("Expected imported virtual secret")
+	}
+
+	// the virtual secret is deleted once the import is removed
+	i.secrets = nil
+	err = i.syncAll(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vSecret := getVirtualSecret(t, ctx); vSecret != nil {
+		t.Errorf("Expected virtual secret to be deleted, got %v", vSecret)
+	}
+}
+
+func TestSyncExistingSecret(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: to.Namespace, Name: to.Name, UID: "tenant"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("tenant")},
+		Type:       corev1.SecretTypeTLS,
+	}
+
+	testCases := []struct {
+		name   string
+		labels map[string]string
+	}{
+		{
+			name: "Secret of the tenant",
+		},
+		{
+			name:   "Secret of the tenant labeled as imported",
+			labels: map[string]string{constants.ImportedLabel: "true"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		vSecret := existing.DeepCopy()
+		vSecret.Labels = testCase.labels
+		cfg := config.SecretImports{AllowedNamespaces: []string{from.Namespace}}
+		i, ctx := newTestImporter(t, cfg, []client.Object{vSecret}, []client.Object{hostSecret(nil, "cert")})
+
+		// virtual secrets that were not imported are neither overwritten nor deleted
+		err := i.sync(ctx, from, to)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		err = i.deleteOrphan(ctx, to)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if vSecret := getVirtualSecret(t, ctx); vSecret == nil || string(vSecret.Data[corev1.TLSCertKey]) != "tenant" {
+			t.Errorf("Test case %s: expected the secret of the tenant to be kept, got %v", testCase.name, vSecret)
+		}
+	}
+}
+
+func TestSyncNotAllowed(t *testing.T) {
+	cfg := config.SecretImports{Secrets: []config.SecretImport{{From: from.String(), To: to.String()}}}
+	annotations := map[string]string{constants.ImportToAnnotation: targetNamespace + "/" + vclustertranslate.Suffix}
+	i, ctx := newTestImporter(t, cfg, nil, []client.Object{hostSecret(annotations, "cert")})
+	err := i.syncAll(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	} else if getVirtualSecret(t, ctx) == nil {
+		t.Fatalf("Expected virtual secret to be imported")
+	}
+
+	// the imported secret is deleted once the host secret is annotated with another vcluster
+	pSecret := hostSecret(map[string]string{constants.ImportToAnnotation: "other/vcluster"}, "cert")
+	err = ctx.PhysicalClient.Update(ctx.Context, pSecret)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = i.syncAll(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if vSecret := getVirtualSecret(t, ctx); vSecret != nil {
+		t.Errorf("Expected virtual secret to be deleted, got %v", vSecret)
+	}
+}
@@ -0,0 +1,68 @@
+package imports
+
+import (
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// translate creates the virtual secret of the imported host secret. Only the data and type of the
+// host secret are imported, as its labels and annotations are meaningful within the host cluster only.
+func translate(pSecret *corev1.Secret, from, to types.NamespacedName) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      to.Name,
+			Namespace: to.Namespace,
+			Annotations: map[string]string{
+				constants.ImportedFromAnnotation: from.String(),
+			},
+			Labels: map[string]string{
+				constants.ImportedLabel: "true",
+			},
+		},
+		Data: pSecret.Data,
+		Type: pSecret.Type,
+	}
+}
+
+// translateUpdate returns an updated virtual secret if it differs from the host secret, either
+// because the host secret was renewed or the virtual secret was edited. Labels and annotations
+// added within the vcluster are preserved, while the ones of the importer are restored.
+func translateUpdate(pSecret, vSecret *corev1.Secret, from types.NamespacedName) *corev1.Secret {
+	var updated *corev1.Secret
+
+	// check data
+	if !equality.Semantic.DeepEqual(vSecret.Data, pSecret.Data) {
+		updated = newIfNil(updated, vSecret)
+		updated.Data = pSecret.Data
+	}
+
+	// check annotations
+	if vSecret.Annotations == nil || vSecret.Annotations[constants.ImportedFromAnnotation] != from.String() {
+		updated = newIfNil(updated, vSecret)
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[constants.ImportedFromAnnotation] = from.String()
+	}
+
+	// check labels
+	if vSecret.Labels == nil || vSecret.Labels[constants.ImportedLabel] != "true" {
+		updated = newIfNil(updated, vSecret)
+		if updated.Labels == nil {
+			updated.Labels = map[string]string{}
+		}
+		updated.Labels[constants.ImportedLabel] = "true"
+	}
+
+	return updated
+}
+
+func newIfNil(updated *corev1.Secret, pObj *corev1.Secret) *corev1.Secret {
+	if updated == nil {
+		return pObj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+var (
+	certificateExpirationTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "certificate_expiration_timestamp_seconds"),
+		"The date after which the certificate expires, expressed as unix epoch time",
+		[]string{"namespace", "name", "issuer_name", "issuer_kind", "issuer_group"}, nil,
+	)
+
+	certificateRenewalTimestamp = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "certificate_renewal_timestamp_seconds"),
+		"The date after which the certificate will be renewed, expressed as unix epoch time",
+		[]string{"namespace", "name", "issuer_name", "issuer_kind", "issuer_group"}, nil,
+	)
+
+	certificateReadyStatus = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "certificate_ready_status"),
+		"The ready condition status of the certificate",
+		[]string{"namespace", "name", "condition", "issuer_name", "issuer_kind", "issuer_group"}, nil,
+	)
+
+	issuerReadyStatus = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "issuer_ready_status"),
+		"The ready condition status of the issuer or cluster issuer",
+		[]string{"namespace", "name", "kind", "condition"}, nil,
+	)
+
+	conditionStatuses = []cmmeta.ConditionStatus{cmmeta.ConditionTrue, cmmeta.ConditionFalse, cmmeta.ConditionUnknown}
+)
+
+// collector reports the state of the certificates and issuers within the vcluster, keyed by their
+// virtual namespace and name. The objects are read from the virtual cache on every scrape, so that
+// no metrics of deleted objects are left behind.
+type collector struct {
+	virtualClient client.Client
+}
+
+func newCollector(virtualClient client.Client) prometheus.Collector {
+	return &collector{
+		virtualClient: virtualClient,
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- certificateExpirationTimestamp
+	ch <- certificateRenewalTimestamp
+	ch <- certificateReadyStatus
+	ch <- issuerReadyStatus
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	certificates := &certmanagerv1.CertificateList{}
+	err := c.virtualClient.List(ctx, certificates)
+	if err != nil {
+		klog.Infof("error listing certificates for metrics: %v", err)
+	} else {
+		for i := range certificates.Items {
+			collectCertificate(ch, &certificates.Items[i])
+		}
+	}
+
+	issuers := &certmanagerv1.IssuerList{}
+	err = c.virtualClient.List(ctx, issuers)
+	if err != nil {
+		klog.Infof("error listing issuers for metrics: %v", err)
+	} else {
+		for _, issuer := range issuers.Items {
+			collectIssuer(ch, issuer.Status.Conditions, issuer.Namespace, issuer.Name, certmanagerv1.IssuerKind)
+		}
+	}
+
+	clusterIssuers := &certmanagerv1.ClusterIssuerList{}
+	err = c.virtualClient.List(ctx, clusterIssuers)
+	if err != nil {
+		klog.Infof("error listing cluster issuers for metrics: %v", err)
+	} else {
+		for _, clusterIssuer := range clusterIssuers.Items {
+			collectIssuer(ch, clusterIssuer.Status.Conditions, "", clusterIssuer.Name, certmanagerv1.ClusterIssuerKind)
+		}
+	}
+}
+
+func collectCertificate(ch chan<- prometheus.Metric, certificate *certmanagerv1.Certificate) {
+	issuerRef := certificate.Spec.IssuerRef
+	issuerKind := issuerRef.Kind
+	if issuerKind == "" {
+		issuerKind = certmanagerv1.IssuerKind
+	}
+
+	expiration := 0.0
+	if certificate.Status.NotAfter != nil {
+		expiration = float64(certificate.Status.NotAfter.Unix())
+	}
+	ch <- prometheus.MustNewConstMetric(certificateExpirationTimestamp, prometheus.GaugeValue, expiration, certificate.Namespace, certificate.Name, issuerRef.Name, issuerKind, issuerRef.Group)
+
+	renewal := 0.0
+	if certificate.Status.RenewalTime != nil {
+		renewal = float64(certificate.Status.RenewalTime.Unix())
+	}
+	ch <- prometheus.MustNewConstMetric(certificateRenewalTimestamp, prometheus.GaugeValue, renewal, certificate.Namespace, certificate.Name, issuerRef.Name, issuerKind, issuerRef.Group)
+
+	status := cmmeta.ConditionUnknown
+	for _, condition := range certificate.Status.Conditions {
+		if condition.Type == certmanagerv1.CertificateConditionReady {
+			status = condition.Status
+			break
+		}
+	}
+	for _, conditionStatus := range conditionStatuses {
+		value := 0.0
+		if status == conditionStatus {
+			value = 1.0
+		}
+
+		ch <- prometheus.MustNewConstMetric(certificateReadyStatus, prometheus.GaugeValue, value, certificate.Namespace, certificate.Name, string(conditionStatus), issuerRef.Name, issuerKind, issuerRef.Group)
+	}
+}
+
+func collectIssuer(ch chan<- prometheus.Metric, conditions []certmanagerv1.IssuerCondition, namespace, name, kind string) {
+	status := cmmeta.ConditionUnknown
+	for _, condition := range conditions {
+		if condition.Type == certmanagerv1.IssuerConditionReady {
+			status = condition.Status
+			break
+		}
+	}
+
+	for _, conditionStatus := range conditionStatuses {
+		value := 0.0
+		if status == conditionStatus {
+			value = 1.0
+		}
+
+		ch <- prometheus.MustNewConstMetric(issuerReadyStatus, prometheus.GaugeValue, value, namespace, name, kind, string(conditionStatus))
+	}
+}
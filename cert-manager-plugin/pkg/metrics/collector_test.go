@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+// collect returns the values of the collected metrics keyed by their description and labels, which are
+// sorted by name
+func collect(t *testing.T, c prometheus.Collector) map[string]float64 {
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+
+	values := map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		err := metric.Write(m)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		key := metric.Desc().String()
+		for _, label := range m.GetLabel() {
+			key += " " + label.GetName() + "=" + label.GetValue()
+		}
+		values[key] = m.GetGauge().GetValue()
+	}
+	return values
+}
+
+func TestCollect(t *testing.T) {
+	notAfter := metav1.NewTime(time.Unix(1700000000, 0))
+	renewalTime := metav1.NewTime(time.Unix(1690000000, 0))
+	certificate := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.Namespace, Name: "tls"},
+		Spec:       certmanagerv1.CertificateSpec{IssuerRef: cmmeta.ObjectReference{Name: "letsencrypt"}},
+		Status: certmanagerv1.CertificateStatus{
+			NotAfter:    &notAfter,
+			RenewalTime: &renewalTime,
+			Conditions:  []certmanagerv1.CertificateCondition{{Type: certmanagerv1.CertificateConditionReady, Status: cmmeta.ConditionTrue}},
+		},
+	}
+	issuer := &certmanagerv1.Issuer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.Namespace, Name: "letsencrypt"},
+		Status: certmanagerv1.IssuerStatus{
+			Conditions: []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionFalse}},
+		},
+	}
+	clusterIssuer := &certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "ca"}}
+
+	values := collect(t, newCollector(testingutil.NewFakeClient(certificate, issuer, clusterIssuer)))
+
+	certificateLabels := " issuer_group= issuer_kind=Issuer issuer_name=letsencrypt name=tls namespace=" + testingutil.Namespace
+	expected := map[string]float64{
+		certificateExpirationTimestamp.String() + certificateLabels: 1700000000,
+		certificateRenewalTimestamp.String() + certificateLabels:    1690000000,
+	}
+	for _, status := range conditionStatuses {
+		certificateValue, issuerValue, clusterIssuerValue := 0.0, 0.0, 0.0
+		if status == cmmeta.ConditionTrue {
+			certificateValue = 1
+		}
+		if status == cmmeta.ConditionFalse {
+			issuerValue = 1
+		}
+		if status == cmmeta.ConditionUnknown {
+			clusterIssuerValue = 1
+		}
+
+		expected[certificateReadyStatus.String()+" condition="+string(status)+certificateLabels] = certificateValue
+		expected[issuerReadyStatus.String()+" condition="+string(status)+" kind=Issuer name=letsencrypt namespace="+testingutil.Namespace] = issuerValue
+		expected[issuerReadyStatus.String()+" condition="+string(status)+" kind=ClusterIssuer name=ca namespace="] = clusterIssuerValue
+	}
+
+	if len(values) != len(expected) {
+		t.Errorf("expected %d metrics, got %d: %v", len(expected), len(values), values)
+	}
+	for key, value := range expected {
+		actual, ok := values[key]
+		if !ok {
+			t.Errorf("expected metric %s, got %v", key, values)
+		} else if actual != value {
+			t.Errorf("expected metric %s to be %v, got %v", key, value, actual)
+		}
+	}
+}
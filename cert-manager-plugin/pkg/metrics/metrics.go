@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+)
+
+const (
+	// namespace is the prefix of all metrics of the plugin
+	namespace = "vcluster_cert_manager"
+
+	// ClusterVirtual and ClusterHost are the clusters the syncers write objects to
+	ClusterVirtual = "virtual"
+	ClusterHost    = "host"
+
+	// OperationCreate, OperationUpdate and OperationDelete are the writes reported per syncer and
+	// cluster. Status updates are reported as updates.
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+var (
+	syncTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_total",
+		Help:      "Total number of objects written per syncer, cluster and operation",
+	}, []string{"syncer", "cluster", "operation"})
+
+	syncErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sync_errors_total",
+		Help:      "Total number of failed object writes per syncer, cluster and operation",
+	}, []string{"syncer", "cluster", "operation"})
+
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Latency of object writes per syncer, cluster and operation",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"syncer", "cluster", "operation"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(syncTotal, syncErrorsTotal, syncDuration)
+}
+
+// Write performs a single write of the syncer to the given cluster and records it, so that reconciles
+// without changes are not counted. The operation is the write done to the object, independent of
+// the direction it is synced in.
+func Write(syncer, cluster, operation string, write func() error) error {
+	start := time.Now()
+	err := write()
+	syncTotal.WithLabelValues(syncer, cluster, operation).Inc()
+	syncDuration.WithLabelValues(syncer, cluster, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		syncErrorsTotal.WithLabelValues(syncer, cluster, operation).Inc()
+	}
+
+	return err
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	testCases := []struct {
+		name      string
+		syncer    string
+		cluster   string
+		operation string
+		err       error
+
+		expectedTotal  float64
+		expectedErrors float64
+	}{
+		{
+			name:          "Successful write",
+			syncer:        "certificate",
+			cluster:       ClusterHost,
+			operation:     OperationCreate,
+			expectedTotal: 1,
+		},
+		{
+			name:           "Failed write",
+			syncer:         "secret",
+			cluster:        ClusterVirtual,
+			operation:      OperationDelete,
+			err:            errors.New("conflict"),
+			expectedTotal:  1,
+			expectedErrors: 1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		written := 0
+		err := Write(testCase.syncer, testCase.cluster, testCase.operation, func() error {
+			written++
+			return testCase.err
+		})
+		if err != testCase.err {
+			t.Errorf("Test case %s: expected error %v, got %v", testCase.name, testCase.err, err)
+		}
+		if written != 1 {
+			t.Errorf("Test case %s: expected write to be performed once, got %d", testCase.name, written)
+		}
+
+		total := &dto.Metric{}
+		_ = syncTotal.WithLabelValues(testCase.syncer, testCase.cluster, testCase.operation).Write(total)
+		if total.GetCounter().GetValue() != testCase.expectedTotal {
+			t.Errorf("Test case %s: expected sync total %v, got %v", testCase.name, testCase.expectedTotal, total.GetCounter().GetValue())
+		}
+
+		errorsTotal := &dto.Metric{}
+		_ = syncErrorsTotal.WithLabelValues(testCase.syncer, testCase.cluster, testCase.operation).Write(errorsTotal)
+		if errorsTotal.GetCounter().GetValue() != testCase.expectedErrors {
+			t.Errorf("Test case %s: expected sync errors %v, got %v", testCase.name, testCase.expectedErrors, errorsTotal.GetCounter().GetValue())
+		}
+
+		duration := &dto.Metric{}
+		_ = syncDuration.WithLabelValues(testCase.syncer, testCase.cluster, testCase.operation).(prometheus.Histogram).Write(duration)
+		if duration.GetHistogram().GetSampleCount() != 1 {
+			t.Errorf("Test case %s: expected one observed duration, got %d", testCase.name, duration.GetHistogram().GetSampleCount())
+		}
+	}
+}
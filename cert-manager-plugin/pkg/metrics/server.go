@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	context2 "context"
+	"errors"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+)
+
+// New creates the metrics server, which serves the sync metrics as well as the state of the
+// virtual certificates and issuers on the configured bind address
+func New(cfg config.Metrics) syncer.Base {
+	return &server{
+		bindAddress: cfg.BindAddress,
+	}
+}
+
+type server struct {
+	bindAddress string
+}
+
+func (s *server) Name() string {
+	return "metrics-server"
+}
+
+var _ syncer.ControllerStarter = &server{}
+
+func (s *server) Register(ctx *context.RegisterContext) error {
+	if s.bindAddress == "" {
+		return nil
+	}
+
+	// the collector reads from the virtual cache, which is synced at this point
+	err := metrics.Registry.Register(newCollector(ctx.VirtualManager.GetClient()))
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.bindAddress)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+	httpServer := &http.Server{Handler: mux}
+
+	syncContext := context.ConvertContext(ctx, s.Name())
+	go func() {
+		<-ctx.Context.Done()
+		shutdownCtx, cancel := context2.WithTimeout(context2.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		syncContext.Log.Infof("serving metrics on %s", s.bindAddress)
+		err := httpServer.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			syncContext.Log.Infof("error serving metrics: %v", err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	synccontext "github.com/loft-sh/vcluster-sdk/syncer/context"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"net"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+const (
+	// DNSNamesKey and IPRangesKey are the keys of the domain policy config map. Values are
+	// separated by new lines or commas.
+	DNSNamesKey = "dnsNames"
+	IPRangesKey = "ipRanges"
+)
+
+// Names are the names a certificate is requested for
+type Names struct {
+	// DNSNames include the common name of the certificate
+	DNSNames       []string
+	IPAddresses    []string
+	URIs           []string
+	EmailAddresses []string
+}
+
+// CertificateRequestNames returns the names requested by the given PEM encoded x509 certificate
+// signing request
+func CertificateRequestNames(request []byte) (Names, error) {
+	block, _ := pem.Decode(request)
+	if block == nil {
+		return Names{}, fmt.Errorf("certificate signing request is not PEM encoded")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return Names{}, fmt.Errorf("parse certificate signing request: %v", err)
+	}
+
+	names := Names{
+		DNSNames:       append([]string{}, csr.DNSNames...),
+		EmailAddresses: csr.EmailAddresses,
+	}
+	if csr.Subject.CommonName != "" {
+		names.DNSNames = append(names.DNSNames, csr.Subject.CommonName)
+	}
+	for _, ipAddress := range csr.IPAddresses {
+		names.IPAddresses = append(names.IPAddresses, ipAddress.String())
+	}
+	for _, uri := range csr.URIs {
+		names.URIs = append(names.URIs, uri.String())
+	}
+
+	return names, nil
+}
+
+// DomainPolicy restricts the dns names and ip addresses certificates of the vcluster can be
+// issued for, so that tenants cannot obtain certificates for domains of other tenants
+type DomainPolicy struct {
+	physicalClient  client.Client
+	targetNamespace string
+
+	dnsNames  []string
+	ipRanges  []string
+	configMap string
+	auditOnly bool
+}
+
+func NewDomainPolicy(ctx *synccontext.RegisterContext, cfg *config.Config) *DomainPolicy {
+	return &DomainPolicy{
+		physicalClient:  ctx.PhysicalManager.GetClient(),
+		targetNamespace: ctx.TargetNamespace,
+
+		dnsNames:  cfg.DomainPolicy.DNSNames,
+		ipRanges:  cfg.DomainPolicy.IPRanges,
+		configMap: cfg.DomainPolicy.ConfigMap,
+		auditOnly: cfg.DomainPolicy.AuditOnly,
+	}
+}
+
+// Enabled returns true if a domain policy is configured
+func (p *DomainPolicy) Enabled() bool {
+	return len(p.dnsNames) > 0 || len(p.ipRanges) > 0 || p.configMap != ""
+}
+
+// AuditOnly returns true if violations should only be logged instead of refusing the certificate
+func (p *DomainPolicy) AuditOnly() bool {
+	return p.auditOnly
+}
+
+// Check returns a message describing the violation if any of the given names is not allowed by the
+// policy. The hosts of uris and the domains of email addresses have to match the allowed dns names.
+func (p *DomainPolicy) Check(ctx context.Context, names Names) (string, error) {
+	if !p.Enabled() {
+		return "", nil
+	}
+
+	allowedDNSNames, allowedIPRanges, err := p.allowed(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return check(names, allowedDNSNames, allowedIPRanges), nil
+}
+
+func check(names Names, allowedDNSNames []string, allowedIPRanges []*net.IPNet) string {
+	for _, dnsName := range names.DNSNames {
+		if !matchesDNSName(dnsName, allowedDNSNames) {
+			return fmt.Sprintf("dns name %s is not allowed by the domain policy of this vcluster", dnsName)
+		}
+	}
+	for _, ipAddress := range names.IPAddresses {
+		ip := net.ParseIP(ipAddress)
+		if ip == nil || !containsIP(ip, allowedIPRanges) {
+			return fmt.Sprintf("ip address %s is not allowed by the domain policy of this vcluster", ipAddress)
+		}
+	}
+	for _, uri := range names.URIs {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Hostname() == "" || !matchesDNSName(parsed.Hostname(), allowedDNSNames) {
+			return fmt.Sprintf("uri %s is not allowed by the domain policy of this vcluster", uri)
+		}
+	}
+	for _, emailAddress := range names.EmailAddresses {
+		i := strings.LastIndex(emailAddress, "@")
+		if i < 0 || !matchesDNSName(emailAddress[i+1:], allowedDNSNames) {
+			return fmt.Sprintf("email address %s is not allowed by the domain policy of this vcluster", emailAddress)
+		}
+	}
+
+	return ""
+}
+
+// allowed returns the dns name patterns and ip ranges of the plugin config and the policy config map
+func (p *DomainPolicy) allowed(ctx context.Context) ([]string, []*net.IPNet, error) {
+	dnsNames := append([]string{}, p.dnsNames...)
+	ipRanges := append([]string{}, p.ipRanges...)
+	if p.configMap != "" {
+		configMap := &corev1.ConfigMap{}
+		err := p.physicalClient.Get(ctx, types.NamespacedName{Namespace: p.targetNamespace, Name: p.configMap}, configMap)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return nil, nil, err
+		}
+
+		// a missing config map allows nothing
+		dnsNames = append(dnsNames, splitList(configMap.Data[DNSNamesKey])...)
+		ipRanges = append(ipRanges, splitList(configMap.Data[IPRangesKey])...)
+	}
+
+	ipNets := []*net.IPNet{}
+	for _, ipRange := range ipRanges {
+		_, ipNet, err := net.ParseCIDR(ipRange)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parse domain policy ip range %s: %v", ipRange, err)
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return dnsNames, ipNets, nil
+}
+
+// matchesDNSName returns true if the dns name matches any of the patterns. Patterns are either
+// exact dns names or wildcards like *.example.com, which match all subdomains of example.com.
+func matchesDNSName(dnsName string, patterns []string) bool {
+	dnsName = strings.TrimSuffix(strings.ToLower(dnsName), ".")
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+		if dnsName == pattern {
+			return true
+		} else if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(dnsName, pattern[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsIP(ip net.IP, ipNets []*net.IPNet) bool {
+	for _, ipNet := range ipNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(value string) []string {
+	values := []string{}
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == '\n' || r == ','
+	}) {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			values = append(values, line)
+		}
+	}
+
+	return values
+}
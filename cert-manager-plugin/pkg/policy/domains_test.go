@@ -0,0 +1,236 @@
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net"
+	"net/url"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const targetNamespace = "vcluster"
+
+func TestCheck(t *testing.T) {
+	policy := &DomainPolicy{
+		physicalClient:  fake.NewClientBuilder().Build(),
+		targetNamespace: targetNamespace,
+
+		dnsNames: []string{"tenant-a.example.com", "*.tenant-a.example.com"},
+		ipRanges: []string{"10.0.0.0/8"},
+	}
+
+	testCases := []struct {
+		name    string
+		names   Names
+		allowed bool
+	}{
+		{
+			name:    "exact dns name",
+			names:   Names{DNSNames: []string{"tenant-a.example.com"}},
+			allowed: true,
+		},
+		{
+			name:    "wildcard dns name",
+			names:   Names{DNSNames: []string{"www.tenant-a.example.com", "*.tenant-a.example.com", "WWW.Tenant-A.example.com."}},
+			allowed: true,
+		},
+		{
+			name:    "dns name of another tenant",
+			names:   Names{DNSNames: []string{"tenant-a.example.com", "tenant-b.example.com"}},
+			allowed: false,
+		},
+		{
+			name:    "dns name with allowed suffix",
+			names:   Names{DNSNames: []string{"eviltenant-a.example.com"}},
+			allowed: false,
+		},
+		{
+			name:    "ip address within range",
+			names:   Names{IPAddresses: []string{"10.1.2.3"}},
+			allowed: true,
+		},
+		{
+			name:    "ip address outside of range",
+			names:   Names{IPAddresses: []string{"192.168.0.1"}},
+			allowed: false,
+		},
+		{
+			name:    "invalid ip address",
+			names:   Names{IPAddresses: []string{"10.1.2"}},
+			allowed: false,
+		},
+		{
+			name:    "uri of allowed host",
+			names:   Names{URIs: []string{"spiffe://tenant-a.example.com/ns/default/sa/app"}},
+			allowed: true,
+		},
+		{
+			name:    "uri of another host",
+			names:   Names{URIs: []string{"spiffe://cluster.local/ns/default/sa/app"}},
+			allowed: false,
+		},
+		{
+			name:    "uri without host",
+			names:   Names{URIs: []string{"urn:tenant-a.example.com"}},
+			allowed: false,
+		},
+		{
+			name:    "email address of allowed domain",
+			names:   Names{EmailAddresses: []string{"admin@mail.tenant-a.example.com"}},
+			allowed: true,
+		},
+		{
+			name:    "email address of another domain",
+			names:   Names{EmailAddresses: []string{"admin@tenant-b.example.com"}},
+			allowed: false,
+		},
+		{
+			name:    "invalid email address",
+			names:   Names{EmailAddresses: []string{"tenant-a.example.com"}},
+			allowed: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		message, err := policy.Check(context.TODO(), testCase.names)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if (message == "") != testCase.allowed {
+			t.Errorf("Test case %s: expected allowed %t, got message %q", testCase.name, testCase.allowed, message)
+		}
+	}
+}
+
+func TestCheckConfigMap(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "domain-policy"},
+		Data: map[string]string{
+			DNSNamesKey: "tenant-a.example.com\n*.tenant-a.example.com",
+			IPRangesKey: "10.0.0.0/8, 172.16.0.0/12",
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		objects   []client.Object
+		dnsNames  []string
+		names     Names
+		allowed   bool
+		expectErr bool
+	}{
+		{
+			name:    "names of config map",
+			objects: []client.Object{configMap},
+			names:   Names{DNSNames: []string{"www.tenant-a.example.com"}, IPAddresses: []string{"172.16.0.1"}},
+			allowed: true,
+		},
+		{
+			name:     "names of plugin config",
+			objects:  []client.Object{configMap},
+			dnsNames: []string{"tenant-b.example.com"},
+			names:    Names{DNSNames: []string{"tenant-a.example.com", "tenant-b.example.com"}},
+			allowed:  true,
+		},
+		{
+			name:    "names not within config map",
+			objects: []client.Object{configMap},
+			names:   Names{DNSNames: []string{"tenant-b.example.com"}},
+			allowed: false,
+		},
+		{
+			name:    "missing config map",
+			names:   Names{DNSNames: []string{"tenant-a.example.com"}},
+			allowed: false,
+		},
+		{
+			name: "invalid ip range",
+			objects: []client.Object{&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "domain-policy"},
+				Data:       map[string]string{IPRangesKey: "10.0.0.0"},
+			}},
+			names:     Names{IPAddresses: []string{"10.0.0.1"}},
+			expectErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		policy := &DomainPolicy{
+			physicalClient:  fake.NewClientBuilder().WithObjects(testCase.objects...).Build(),
+			targetNamespace: targetNamespace,
+
+			dnsNames:  testCase.dnsNames,
+			configMap: "domain-policy",
+		}
+
+		message, err := policy.Check(context.TODO(), testCase.names)
+		if testCase.expectErr {
+			if err == nil {
+				t.Errorf("Test case %s: expected error, got none", testCase.name)
+			}
+			continue
+		} else if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if (message == "") != testCase.allowed {
+			t.Errorf("Test case %s: expected allowed %t, got message %q", testCase.name, testCase.allowed, message)
+		}
+	}
+}
+
+func TestCheckDisabled(t *testing.T) {
+	policy := &DomainPolicy{}
+	message, err := policy.Check(context.TODO(), Names{DNSNames: []string{"example.com"}, URIs: []string{"spiffe://cluster.local"}})
+	if err != nil || message != "" {
+		t.Errorf("Expected disabled policy to allow all names, got message %q and error %v", message, err)
+	}
+}
+
+func TestCertificateRequestNames(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	uri, _ := url.Parse("spiffe://tenant-a.example.com/ns/default/sa/app")
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: "tenant-a.example.com"},
+		DNSNames:       []string{"www.tenant-a.example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{uri},
+		EmailAddresses: []string{"admin@tenant-a.example.com"},
+	}, privateKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	names, err := CertificateRequestNames(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := Names{
+		DNSNames:       []string{"www.tenant-a.example.com", "tenant-a.example.com"},
+		IPAddresses:    []string{"10.0.0.1"},
+		URIs:           []string{"spiffe://tenant-a.example.com/ns/default/sa/app"},
+		EmailAddresses: []string{"admin@tenant-a.example.com"},
+	}
+	if !reflect.DeepEqual(expected, names) {
+		t.Errorf("Expected names %v, got %v", expected, names)
+	}
+
+	_, err = CertificateRequestNames([]byte("invalid"))
+	if err == nil {
+		t.Errorf("Expected error for invalid certificate signing request")
+	}
+}
@@ -0,0 +1,83 @@
+package policy
+
+import (
+	"fmt"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"strings"
+)
+
+// IssuerPolicy restricts the issuer types and acme servers issuers created within the vcluster can use,
+// as issuers synced to the host cluster have access to the credentials they reference
+type IssuerPolicy struct {
+	allowedTypes       []string
+	allowedACMEServers []string
+}
+
+func NewIssuerPolicy(cfg *config.Config) *IssuerPolicy {
+	return &IssuerPolicy{
+		allowedTypes:       cfg.IssuerPolicy.AllowedTypes,
+		allowedACMEServers: cfg.IssuerPolicy.AllowedACMEServers,
+	}
+}
+
+// Check returns a message describing why the issuer spec is denied by the policy
+func (p *IssuerPolicy) Check(spec *certmanagerv1.IssuerSpec) string {
+	message := p.CheckType(IssuerType(spec))
+	if message != "" {
+		return message
+	}
+	if spec.ACME != nil && len(p.allowedACMEServers) > 0 && !containsServer(p.allowedACMEServers, spec.ACME.Server) {
+		return fmt.Sprintf("ACME server %s is not allowed within this vcluster", spec.ACME.Server)
+	}
+
+	return ""
+}
+
+// CheckType returns a message describing why the issuer type is denied by the policy. The types of
+// external issuers are their kinds, e.g. AWSPCAIssuer.
+func (p *IssuerPolicy) CheckType(issuerType string) string {
+	if len(p.allowedTypes) > 0 && !containsFold(p.allowedTypes, issuerType) {
+		return fmt.Sprintf("Issuer type %s is not allowed within this vcluster", issuerType)
+	}
+
+	return ""
+}
+
+// IssuerType returns the type of the issuer spec, e.g. ACME or CA
+func IssuerType(spec *certmanagerv1.IssuerSpec) string {
+	switch {
+	case spec.ACME != nil:
+		return "ACME"
+	case spec.CA != nil:
+		return "CA"
+	case spec.SelfSigned != nil:
+		return "SelfSigned"
+	case spec.Vault != nil:
+		return "Vault"
+	case spec.Venafi != nil:
+		return "Venafi"
+	}
+
+	return "Unknown"
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsServer(servers []string, server string) bool {
+	for _, s := range servers {
+		if strings.TrimSuffix(s, "/") == strings.TrimSuffix(server, "/") {
+			return true
+		}
+	}
+
+	return false
+}
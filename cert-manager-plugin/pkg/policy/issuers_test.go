@@ -0,0 +1,102 @@
+package policy
+
+import (
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"testing"
+)
+
+func TestIssuerPolicyCheck(t *testing.T) {
+	acmeSpec := &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{ACME: &cmacme.ACMEIssuer{Server: "https://acme-v02.api.letsencrypt.org/directory"}}}
+	caSpec := &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{CA: &certmanagerv1.CAIssuer{SecretName: "ca"}}}
+
+	testCases := []struct {
+		name    string
+		policy  *IssuerPolicy
+		spec    *certmanagerv1.IssuerSpec
+		allowed bool
+	}{
+		{
+			name:    "empty policy",
+			policy:  &IssuerPolicy{},
+			spec:    caSpec,
+			allowed: true,
+		},
+		{
+			name:    "allowed type",
+			policy:  &IssuerPolicy{allowedTypes: []string{"acme"}},
+			spec:    acmeSpec,
+			allowed: true,
+		},
+		{
+			name:    "denied type",
+			policy:  &IssuerPolicy{allowedTypes: []string{"ACME"}},
+			spec:    caSpec,
+			allowed: false,
+		},
+		{
+			name:    "allowed acme server",
+			policy:  &IssuerPolicy{allowedACMEServers: []string{"https://acme-v02.api.letsencrypt.org/directory/"}},
+			spec:    acmeSpec,
+			allowed: true,
+		},
+		{
+			name:    "denied acme server",
+			policy:  &IssuerPolicy{allowedACMEServers: []string{"https://acme-staging-v02.api.letsencrypt.org/directory"}},
+			spec:    acmeSpec,
+			allowed: false,
+		},
+		{
+			name:    "acme servers do not restrict other types",
+			policy:  &IssuerPolicy{allowedACMEServers: []string{"https://acme-staging-v02.api.letsencrypt.org/directory"}},
+			spec:    caSpec,
+			allowed: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		message := testCase.policy.Check(testCase.spec)
+		if (message == "") != testCase.allowed {
+			t.Errorf("Test case %s: expected allowed %t, got message %q", testCase.name, testCase.allowed, message)
+		}
+	}
+}
+
+func TestIssuerPolicyCheckType(t *testing.T) {
+	policy := NewIssuerPolicy(&config.Config{IssuerPolicy: config.IssuerPolicy{AllowedTypes: []string{"CA", "AWSPCAIssuer"}}})
+
+	testCases := []struct {
+		name       string
+		issuerType string
+		allowed    bool
+	}{
+		{
+			name:       "allowed type",
+			issuerType: "CA",
+			allowed:    true,
+		},
+		{
+			name:       "allowed external issuer kind",
+			issuerType: "awspcaissuer",
+			allowed:    true,
+		},
+		{
+			name:       "denied external issuer kind",
+			issuerType: "StepIssuer",
+			allowed:    false,
+		},
+		{
+			name:       "unknown type",
+			issuerType: IssuerType(&certmanagerv1.IssuerSpec{}),
+			allowed:    false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		message := policy.CheckType(testCase.issuerType)
+		if (message == "") != testCase.allowed {
+			t.Errorf("Test case %s: expected allowed %t, got message %q", testCase.name, testCase.allowed, message)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package records
+
+import (
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// Records tracks the virtual objects the plugin created within a config map of the host namespace.
+// Labels and annotations of virtual objects can be edited within the vcluster, so they cannot prove
+// that an object was created by the plugin.
+type Records struct {
+	// hostClient should not be cached, as objects are recorded right after they were created
+	hostClient client.Client
+	name       string
+}
+
+// New returns the records of the given name, which are stored in the config map
+// cert-manager-plugin-<name>-x-<vcluster name>
+func New(hostClient client.Client, name string) *Records {
+	return &Records{
+		hostClient: hostClient,
+		name:       translate.SafeConcatName("cert-manager-plugin", name, "x", translate.Suffix),
+	}
+}
+
+// Name returns the name of the config map the records are stored in
+func (r *Records) Name() string {
+	return r.name
+}
+
+// List returns the uids of all recorded virtual objects
+func (r *Records) List(ctx *context.SyncContext) (map[types.NamespacedName]types.UID, error) {
+	configMap, err := r.get(ctx)
+	if err != nil || configMap == nil {
+		return map[types.NamespacedName]types.UID{}, err
+	}
+
+	records := map[types.NamespacedName]types.UID{}
+	for key, uid := range configMap.Data {
+		splitted := strings.SplitN(key, ".", 2)
+		if len(splitted) == 2 {
+			records[types.NamespacedName{Namespace: splitted[0], Name: splitted[1]}] = types.UID(uid)
+		}
+	}
+	return records, nil
+}
+
+// IsRecorded returns true if the virtual object was recorded, i.e. created by the plugin
+func (r *Records) IsRecorded(ctx *context.SyncContext, vObj client.Object) (bool, error) {
+	configMap, err := r.get(ctx)
+	if err != nil || configMap == nil {
+		return false, err
+	}
+
+	uid := configMap.Data[key(types.NamespacedName{Namespace: vObj.GetNamespace(), Name: vObj.GetName()})]
+	return uid != "" && uid == string(vObj.GetUID()), nil
+}
+
+// Record records the virtual object as created by the plugin
+func (r *Records) Record(ctx *context.SyncContext, vObj client.Object) error {
+	configMap, err := r.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	vName := types.NamespacedName{Namespace: vObj.GetNamespace(), Name: vObj.GetName()}
+	if configMap == nil {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ctx.TargetNamespace, Name: r.name},
+			Data:       map[string]string{key(vName): string(vObj.GetUID())},
+		}
+		ctx.Log.Infof("create config map %s/%s, because virtual object %s was created", configMap.Namespace, configMap.Name, vName.String())
+		return r.hostClient.Create(ctx.Context, configMap)
+	} else if configMap.Data[key(vName)] == string(vObj.GetUID()) {
+		return nil
+	}
+
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[key(vName)] = string(vObj.GetUID())
+	return r.hostClient.Update(ctx.Context, configMap)
+}
+
+// Forget removes the virtual object from the records
+func (r *Records) Forget(ctx *context.SyncContext, vName types.NamespacedName) error {
+	configMap, err := r.get(ctx)
+	if err != nil || configMap == nil {
+		return err
+	} else if _, ok := configMap.Data[key(vName)]; !ok {
+		return nil
+	}
+
+	delete(configMap.Data, key(vName))
+	return r.hostClient.Update(ctx.Context, configMap)
+}
+
+func (r *Records) get(ctx *context.SyncContext) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.hostClient.Get(ctx.Context, types.NamespacedName{Namespace: ctx.TargetNamespace, Name: r.name}, configMap)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return configMap, nil
+}
+
+// key returns the config map key of the virtual object. Namespaces cannot contain dots, so the key
+// is unambiguous.
+func key(vName types.NamespacedName) string {
+	return vName.Namespace + "." + vName.Name
+}
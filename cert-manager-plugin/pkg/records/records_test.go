@@ -0,0 +1,219 @@
+package records
+
+import (
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const recordsName = "bundles"
+
+func recordsConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.TargetNamespace, Name: New(nil, recordsName).Name()},
+		Data:       data,
+	}
+}
+
+func virtualConfigMap(namespace, uid string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "ca-bundle", UID: types.UID(uid)}}
+}
+
+func TestList(t *testing.T) {
+	testCases := []struct {
+		name      string
+		configMap *corev1.ConfigMap
+
+		expected map[types.NamespacedName]types.UID
+	}{
+		{
+			name:     "Missing config map",
+			expected: map[types.NamespacedName]types.UID{},
+		},
+		{
+			name:      "Recorded objects",
+			configMap: recordsConfigMap(map[string]string{"team-a.ca-bundle": "uid-a", "team-b.ca.bundle": "uid-b"}),
+			expected: map[types.NamespacedName]types.UID{
+				{Namespace: "team-a", Name: "ca-bundle"}: "uid-a",
+				{Namespace: "team-b", Name: "ca.bundle"}: "uid-b",
+			},
+		},
+		{
+			name:      "Corrupted config map",
+			configMap: recordsConfigMap(map[string]string{"team-a.ca-bundle": "uid-a", "garbage": "uid-b"}),
+			expected: map[types.NamespacedName]types.UID{
+				{Namespace: "team-a", Name: "ca-bundle"}: "uid-a",
+			},
+		},
+		{
+			name:      "Config map without data",
+			configMap: recordsConfigMap(nil),
+			expected:  map[types.NamespacedName]types.UID{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		pObjs := []client.Object{}
+		if testCase.configMap != nil {
+			pObjs = append(pObjs, testCase.configMap)
+		}
+		ctx := testingutil.NewSyncContext(t, nil, pObjs)
+
+		records, err := New(ctx.PhysicalClient, recordsName).List(ctx)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if !reflect.DeepEqual(records, testCase.expected) {
+			t.Errorf("Test case %s: expected %v, got %v", testCase.name, testCase.expected, records)
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	testCases := []struct {
+		name      string
+		configMap *corev1.ConfigMap
+		vObj      *corev1.ConfigMap
+
+		expected map[string]string
+	}{
+		{
+			name:     "Missing config map",
+			vObj:     virtualConfigMap("team-a", "uid-a"),
+			expected: map[string]string{"team-a.ca-bundle": "uid-a"},
+		},
+		{
+			name:      "Additional object",
+			configMap: recordsConfigMap(map[string]string{"team-a.ca-bundle": "uid-a"}),
+			vObj:      virtualConfigMap("team-b", "uid-b"),
+			expected:  map[string]string{"team-a.ca-bundle": "uid-a", "team-b.ca-bundle": "uid-b"},
+		},
+		{
+			name:      "Recreated object",
+			configMap: recordsConfigMap(map[string]string{"team-a.ca-bundle": "uid-a"}),
+			vObj:      virtualConfigMap("team-a", "uid-c"),
+			expected:  map[string]string{"team-a.ca-bundle": "uid-c"},
+		},
+		{
+			name:      "Corrupted config map",
+			configMap: recordsConfigMap(map[string]string{"garbage": "uid-b"}),
+			vObj:      virtualConfigMap("team-a", "uid-a"),
+			expected:  map[string]string{"garbage": "uid-b", "team-a.ca-bundle": "uid-a"},
+		},
+		{
+			name:      "Config map without data",
+			configMap: recordsConfigMap(nil),
+			vObj:      virtualConfigMap("team-a", "uid-a"),
+			expected:  map[string]string{"team-a.ca-bundle": "uid-a"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		pObjs := []client.Object{}
+		if testCase.configMap != nil {
+			pObjs = append(pObjs, testCase.configMap)
+		}
+		ctx := testingutil.NewSyncContext(t, nil, pObjs)
+		records := New(ctx.PhysicalClient, recordsName)
+
+		err := records.Record(ctx, testCase.vObj)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		configMap := &corev1.ConfigMap{}
+		err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: testingutil.TargetNamespace, Name: records.Name()}, configMap)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if !reflect.DeepEqual(configMap.Data, testCase.expected) {
+			t.Errorf("Test case %s: expected %v, got %v", testCase.name, testCase.expected, configMap.Data)
+		}
+
+		recorded, err := records.IsRecorded(ctx, testCase.vObj)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if !recorded {
+			t.Errorf("Test case %s: expected object to be recorded", testCase.name)
+		}
+
+		recorded, err = records.IsRecorded(ctx, virtualConfigMap(testCase.vObj.Namespace, "other"))
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if recorded {
+			t.Errorf("Test case %s: expected object with another uid not to be recorded", testCase.name)
+		}
+	}
+}
+
+func TestForget(t *testing.T) {
+	testCases := []struct {
+		name      string
+		configMap *corev1.ConfigMap
+		vName     types.NamespacedName
+
+		expected map[string]string
+	}{
+		{
+			name:  "Missing config map",
+			vName: types.NamespacedName{Namespace: "team-a", Name: "ca-bundle"},
+		},
+		{
+			name:      "Recorded object",
+			configMap: recordsConfigMap(map[string]string{"team-a.ca-bundle": "uid-a", "team-b.ca-bundle": "uid-b"}),
+			vName:     types.NamespacedName{Namespace: "team-a", Name: "ca-bundle"},
+			expected:  map[string]string{"team-b.ca-bundle": "uid-b"},
+		},
+		{
+			name:      "Object that was not recorded",
+			configMap: recordsConfigMap(map[string]string{"team-b.ca-bundle": "uid-b"}),
+			vName:     types.NamespacedName{Namespace: "team-a", Name: "ca-bundle"},
+			expected:  map[string]string{"team-b.ca-bundle": "uid-b"},
+		},
+		{
+			name:      "Corrupted config map",
+			configMap: recordsConfigMap(map[string]string{"garbage": "uid-b", "team-a.ca-bundle": "uid-a"}),
+			vName:     types.NamespacedName{Namespace: "team-a", Name: "ca-bundle"},
+			expected:  map[string]string{"garbage": "uid-b"},
+		},
+		{
+			name:      "Config map without data",
+			configMap: recordsConfigMap(nil),
+			vName:     types.NamespacedName{Namespace: "team-a", Name: "ca-bundle"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		pObjs := []client.Object{}
+		if testCase.configMap != nil {
+			pObjs = append(pObjs, testCase.configMap)
+		}
+		ctx := testingutil.NewSyncContext(t, nil, pObjs)
+		records := New(ctx.PhysicalClient, recordsName)
+
+		err := records.Forget(ctx, testCase.vName)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		recorded, err := records.List(ctx)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if _, ok := recorded[testCase.vName]; ok {
+			t.Errorf("Test case %s: expected %s to be forgotten, got %v", testCase.name, testCase.vName, recorded)
+		}
+		if testCase.configMap == nil {
+			continue
+		}
+
+		configMap := &corev1.ConfigMap{}
+		err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: testingutil.TargetNamespace, Name: records.Name()}, configMap)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if len(configMap.Data) != len(testCase.expected) || (len(testCase.expected) > 0 && !reflect.DeepEqual(configMap.Data, testCase.expected)) {
+			t.Errorf("Test case %s: expected %v, got %v", testCase.name, testCase.expected, configMap.Data)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package status
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
+)
+
+// Translator replaces physical object names within status messages and fields with their
+// virtual names, so that the host cluster layout is not exposed within the vcluster
+type Translator struct {
+	names map[string]string
+}
+
+func NewTranslator() *Translator {
+	return &Translator{
+		names: map[string]string{},
+	}
+}
+
+// AddName adds a physical name that should be replaced with the given virtual name
+func (t *Translator) AddName(pName, vName string) {
+	if pName == "" || vName == "" || pName == vName {
+		return
+	}
+
+	t.names[pName] = vName
+}
+
+// AddNamespacedName adds a physical object that should be replaced with the given virtual object,
+// either referenced by name only or by namespace and name
+func (t *Translator) AddNamespacedName(pName, vName types.NamespacedName) {
+	if pName.Name == "" || vName.Name == "" {
+		return
+	}
+
+	t.AddName(pName.String(), vName.String())
+	t.AddName(pName.Name, vName.Name)
+}
+
+// Translate replaces all added physical names within the given string
+func (t *Translator) Translate(str string) string {
+	if str == "" || len(t.names) == 0 {
+		return str
+	}
+
+	// longer names are replaced first, as they might contain shorter ones
+	pNames := make([]string, 0, len(t.names))
+	for pName := range t.names {
+		pNames = append(pNames, pName)
+	}
+	sort.Slice(pNames, func(i, j int) bool {
+		if len(pNames[i]) != len(pNames[j]) {
+			return len(pNames[i]) > len(pNames[j])
+		}
+		return pNames[i] < pNames[j]
+	})
+
+	replacements := make([]string, 0, len(pNames)*2)
+	for _, pName := range pNames {
+		replacements = append(replacements, pName, t.names[pName])
+	}
+
+	return strings.NewReplacer(replacements...).Replace(str)
+}
@@ -0,0 +1,64 @@
+package status
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		names map[string]string
+		str   string
+
+		expected string
+	}{
+		{
+			name:     "No names",
+			str:      "Certificate tls-x-team-a-x-vcluster is ready",
+			expected: "Certificate tls-x-team-a-x-vcluster is ready",
+		},
+		{
+			name:     "Physical name",
+			names:    map[string]string{"tls-x-team-a-x-vcluster": "tls"},
+			str:      "Certificate tls-x-team-a-x-vcluster is ready",
+			expected: "Certificate tls is ready",
+		},
+		{
+			name:     "Longer names first",
+			names:    map[string]string{"tls-x-team-a-x-vcluster": "tls", "tls-x-team-a-x-vcluster-1": "tls-1"},
+			str:      "Waiting for CertificateRequest tls-x-team-a-x-vcluster-1 of tls-x-team-a-x-vcluster",
+			expected: "Waiting for CertificateRequest tls-1 of tls",
+		},
+		{
+			name:     "Empty and unchanged names are ignored",
+			names:    map[string]string{"": "tls", "ca": "", "letsencrypt": "letsencrypt"},
+			str:      "Issuer letsencrypt uses secret ca",
+			expected: "Issuer letsencrypt uses secret ca",
+		},
+	}
+
+	for _, testCase := range testCases {
+		translator := NewTranslator()
+		for pName, vName := range testCase.names {
+			translator.AddName(pName, vName)
+		}
+
+		translated := translator.Translate(testCase.str)
+		if translated != testCase.expected {
+			t.Errorf("Test case %s: expected %q, got %q", testCase.name, testCase.expected, translated)
+		}
+	}
+}
+
+func TestAddNamespacedName(t *testing.T) {
+	translator := NewTranslator()
+	translator.AddNamespacedName(types.NamespacedName{Namespace: "vcluster", Name: "tls-x-team-a-x-vcluster"}, types.NamespacedName{Namespace: "team-a", Name: "tls"})
+	translator.AddNamespacedName(types.NamespacedName{Namespace: "vcluster"}, types.NamespacedName{Namespace: "team-a", Name: "ignored"})
+
+	translated := translator.Translate("Secret vcluster/tls-x-team-a-x-vcluster of certificate tls-x-team-a-x-vcluster in namespace vcluster")
+	expected := "Secret team-a/tls of certificate tls in namespace vcluster"
+	if translated != expected {
+		t.Errorf("Expected %q, got %q", expected, translated)
+	}
+}
@@ -0,0 +1,189 @@
+package certificaterequests
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/clienthelper"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+var (
+	IndexByCertificateRevision = "indexbycertificaterevision"
+)
+
+var _ syncer.IndicesRegisterer = &certificateRequestSyncer{}
+
+func (s *certificateRequestSyncer) RegisterIndices(ctx *context.RegisterContext) error {
+	err := ctx.PhysicalManager.GetFieldIndexer().IndexField(ctx.Context, &certmanagerv1.CertificateRequest{}, IndexByCertificateRevision, func(rawObj client.Object) []string {
+		name := certificateRevision(rawObj)
+		if name == "" {
+			return nil
+		}
+
+		return []string{name}
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.NamespacedTranslator.RegisterIndices(ctx)
+}
+
+func (s *certificateRequestSyncer) IsManaged(pObj client.Object) (bool, error) {
+	if translate.IsManaged(pObj) {
+		return true, nil
+	}
+
+	// certificate requests created by cert-manager for synced certificates or by the csi driver
+	// for synced issuers are managed as well
+	return s.certificateByCertificateRequest(pObj) != nil || s.issuerByCertificateRequest(pObj) != nil, nil
+}
+
+func (s *certificateRequestSyncer) VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName {
+	if vObj == nil || isBackward(vObj) {
+		name := s.physicalNameByCertificate(req)
+		if name.Name != "" {
+			return name
+		}
+
+		// certificate requests of the csi driver keep their name
+		if vObj == nil || !isCertificateRevision(vObj) {
+			name = s.physicalNameByIssuer(req)
+			if name.Name != "" {
+				return name
+			}
+		}
+	}
+
+	return s.NamespacedTranslator.VirtualToPhysical(req, vObj)
+}
+
+func (s *certificateRequestSyncer) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
+	namespacedName := s.NamespacedTranslator.PhysicalToVirtual(pObj)
+	if namespacedName.Name != "" {
+		return namespacedName
+	}
+
+	vCertificate := s.certificateByCertificateRequest(pObj)
+	if vCertificate != nil {
+		return types.NamespacedName{
+			Namespace: vCertificate.Namespace,
+			Name:      vCertificate.Name + "-" + pObj.GetAnnotations()[certmanagerv1.CertificateRequestRevisionAnnotationKey],
+		}
+	}
+
+	vIssuer := s.issuerByCertificateRequest(pObj)
+	if vIssuer != nil {
+		return types.NamespacedName{
+			Namespace: vIssuer.Namespace,
+			Name:      pObj.GetName(),
+		}
+	}
+
+	return types.NamespacedName{}
+}
+
+// certificateByCertificateRequest returns the virtual certificate the physical certificate
+// request was created for by cert-manager in the host cluster
+func (s *certificateRequestSyncer) certificateByCertificateRequest(pObj client.Object) *certmanagerv1.Certificate {
+	if certificateRevision(pObj) == "" {
+		return nil
+	}
+
+	vCertificate := &certmanagerv1.Certificate{}
+	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vCertificate, translator.IndexByPhysicalName, pObj.GetAnnotations()[certmanagerv1.CertificateNameKey])
+	if err != nil || vCertificate.Name == "" {
+		return nil
+	}
+
+	return vCertificate
+}
+
+// issuerByCertificateRequest returns the virtual issuer of the physical certificate request, which
+// was created within the host namespace by the cert-manager csi driver for a pod of the vcluster.
+// Certificate requests of cluster issuers are not resolved, as their virtual namespace is unknown.
+func (s *certificateRequestSyncer) issuerByCertificateRequest(pObj client.Object) *certmanagerv1.Issuer {
+	pCertificateRequest, ok := pObj.(*certmanagerv1.CertificateRequest)
+	if !ok || translate.IsManaged(pObj) || isCertificateRevision(pObj) {
+		return nil
+	}
+
+	issuerRef := pCertificateRequest.Spec.IssuerRef
+	if (issuerRef.Kind != "" && issuerRef.Kind != "Issuer") || (issuerRef.Group != "" && issuerRef.Group != certmanagerv1.SchemeGroupVersion.Group) {
+		return nil
+	}
+
+	vIssuer := &certmanagerv1.Issuer{}
+	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vIssuer, translator.IndexByPhysicalName, issuerRef.Name)
+	if err != nil || vIssuer.Name == "" {
+		return nil
+	}
+
+	return vIssuer
+}
+
+// physicalNameByCertificate returns the physical certificate request that was created by cert-manager
+// in the host cluster for the given virtual certificate request name, which is <certificate>-<revision>
+func (s *certificateRequestSyncer) physicalNameByCertificate(req types.NamespacedName) types.NamespacedName {
+	idx := strings.LastIndex(req.Name, "-")
+	if idx <= 0 {
+		return types.NamespacedName{}
+	}
+
+	pCertificateRequest := &certmanagerv1.CertificateRequest{}
+	err := clienthelper.GetByIndex(context2.TODO(), s.physicalClient, pCertificateRequest, IndexByCertificateRevision, translate.PhysicalName(req.Name[:idx], req.Namespace)+"/"+req.Name[idx+1:])
+	if err != nil || pCertificateRequest.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	return types.NamespacedName{
+		Namespace: pCertificateRequest.Namespace,
+		Name:      pCertificateRequest.Name,
+	}
+}
+
+// physicalNameByIssuer returns the physical certificate request the csi driver created with the given
+// name, if it belongs to an issuer within the namespace of the virtual certificate request. Otherwise
+// tenants could claim certificate requests of other namespaces by annotating their own ones.
+func (s *certificateRequestSyncer) physicalNameByIssuer(req types.NamespacedName) types.NamespacedName {
+	pCertificateRequest := &certmanagerv1.CertificateRequest{}
+	err := s.physicalClient.Get(context2.TODO(), types.NamespacedName{Namespace: s.targetNamespace, Name: req.Name}, pCertificateRequest)
+	if err != nil {
+		return types.NamespacedName{}
+	}
+
+	vIssuer := s.issuerByCertificateRequest(pCertificateRequest)
+	if vIssuer == nil || vIssuer.Namespace != req.Namespace {
+		return types.NamespacedName{}
+	}
+
+	return types.NamespacedName{
+		Namespace: pCertificateRequest.Namespace,
+		Name:      pCertificateRequest.Name,
+	}
+}
+
+func certificateRevision(pObj client.Object) string {
+	annotations := pObj.GetAnnotations()
+	if annotations == nil || annotations[certmanagerv1.CertificateNameKey] == "" || annotations[certmanagerv1.CertificateRequestRevisionAnnotationKey] == "" {
+		return ""
+	}
+
+	return annotations[certmanagerv1.CertificateNameKey] + "/" + annotations[certmanagerv1.CertificateRequestRevisionAnnotationKey]
+}
+
+// isCertificateRevision returns true if the certificate request was created by cert-manager for a certificate
+func isCertificateRevision(obj client.Object) bool {
+	return obj.GetAnnotations() != nil && obj.GetAnnotations()[certmanagerv1.CertificateNameKey] != ""
+}
+
+func isBackward(vObj client.Object) bool {
+	return vObj.GetAnnotations() != nil && vObj.GetAnnotations()[constants.BackwardSyncAnnotation] == "true"
+}
@@ -0,0 +1,207 @@
+package certificaterequests
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const targetNamespace = "vcluster"
+
+// indexedClient filters lists by the given field indices, which the fake client ignores
+type indexedClient struct {
+	client.Client
+
+	indices map[string]client.IndexerFunc
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	err := c.Client.List(ctx, list)
+	if err != nil || listOpts.FieldSelector == nil {
+		return err
+	}
+
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	filtered := []runtime.Object{}
+	for _, obj := range objs {
+		matches := true
+		for _, requirement := range listOpts.FieldSelector.Requirements() {
+			found := false
+			for _, value := range c.indices[requirement.Field](obj.(client.Object)) {
+				found = found || value == requirement.Value
+			}
+			matches = matches && found
+		}
+		if matches {
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return meta.SetList(list, filtered)
+}
+
+// testNamespacedTranslator translates names like the namespaced translator of the sdk
+type testNamespacedTranslator struct {
+	translator.NamespacedTranslator
+}
+
+func (t *testNamespacedTranslator) VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName {
+	return types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName(req.Name, req.Namespace)}
+}
+
+// PhysicalToVirtual only resolves objects synced by the translator, which are not used within the tests
+func (t *testNamespacedTranslator) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
+	return types.NamespacedName{}
+}
+
+func newTestSyncer(vObjs, pObjs []client.Object) *certificateRequestSyncer {
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+	physicalName := func(obj client.Object) []string {
+		return []string{translator.ObjectPhysicalName(obj)}
+	}
+
+	return &certificateRequestSyncer{
+		NamespacedTranslator: &testNamespacedTranslator{},
+
+		virtualClient: &indexedClient{
+			Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(vObjs...).Build(),
+			indices: map[string]client.IndexerFunc{translator.IndexByPhysicalName: physicalName},
+		},
+		physicalClient: &indexedClient{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(pObjs...).Build(),
+			indices: map[string]client.IndexerFunc{IndexByCertificateRevision: func(obj client.Object) []string {
+				return []string{certificateRevision(obj)}
+			}},
+		},
+		targetNamespace: targetNamespace,
+	}
+}
+
+func TestVirtualToPhysical(t *testing.T) {
+	vIssuer := &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "ca"}}
+	pCSIRequest := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "csi-request"},
+		Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("ca", "team-a")}},
+	}
+	pRevisionRequest := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: targetNamespace,
+			Name:      "tls-abcde",
+			Annotations: map[string]string{
+				certmanagerv1.CertificateNameKey:                      translate.PhysicalName("tls", "team-a"),
+				certmanagerv1.CertificateRequestRevisionAnnotationKey: "1",
+			},
+		},
+	}
+	backward := map[string]string{constants.BackwardSyncAnnotation: "true"}
+	backwardRevision := map[string]string{constants.BackwardSyncAnnotation: "true", certmanagerv1.CertificateNameKey: "tls"}
+
+	testCases := []struct {
+		name     string
+		req      types.NamespacedName
+		vObj     client.Object
+		expected types.NamespacedName
+	}{
+		{
+			name:     "csi driver request",
+			req:      types.NamespacedName{Namespace: "team-a", Name: "csi-request"},
+			vObj:     &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "csi-request", Annotations: backward}},
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: "csi-request"},
+		},
+		{
+			name:     "deleted csi driver request",
+			req:      types.NamespacedName{Namespace: "team-a", Name: "csi-request"},
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: "csi-request"},
+		},
+		{
+			name:     "csi driver request of another namespace",
+			req:      types.NamespacedName{Namespace: "team-b", Name: "csi-request"},
+			vObj:     &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "csi-request", Annotations: backward}},
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName("csi-request", "team-b")},
+		},
+		{
+			name:     "certificate revision",
+			req:      types.NamespacedName{Namespace: "team-a", Name: "tls-1"},
+			vObj:     &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "tls-1", Annotations: backwardRevision}},
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: "tls-abcde"},
+		},
+		{
+			name:     "certificate revision of another namespace",
+			req:      types.NamespacedName{Namespace: "team-b", Name: "tls-1"},
+			vObj:     &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "tls-1", Annotations: backwardRevision}},
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName("tls-1", "team-b")},
+		},
+		{
+			name:     "request created within the vcluster",
+			req:      types.NamespacedName{Namespace: "team-a", Name: "csi-request"},
+			vObj:     &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "csi-request"}},
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName("csi-request", "team-a")},
+		},
+	}
+
+	s := newTestSyncer([]client.Object{vIssuer}, []client.Object{pCSIRequest, pRevisionRequest})
+	for _, testCase := range testCases {
+		name := s.VirtualToPhysical(testCase.req, testCase.vObj)
+		if name != testCase.expected {
+			t.Errorf("Test case %s: expected %s, got %s", testCase.name, testCase.expected.String(), name.String())
+		}
+	}
+}
+
+func TestPhysicalToVirtual(t *testing.T) {
+	vIssuer := &certmanagerv1.Issuer{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "ca"}}
+	s := newTestSyncer([]client.Object{vIssuer}, nil)
+
+	testCases := []struct {
+		name     string
+		pObj     *certmanagerv1.CertificateRequest
+		expected types.NamespacedName
+	}{
+		{
+			name: "csi driver request",
+			pObj: &certmanagerv1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "csi-request"},
+				Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("ca", "team-a")}},
+			},
+			expected: types.NamespacedName{Namespace: "team-a", Name: "csi-request"},
+		},
+		{
+			name: "request of a host cluster issuer",
+			pObj: &certmanagerv1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "csi-request"},
+				Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "ca", Kind: "ClusterIssuer"}},
+			},
+		},
+		{
+			name: "request of an unknown issuer",
+			pObj: &certmanagerv1.CertificateRequest{
+				ObjectMeta: metav1.ObjectMeta{Namespace: targetNamespace, Name: "csi-request"},
+				Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "unknown"}},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		name := s.PhysicalToVirtual(testCase.pObj)
+		if name != testCase.expected {
+			t.Errorf("Test case %s: expected %s, got %s", testCase.name, testCase.expected.String(), name.String())
+		}
+	}
+}
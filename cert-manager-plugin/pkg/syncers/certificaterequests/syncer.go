@@ -0,0 +1,292 @@
+package certificaterequests
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/conditions"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/clusterissuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
+)
+
+func New(ctx *context.RegisterContext, cfg *config.Config) syncer.Syncer {
+	return &certificateRequestSyncer{
+		NamespacedTranslator: translator.NewNamespacedTranslator(ctx, "certificaterequest", &certmanagerv1.CertificateRequest{}),
+
+		virtualClient:   ctx.VirtualManager.GetClient(),
+		physicalClient:  ctx.PhysicalManager.GetClient(),
+		targetNamespace: ctx.TargetNamespace,
+
+		allowedClusterIssuers: cfg.ClusterIssuers.Allowed,
+		externalIssuers:       cfg.ExternalIssuers,
+		domainPolicy:          policy.NewDomainPolicy(ctx, cfg),
+		rejections:            admission.NewRejections(),
+	}
+}
+
+type certificateRequestSyncer struct {
+	translator.NamespacedTranslator
+
+	virtualClient   client.Client
+	physicalClient  client.Client
+	targetNamespace string
+
+	allowedClusterIssuers []string
+	externalIssuers       config.ExternalIssuers
+	domainPolicy          *policy.DomainPolicy
+	rejections            *admission.Rejections
+}
+
+var _ syncer.Initializer = &certificateRequestSyncer{}
+
+func (s *certificateRequestSyncer) Init(ctx *context.RegisterContext) error {
+	return translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), certmanagerv1.SchemeGroupVersion.WithKind("CertificateRequest"))
+}
+
+func (s *certificateRequestSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
+	vCertificateRequest := vObj.(*certmanagerv1.CertificateRequest)
+
+	// was certificate request created by a certificate in the host cluster?
+	if isBackward(vCertificateRequest) {
+		// delete here as certificate request is no longer needed
+		ctx.Log.Infof("delete virtual certificate request %s/%s, because physical got deleted", vObj.GetNamespace(), vObj.GetName())
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+			return ctx.VirtualClient.Delete(ctx.Context, vObj)
+		})
+	}
+
+	// is the referenced issuer kind synced from the vcluster?
+	if !issuers.IsSupportedRef(vCertificateRequest.Spec.IssuerRef, s.externalIssuers) {
+		kind := schema.GroupKind{Group: vCertificateRequest.Spec.IssuerRef.Group, Kind: vCertificateRequest.Spec.IssuerRef.Kind}
+		ctx.Log.Infof("skip syncing certificate request %s/%s, because issuer kind %s is not supported", vObj.GetNamespace(), vObj.GetName(), kind.String())
+		s.EventRecorder().Eventf(vObj, "Warning", "IssuerNotSupported", "Issuer kind %s is not supported within this vcluster", kind.String())
+		return ctrl.Result{}, nil
+	}
+
+	// is the certificate request allowed to use the referenced cluster issuer?
+	pCertificateRequest := s.translate(vCertificateRequest)
+	allowed, err := clusterissuers.IsAllowedRef(ctx.Context, ctx.PhysicalClient, pCertificateRequest.Spec.IssuerRef, s.allowedClusterIssuers)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if !allowed {
+		ctx.Log.Infof("skip syncing certificate request %s/%s, because cluster issuer %s is not allowed", vObj.GetNamespace(), vObj.GetName(), vCertificateRequest.Spec.IssuerRef.Name)
+		s.EventRecorder().Eventf(vObj, "Warning", "SyncError", "ClusterIssuer %s is not allowed within this vcluster", vCertificateRequest.Spec.IssuerRef.Name)
+		return ctrl.Result{}, nil
+	}
+
+	// are the names requested by the csr allowed by the domain policy?
+	message, err := s.checkDomainPolicy(ctx, vCertificateRequest)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if message != "" {
+		ctx.Log.Infof("skip syncing certificate request %s/%s, because it violates the domain policy: %s", vObj.GetNamespace(), vObj.GetName(), message)
+		s.EventRecorder().Eventf(vObj, "Warning", "DomainPolicyViolated", "Certificate request is not synced, because %s", message)
+		return ctrl.Result{}, nil
+	}
+
+	// was the certificate request refused by the host cluster before? The spec is immutable, so only
+	// the creation can be refused.
+	rejection := s.rejections.Get(vCertificateRequest)
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationCreate, func() error {
+		_, err := s.SyncDownCreate(ctx, vObj, pCertificateRequest)
+		return err
+	})
+	if admission.IsRejected(err) {
+		return s.reject(ctx, vCertificateRequest, pCertificateRequest, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vCertificateRequest)
+	}
+
+	return ctrl.Result{}, err
+}
+
+// reject sets the Ready condition of the virtual certificate request, which the host cluster refused, to
+// false and records a warning event. The certificate request is synced again after an exponential backoff.
+func (s *certificateRequestSyncer) reject(ctx *context.SyncContext, vCertificateRequest, pCertificateRequest *certmanagerv1.CertificateRequest, err error) (ctrl.Result, error) {
+	message := statusTranslator(pCertificateRequest, vCertificateRequest).Translate(err.Error())
+	backoff := s.rejections.Reject(vCertificateRequest, message)
+
+	newStatus := vCertificateRequest.Status.DeepCopy()
+	newStatus.Conditions = conditions.Set(newStatus.Conditions, certmanagerv1.CertificateRequestCondition{
+		Type:    certmanagerv1.CertificateRequestConditionReady,
+		Status:  cmmeta.ConditionFalse,
+		Reason:  admission.HostValidationFailedReason,
+		Message: message,
+	})
+	if !equality.Semantic.DeepEqual(vCertificateRequest.Status, *newStatus) {
+		newCertificateRequest := vCertificateRequest.DeepCopy()
+		newCertificateRequest.Status = *newStatus
+		ctx.Log.Infof("update virtual certificate request %s/%s, because host cluster refused it: %s", vCertificateRequest.Namespace, vCertificateRequest.Name, message)
+		s.EventRecorder().Eventf(vCertificateRequest, "Warning", admission.HostValidationFailedReason, message)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newCertificateRequest)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+// checkDomainPolicy returns a message if the names requested by the csr of the certificate request violate
+// the domain policy. Certificate requests whose csr cannot be parsed are refused as well. In audit mode,
+// violations are only logged.
+func (s *certificateRequestSyncer) checkDomainPolicy(ctx *context.SyncContext, vCertificateRequest *certmanagerv1.CertificateRequest) (string, error) {
+	if !s.domainPolicy.Enabled() {
+		return "", nil
+	}
+
+	names, err := policy.CertificateRequestNames(vCertificateRequest.Spec.Request)
+	if err != nil {
+		return err.Error(), nil
+	}
+
+	message, err := s.domainPolicy.Check(ctx.Context, names)
+	if err != nil || message == "" {
+		return "", err
+	} else if s.domainPolicy.AuditOnly() {
+		ctx.Log.Infof("certificate request %s/%s violates the domain policy: %s", vCertificateRequest.Namespace, vCertificateRequest.Name, message)
+		return "", nil
+	}
+
+	return message, nil
+}
+
+func (s *certificateRequestSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
+	vCertificateRequest := vObj.(*certmanagerv1.CertificateRequest)
+	pCertificateRequest := pObj.(*certmanagerv1.CertificateRequest)
+
+	// was certificate request created by a certificate in the host cluster?
+	if isBackward(vCertificateRequest) {
+		return s.syncBackwards(ctx, pCertificateRequest, vCertificateRequest)
+	}
+
+	// approvers in the host cluster cannot see the virtual identity, so we sync down approvals and
+	// denials made within the vcluster for issuers of the vcluster. Requests for host cluster issuers
+	// have to be approved by the approvers of the host cluster.
+	approvable, err := isApprovable(ctx.Context, ctx.PhysicalClient, pCertificateRequest)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	updated := translateApproval(pCertificateRequest, vCertificateRequest)
+	if approvable && updated != nil {
+		ctx.Log.Infof("update physical certificate request %s/%s, because approval is out of sync", pCertificateRequest.Namespace, pCertificateRequest.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+			return ctx.PhysicalClient.Status().Update(ctx.Context, updated)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	vStatus := translateStatus(pCertificateRequest, vCertificateRequest)
+	if !equality.Semantic.DeepEqual(vCertificateRequest.Status, *vStatus) {
+		newCertificateRequest := vCertificateRequest.DeepCopy()
+		newCertificateRequest.Status = *vStatus
+		ctx.Log.Infof("update virtual certificate request %s/%s, because status is out of sync", vCertificateRequest.Namespace, vCertificateRequest.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newCertificateRequest)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	// did the certificate request change? The spec is immutable, so only metadata is synced
+	updated = s.translateUpdate(pCertificateRequest, vCertificateRequest)
+	if updated == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+		_, err := s.SyncDownUpdate(ctx, vObj, updated)
+		return err
+	})
+}
+
+func (s *certificateRequestSyncer) syncBackwards(ctx *context.SyncContext, pCertificateRequest, vCertificateRequest *certmanagerv1.CertificateRequest) (ctrl.Result, error) {
+	vStatus := translateStatus(pCertificateRequest, vCertificateRequest)
+	if !equality.Semantic.DeepEqual(vCertificateRequest.Status, *vStatus) {
+		newCertificateRequest := vCertificateRequest.DeepCopy()
+		newCertificateRequest.Status = *vStatus
+		ctx.Log.Infof("update virtual certificate request %s/%s, because status is out of sync", vCertificateRequest.Namespace, vCertificateRequest.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newCertificateRequest)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	updated := s.translateUpdateBackwards(pCertificateRequest, vCertificateRequest)
+	if updated != nil {
+		ctx.Log.Infof("update virtual certificate request %s/%s, because it is out of sync", vCertificateRequest.Namespace, vCertificateRequest.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Update(ctx.Context, updated)
+		})
+	}
+
+	return ctrl.Result{}, nil
+}
+
+var _ syncer.UpSyncer = &certificateRequestSyncer{}
+
+func (s *certificateRequestSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object) (ctrl.Result, error) {
+	pCertificateRequest := pObj.(*certmanagerv1.CertificateRequest)
+
+	// was certificate request created by a synced certificate?
+	vCertificate := s.certificateByCertificateRequest(pCertificateRequest)
+	if vCertificate != nil {
+		vCertificateRequest := s.translateBackwards(pCertificateRequest, vCertificate)
+		ctx.Log.Infof("create virtual certificate request %s/%s, because physical is there and virtual is missing", vCertificateRequest.Namespace, vCertificateRequest.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+			return ctx.VirtualClient.Create(ctx.Context, vCertificateRequest)
+		})
+	}
+
+	// was certificate request created by the csi driver for a synced issuer?
+	vIssuer := s.issuerByCertificateRequest(pCertificateRequest)
+	if vIssuer != nil {
+		vCertificateRequest := translateIssuerRequestBackwards(pCertificateRequest, vIssuer)
+		ctx.Log.Infof("create virtual certificate request %s/%s, because physical was created by the csi driver", vCertificateRequest.Namespace, vCertificateRequest.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+			return ctx.VirtualClient.Create(ctx.Context, vCertificateRequest)
+		})
+	}
+
+	managed := translate.IsManaged(pObj)
+	if !managed {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+		_, err := syncer.DeleteObject(ctx, pObj)
+		return err
+	})
+}
@@ -0,0 +1,295 @@
+package certificaterequests
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+func (s *certificateRequestSyncer) translate(vObj *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequest {
+	pObj := s.TranslateMetadata(vObj).(*certmanagerv1.CertificateRequest)
+	pObj.Annotations = translateRequesterAnnotation(pObj.Annotations, vObj)
+	pObj.Annotations = issuers.TranslateIngressNameOverride(context.TODO(), s.virtualClient, vObj, pObj.Annotations, vObj.Spec.IssuerRef)
+	pObj.Spec = *s.rewriteSpec(&vObj.Spec, vObj.Namespace)
+	pObj.Status = certmanagerv1.CertificateRequestStatus{}
+	return pObj
+}
+
+func (s *certificateRequestSyncer) translateUpdate(pObj, vObj *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequest {
+	var updated *certmanagerv1.CertificateRequest
+
+	// check annotations & labels
+	_, updatedAnnotations, updatedLabels := s.TranslateMetadataUpdate(vObj, pObj)
+	updatedAnnotations = translateRequesterAnnotation(updatedAnnotations, vObj)
+	updatedAnnotations = issuers.TranslateIngressNameOverride(context.TODO(), s.virtualClient, vObj, updatedAnnotations, vObj.Spec.IssuerRef)
+	if !equality.Semantic.DeepEqual(updatedAnnotations, pObj.Annotations) || !equality.Semantic.DeepEqual(updatedLabels, pObj.Labels) {
+		updated = newIfNil(updated, pObj)
+		updated.Labels = updatedLabels
+		updated.Annotations = updatedAnnotations
+	}
+
+	return updated
+}
+
+// translateRequesterAnnotation sets the virtual namespace of the certificate request within the requester
+// annotation, as the host cluster cert-manager webhook sets the identity of the plugin as requester. Values
+// set within the vcluster are overwritten, so that host approvers can rely on the annotation.
+func translateRequesterAnnotation(annotations map[string]string, vObj *certmanagerv1.CertificateRequest) map[string]string {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	annotations[constants.RequesterAnnotation] = vObj.Namespace
+	return annotations
+}
+
+func (s *certificateRequestSyncer) rewriteSpec(vObjSpec *certmanagerv1.CertificateRequestSpec, namespace string) *certmanagerv1.CertificateRequestSpec {
+	vObjSpec = vObjSpec.DeepCopy()
+	vObjSpec.IssuerRef = issuers.TranslateIssuerRef(context.TODO(), s.virtualClient, vObjSpec.IssuerRef, namespace, s.targetNamespace, s.externalIssuers)
+
+	// the requester identity is set by the host cluster cert-manager webhook to the identity of the plugin,
+	// identities set within the vcluster cannot be trusted, as there is no webhook within the vcluster
+	vObjSpec.Username = ""
+	vObjSpec.UID = ""
+	vObjSpec.Groups = nil
+	vObjSpec.Extra = nil
+	return vObjSpec
+}
+
+// translateStatus replaces the physical certificate request, certificate and issuer names within the
+// status of the physical certificate request with their virtual names
+func translateStatus(pObj, vObj *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequestStatus {
+	t := statusTranslator(pObj, vObj)
+	vObjStatus := pObj.Status.DeepCopy()
+	for i := range vObjStatus.Conditions {
+		vObjStatus.Conditions[i].Message = t.Translate(vObjStatus.Conditions[i].Message)
+	}
+
+	return vObjStatus
+}
+
+// statusTranslator returns a translator for the physical certificate request, certificate and issuer names
+func statusTranslator(pObj, vObj *certmanagerv1.CertificateRequest) *status.Translator {
+	t := status.NewTranslator()
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Name}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Name})
+	if pObj.Annotations != nil && vObj.Annotations != nil {
+		t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Annotations[certmanagerv1.CertificateNameKey]}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Annotations[certmanagerv1.CertificateNameKey]})
+	}
+	if pObj.Spec.IssuerRef.Name != vObj.Spec.IssuerRef.Name {
+		if vObj.Spec.IssuerRef.Kind == "ClusterIssuer" {
+			t.AddName(pObj.Namespace+"/"+pObj.Spec.IssuerRef.Name, vObj.Spec.IssuerRef.Name)
+			t.AddName(pObj.Spec.IssuerRef.Name, vObj.Spec.IssuerRef.Name)
+		} else {
+			t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Spec.IssuerRef.Name}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Spec.IssuerRef.Name})
+		}
+	}
+
+	return t
+}
+
+// translateApproval returns an updated physical certificate request if the virtual certificate
+// request was approved or denied, but the physical one was neither approved nor denied yet. The
+// cert-manager webhook refuses a second approval condition, so the first one is final.
+func translateApproval(pObj, vObj *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequest {
+	if getCondition(pObj, certmanagerv1.CertificateRequestConditionApproved) != nil || getCondition(pObj, certmanagerv1.CertificateRequestConditionDenied) != nil {
+		return nil
+	}
+
+	for _, conditionType := range []certmanagerv1.CertificateRequestConditionType{certmanagerv1.CertificateRequestConditionApproved, certmanagerv1.CertificateRequestConditionDenied} {
+		vCondition := getCondition(vObj, conditionType)
+		if vCondition == nil || vCondition.Status != cmmeta.ConditionTrue {
+			continue
+		}
+
+		updated := pObj.DeepCopy()
+		updated.Status.Conditions = append(updated.Status.Conditions, *vCondition)
+		return updated
+	}
+
+	return nil
+}
+
+// isApprovable returns true if the physical certificate request references a namespaced issuer that was
+// synced from this vcluster. Requests for host cluster issuers are left to the approvers of the host cluster.
+func isApprovable(ctx context.Context, physicalClient client.Client, pObj *certmanagerv1.CertificateRequest) (bool, error) {
+	issuerRef := pObj.Spec.IssuerRef
+	if (issuerRef.Kind != "" && issuerRef.Kind != "Issuer") || (issuerRef.Group != "" && issuerRef.Group != certmanagerv1.SchemeGroupVersion.Group) {
+		return false, nil
+	}
+
+	pIssuer := &certmanagerv1.Issuer{}
+	err := physicalClient.Get(ctx, types.NamespacedName{Namespace: pObj.Namespace, Name: issuerRef.Name}, pIssuer)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return translate.IsManaged(pIssuer), nil
+}
+
+func (s *certificateRequestSyncer) translateBackwards(pObj *certmanagerv1.CertificateRequest, vCertificate *certmanagerv1.Certificate) *certmanagerv1.CertificateRequest {
+	vObj := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            vCertificate.Name + "-" + pObj.Annotations[certmanagerv1.CertificateRequestRevisionAnnotationKey],
+			Namespace:       vCertificate.Namespace,
+			Annotations:     translateAnnotationsBackwards(pObj.Annotations),
+			OwnerReferences: ownerReferences(vCertificate),
+		},
+		// the certificate request was created for the virtual certificate, so it uses the same issuer
+		Spec: *rewriteSpecBackwards(&pObj.Spec, vCertificate.Spec.IssuerRef),
+	}
+	vObj.Annotations[certmanagerv1.CertificateNameKey] = vCertificate.Name
+
+	return vObj
+}
+
+// translateIssuerRequestBackwards creates the virtual certificate request of the physical certificate
+// request the csi driver created for a pod of the vcluster. Its name is kept, as it is a unique id.
+func translateIssuerRequestBackwards(pObj *certmanagerv1.CertificateRequest, vIssuer *certmanagerv1.Issuer) *certmanagerv1.CertificateRequest {
+	return &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pObj.Name,
+			Namespace:   vIssuer.Namespace,
+			Labels:      translateLabelsBackwards(pObj.Labels),
+			Annotations: translateAnnotationsBackwards(pObj.Annotations),
+		},
+		Spec: *rewriteSpecBackwards(&pObj.Spec, cmmeta.ObjectReference{
+			Name:  vIssuer.Name,
+			Kind:  "Issuer",
+			Group: certmanagerv1.SchemeGroupVersion.Group,
+		}),
+	}
+}
+
+func (s *certificateRequestSyncer) translateUpdateBackwards(pObj, vObj *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequest {
+	vCertificate := s.certificateByCertificateRequest(pObj)
+	if vCertificate != nil {
+		return updateBackwards(vObj, s.translateBackwards(pObj, vCertificate))
+	}
+
+	vIssuer := s.issuerByCertificateRequest(pObj)
+	if vIssuer != nil {
+		return updateBackwards(vObj, translateIssuerRequestBackwards(pObj, vIssuer))
+	}
+
+	return nil
+}
+
+// updateBackwards returns an updated virtual certificate request if its labels, annotations, owner
+// references or spec differ from the translated physical certificate request
+func updateBackwards(vObj, translated *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequest {
+	var updated *certmanagerv1.CertificateRequest
+
+	// check labels
+	if translated.Labels != nil && !equality.Semantic.DeepEqual(translated.Labels, vObj.Labels) {
+		updated = newIfNil(updated, vObj)
+		updated.Labels = translated.Labels
+	}
+
+	// check annotations
+	if !equality.Semantic.DeepEqual(translated.Annotations, vObj.Annotations) {
+		updated = newIfNil(updated, vObj)
+		updated.Annotations = translated.Annotations
+	}
+
+	// check owner references
+	if !equality.Semantic.DeepEqual(translated.OwnerReferences, vObj.OwnerReferences) {
+		updated = newIfNil(updated, vObj)
+		updated.OwnerReferences = translated.OwnerReferences
+	}
+
+	// check spec
+	if !equality.Semantic.DeepEqual(translated.Spec, vObj.Spec) {
+		updated = newIfNil(updated, vObj)
+		updated.Spec = translated.Spec
+	}
+
+	return updated
+}
+
+func rewriteSpecBackwards(pObjSpec *certmanagerv1.CertificateRequestSpec, vIssuerRef cmmeta.ObjectReference) *certmanagerv1.CertificateRequestSpec {
+	vObjSpec := pObjSpec.DeepCopy()
+	vObjSpec.IssuerRef = vIssuerRef
+
+	// don't expose the host cluster cert-manager identity
+	vObjSpec.Username = ""
+	vObjSpec.UID = ""
+	vObjSpec.Groups = nil
+	vObjSpec.Extra = nil
+	return vObjSpec
+}
+
+// translateLabelsBackwards returns the labels of the physical certificate request without the labels
+// of vcluster and the plugin, such as vcluster.loft.sh/managed-by, which only have a meaning within
+// the host cluster and would make the virtual certificate request look synced
+func translateLabelsBackwards(pLabels map[string]string) map[string]string {
+	if len(pLabels) == 0 {
+		return nil
+	}
+
+	newLabels := map[string]string{}
+	for k, v := range pLabels {
+		prefix := strings.SplitN(k, "/", 2)[0]
+		if prefix == "vcluster.loft.sh" || strings.HasSuffix(prefix, ".vcluster.loft.sh") {
+			continue
+		}
+
+		newLabels[k] = v
+	}
+	return newLabels
+}
+
+func translateAnnotationsBackwards(pAnnotations map[string]string) map[string]string {
+	newAnnotations := map[string]string{}
+	for k, v := range pAnnotations {
+		newAnnotations[k] = v
+	}
+
+	// the private key secret only exists in the host cluster
+	delete(newAnnotations, certmanagerv1.CertificateRequestPrivateKeyAnnotationKey)
+	newAnnotations[constants.BackwardSyncAnnotation] = "true"
+	return newAnnotations
+}
+
+func ownerReferences(vCertificate *certmanagerv1.Certificate) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			APIVersion: certmanagerv1.SchemeGroupVersion.String(),
+			Kind:       "Certificate",
+			Name:       vCertificate.Name,
+			UID:        vCertificate.UID,
+			Controller: pointer.Bool(true),
+		},
+	}
+}
+
+func getCondition(obj *certmanagerv1.CertificateRequest, conditionType certmanagerv1.CertificateRequestConditionType) *certmanagerv1.CertificateRequestCondition {
+	for i := range obj.Status.Conditions {
+		if obj.Status.Conditions[i].Type == conditionType {
+			return &obj.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+func newIfNil(updated *certmanagerv1.CertificateRequest, obj *certmanagerv1.CertificateRequest) *certmanagerv1.CertificateRequest {
+	if updated == nil {
+		return obj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,81 @@
+package certificaterequests
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestTranslateLabelsBackwards(t *testing.T) {
+	testCases := []struct {
+		name     string
+		pLabels  map[string]string
+		expected map[string]string
+	}{
+		{
+			name: "no labels",
+		},
+		{
+			name: "vcluster and plugin labels",
+			pLabels: map[string]string{
+				translate.MarkerLabel:     translate.Suffix,
+				translate.ControllerLabel: "vcluster",
+				constants.SyncedLabel:     "true",
+				"app":                     "test",
+				"example.com/team":        "a",
+			},
+			expected: map[string]string{
+				"app":              "test",
+				"example.com/team": "a",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		labels := translateLabelsBackwards(testCase.pLabels)
+		if !reflect.DeepEqual(testCase.expected, labels) {
+			t.Errorf("Test case %s: expected %v, got %v", testCase.name, testCase.expected, labels)
+		}
+	}
+}
+
+func TestTranslateStatus(t *testing.T) {
+	vCertificateRequest := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "example-1", Annotations: map[string]string{certmanagerv1.CertificateNameKey: "example"}},
+		Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "ca"}},
+	}
+	pCertificateRequest := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "vcluster", Name: "example-x-default-x-suffix-5fj2k", Annotations: map[string]string{certmanagerv1.CertificateNameKey: "example-x-default-x-suffix"}},
+		Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "ca-x-default-x-suffix"}},
+		Status: certmanagerv1.CertificateRequestStatus{Conditions: []certmanagerv1.CertificateRequestCondition{
+			{Type: certmanagerv1.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Message: `Referenced "Issuer" not found: issuer.cert-manager.io "ca-x-default-x-suffix" not found`},
+			{Type: certmanagerv1.CertificateRequestConditionDenied, Status: cmmeta.ConditionTrue, Message: "Request vcluster/example-x-default-x-suffix-5fj2k of certificate example-x-default-x-suffix was denied"},
+		}},
+	}
+
+	vStatus := translateStatus(pCertificateRequest, vCertificateRequest)
+	expected := []string{
+		`Referenced "Issuer" not found: issuer.cert-manager.io "ca" not found`,
+		"Request default/example-1 of certificate example was denied",
+	}
+	for i, condition := range vStatus.Conditions {
+		if condition.Message != expected[i] {
+			t.Errorf("Expected message %q, got %q", expected[i], condition.Message)
+		}
+	}
+
+	// cluster issuers of the vcluster are namespaced issuers within the host namespace
+	vCertificateRequest.Spec.IssuerRef = cmmeta.ObjectReference{Name: "ca", Kind: "ClusterIssuer"}
+	pCertificateRequest.Spec.IssuerRef = cmmeta.ObjectReference{Name: "ca-x-vcluster-x-suffix", Kind: "Issuer"}
+	pCertificateRequest.Status.Conditions = []certmanagerv1.CertificateRequestCondition{
+		{Type: certmanagerv1.CertificateRequestConditionReady, Status: cmmeta.ConditionFalse, Message: "issuer vcluster/ca-x-vcluster-x-suffix is not ready"},
+	}
+	vStatus = translateStatus(pCertificateRequest, vCertificateRequest)
+	if vStatus.Conditions[0].Message != "issuer ca is not ready" {
+		t.Errorf("Expected message %q, got %q", "issuer ca is not ready", vStatus.Conditions[0].Message)
+	}
+}
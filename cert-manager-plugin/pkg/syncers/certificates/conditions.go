@@ -0,0 +1,94 @@
+package certificates
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/conditions"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"time"
+)
+
+const (
+	// CertificateConditionQuotaExceeded is set on virtual certificates that are not synced
+	// to the host cluster, because they exceed the configured quotas
+	CertificateConditionQuotaExceeded certmanagerv1.CertificateConditionType = "QuotaExceeded"
+
+	// CertificateConditionDomainPolicyViolated is set on virtual certificates that are not synced
+	// to the host cluster, because they violate the domain policy
+	CertificateConditionDomainPolicyViolated certmanagerv1.CertificateConditionType = "DomainPolicyViolated"
+
+	// CertificateConditionIssuerNotSupported is set on virtual certificates that are not synced
+	// to the host cluster, because they reference an issuer kind that is not synced from the vcluster
+	CertificateConditionIssuerNotSupported certmanagerv1.CertificateConditionType = "IssuerNotSupported"
+
+	// refusedRequeueInterval is the interval certificates that are not synced are checked again
+	refusedRequeueInterval = time.Minute
+)
+
+// refuseSync sets the given condition on the virtual certificate, which is not synced to the host cluster,
+// and records a warning event
+func (s *certificateSyncer) refuseSync(ctx *context.SyncContext, vCertificate *certmanagerv1.Certificate, vStatus *certmanagerv1.CertificateStatus, conditionType certmanagerv1.CertificateConditionType, reason, message string) (ctrl.Result, error) {
+	newStatus := vStatus.DeepCopy()
+	newStatus.Conditions = conditions.Set(newStatus.Conditions, certmanagerv1.CertificateCondition{
+		Type:               conditionType,
+		Status:             cmmeta.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: vCertificate.Generation,
+	})
+	if !equality.Semantic.DeepEqual(vCertificate.Status, *newStatus) {
+		newCertificate := vCertificate.DeepCopy()
+		newCertificate.Status = *newStatus
+		ctx.Log.Infof("update virtual certificate %s/%s, because it is not synced: %s", vCertificate.Namespace, vCertificate.Name, message)
+		s.EventRecorder().Eventf(vCertificate, "Warning", string(conditionType), message)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newCertificate)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: refusedRequeueInterval}, nil
+}
+
+// rejectSync sets the Ready condition of the virtual certificate, which the host cluster refused, to false
+// and records a warning event. The certificate is synced again after an exponential backoff.
+func (s *certificateSyncer) rejectSync(ctx *context.SyncContext, vCertificate *certmanagerv1.Certificate, vStatus *certmanagerv1.CertificateStatus, pCertificate *certmanagerv1.Certificate, err error) (ctrl.Result, error) {
+	message := statusTranslator(pCertificate, vCertificate).Translate(err.Error())
+	backoff := s.rejections.Reject(vCertificate, message)
+	newStatus := rejectedStatus(vCertificate, vStatus, message)
+	if !equality.Semantic.DeepEqual(vCertificate.Status, *newStatus) {
+		newCertificate := vCertificate.DeepCopy()
+		newCertificate.Status = *newStatus
+		ctx.Log.Infof("update virtual certificate %s/%s, because host cluster refused it: %s", vCertificate.Namespace, vCertificate.Name, message)
+		s.EventRecorder().Eventf(vCertificate, "Warning", admission.HostValidationFailedReason, message)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newCertificate)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+// rejectedStatus returns the translated status vStatus of a certificate the host cluster refused. The Ready
+// condition is based on the current status of the virtual certificate, so that it does not flap between
+// the condition of the physical certificate and the rejection.
+func rejectedStatus(vCertificate *certmanagerv1.Certificate, vStatus *certmanagerv1.CertificateStatus, message string) *certmanagerv1.CertificateStatus {
+	vStatus = vStatus.DeepCopy()
+	vStatus.Conditions = conditions.SetFrom(vStatus.Conditions, vCertificate.Status.Conditions, certmanagerv1.CertificateCondition{
+		Type:               certmanagerv1.CertificateConditionReady,
+		Status:             cmmeta.ConditionFalse,
+		Reason:             admission.HostValidationFailedReason,
+		Message:            message,
+		ObservedGeneration: vCertificate.Generation,
+	})
+	return vStatus
+}
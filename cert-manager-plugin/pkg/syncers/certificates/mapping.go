@@ -4,6 +4,7 @@ import (
 	context2 "context"
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/hooks/gateways"
 	"github.com/loft-sh/vcluster-sdk/clienthelper"
 	"github.com/loft-sh/vcluster-sdk/syncer"
 	"github.com/loft-sh/vcluster-sdk/syncer/context"
@@ -15,11 +16,13 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 	"strings"
 )
 
 var (
 	IndexByIngressCertificate = "indexbyingresscertificate"
+	IndexByGatewayCertificate = "indexbygatewaycertificate"
 )
 
 var _ syncer.IndicesRegisterer = &certificateSyncer{}
@@ -31,6 +34,14 @@ func (s *certificateSyncer) RegisterIndices(ctx *context.RegisterContext) error
 	if err != nil {
 		return err
 	}
+	if s.gatewaysEnabled {
+		err = ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, &gatewayv1alpha2.Gateway{}, IndexByGatewayCertificate, func(rawObj client.Object) []string {
+			return certificateNamesFromGateway(rawObj.(*gatewayv1alpha2.Gateway))
+		})
+		if err != nil {
+			return err
+		}
+	}
 
 	return s.NamespacedTranslator.RegisterIndices(ctx)
 }
@@ -39,6 +50,9 @@ var _ syncer.ControllerModifier = &certificateSyncer{}
 
 func (s *certificateSyncer) ModifyController(ctx *context.RegisterContext, builder *builder.Builder) (*builder.Builder, error) {
 	builder = builder.Watches(&source.Kind{Type: &networkingv1.Ingress{}}, handler.EnqueueRequestsFromMapFunc(mapIngresses))
+	if s.gatewaysEnabled {
+		builder = builder.Watches(&source.Kind{Type: &gatewayv1alpha2.Gateway{}}, handler.EnqueueRequestsFromMapFunc(mapGateways))
+	}
 	return builder, nil
 }
 
@@ -58,13 +72,17 @@ func (s *certificateSyncer) shouldSyncBackwards(pCertificate, vCertificate *cert
 		return true, name
 	}
 
+	name = s.nameByGateway(pCertificate)
+	if name.Name != "" {
+		return true, name
+	}
+
 	return false, types.NamespacedName{}
 }
 
 func (s *certificateSyncer) nameByIngress(pObj client.Object) types.NamespacedName {
-	vIngress := &networkingv1.Ingress{}
-	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vIngress, IndexByIngressCertificate, pObj.GetName())
-	if err == nil && vIngress.Name != "" {
+	vIngress := s.ingressByCertificate(pObj)
+	if vIngress != nil {
 		for _, secret := range vIngress.Spec.TLS {
 			if translate.PhysicalName(secret.SecretName, vIngress.Namespace) == pObj.GetName() {
 				return types.NamespacedName{
@@ -78,6 +96,39 @@ func (s *certificateSyncer) nameByIngress(pObj client.Object) types.NamespacedNa
 	return types.NamespacedName{}
 }
 
+func (s *certificateSyncer) nameByGateway(pObj client.Object) types.NamespacedName {
+	if !s.gatewaysEnabled {
+		return types.NamespacedName{}
+	}
+
+	vGateway := &gatewayv1alpha2.Gateway{}
+	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vGateway, IndexByGatewayCertificate, pObj.GetName())
+	if err == nil && vGateway.Name != "" {
+		for _, secretName := range gatewaySecretNames(vGateway) {
+			if translate.PhysicalName(secretName, vGateway.Namespace) == pObj.GetName() {
+				return types.NamespacedName{
+					Name:      secretName,
+					Namespace: vGateway.Namespace,
+				}
+			}
+		}
+	}
+
+	return types.NamespacedName{}
+}
+
+// ingressByCertificate returns the virtual ingress the physical certificate was created for by
+// the host cluster ingress-shim
+func (s *certificateSyncer) ingressByCertificate(pObj client.Object) *networkingv1.Ingress {
+	vIngress := &networkingv1.Ingress{}
+	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vIngress, IndexByIngressCertificate, pObj.GetName())
+	if err != nil || vIngress.Name == "" {
+		return nil
+	}
+
+	return vIngress
+}
+
 func (s *certificateSyncer) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
 	namespacedName := s.NamespacedTranslator.PhysicalToVirtual(pObj)
 	if namespacedName.Name != "" {
@@ -89,6 +140,11 @@ func (s *certificateSyncer) PhysicalToVirtual(pObj client.Object) types.Namespac
 		return namespacedName
 	}
 
+	namespacedName = s.nameByGateway(pObj)
+	if namespacedName.Name != "" {
+		return namespacedName
+	}
+
 	return types.NamespacedName{}
 }
 
@@ -96,7 +152,7 @@ func certificateNamesFromIngress(ingress *networkingv1.Ingress) []string {
 	certificates := []string{}
 
 	// Do not include certificate.Spec.SecretName here as this will be handled separately by a different controller
-	if ingress.Annotations != nil && (ingress.Annotations[constants.IssuerAnnotation] != "" || ingress.Annotations[constants.ClusterIssuerAnnotation] != "") {
+	if hasIngressShimAnnotations(ingress) {
 		for _, secret := range ingress.Spec.TLS {
 			if secret.SecretName == "" {
 				continue
@@ -109,14 +165,66 @@ func certificateNamesFromIngress(ingress *networkingv1.Ingress) []string {
 	return certificates
 }
 
+func certificateNamesFromGateway(gateway *gatewayv1alpha2.Gateway) []string {
+	certificates := []string{}
+	if gateway.Annotations == nil || (gateway.Annotations[constants.IssuerAnnotation] == "" && gateway.Annotations[constants.ClusterIssuerAnnotation] == "") {
+		return certificates
+	}
+
+	for _, secretName := range gatewaySecretNames(gateway) {
+		certificates = append(certificates, translate.PhysicalName(secretName, gateway.Namespace))
+		certificates = append(certificates, gateway.Namespace+"/"+secretName)
+	}
+	return certificates
+}
+
+// gatewaySecretNames returns the secrets of the gateway listeners the gateway-shim creates certificates for
+func gatewaySecretNames(gateway *gatewayv1alpha2.Gateway) []string {
+	secretNames := []string{}
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+
+		for _, certificateRef := range listener.TLS.CertificateRefs {
+			if gateways.IsSecretRef(certificateRef, gateway.Namespace) {
+				secretNames = append(secretNames, string(certificateRef.Name))
+			}
+		}
+	}
+	return secretNames
+}
+
+// hasIngressShimAnnotations returns true if the ingress-shim creates certificates for the ingress,
+// which either references an issuer or requests one via kubernetes.io/tls-acme
+func hasIngressShimAnnotations(ingress *networkingv1.Ingress) bool {
+	if ingress.Annotations == nil {
+		return false
+	}
+
+	return ingress.Annotations[constants.IssuerAnnotation] != "" || ingress.Annotations[constants.ClusterIssuerAnnotation] != "" || ingress.Annotations[constants.TLSACMEAnnotation] == "true"
+}
+
 func mapIngresses(obj client.Object) []reconcile.Request {
 	ingress, ok := obj.(*networkingv1.Ingress)
 	if !ok {
 		return nil
 	}
 
+	return mapCertificateNames(certificateNamesFromIngress(ingress))
+}
+
+func mapGateways(obj client.Object) []reconcile.Request {
+	gateway, ok := obj.(*gatewayv1alpha2.Gateway)
+	if !ok {
+		return nil
+	}
+
+	return mapCertificateNames(certificateNamesFromGateway(gateway))
+}
+
+func mapCertificateNames(names []string) []reconcile.Request {
 	requests := []reconcile.Request{}
-	names := certificateNamesFromIngress(ingress)
 	for _, name := range names {
 		splitted := strings.Split(name, "/")
 		if len(splitted) == 2 {
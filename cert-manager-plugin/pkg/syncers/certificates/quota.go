@@ -0,0 +1,90 @@
+package certificates
+
+import (
+	"fmt"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+// checkQuotas returns a reason and message if the virtual certificate exceeds the configured quotas. The
+// physical certificate is nil for certificates that are not yet synced. The number of certificates is only
+// checked for these, while issuances are checked as well if the spec of a synced certificate changes, as
+// cert-manager issues the certificate again.
+func (s *certificateSyncer) checkQuotas(ctx *context.SyncContext, vCertificate, pCertificate *certmanagerv1.Certificate) (string, string, error) {
+	if s.quotas.MaxDNSNames > 0 && len(vCertificate.Spec.DNSNames) > s.quotas.MaxDNSNames {
+		return "MaxDNSNames", fmt.Sprintf("Certificate has %d dns names, but only %d are allowed", len(vCertificate.Spec.DNSNames), s.quotas.MaxDNSNames), nil
+	}
+	if len(s.quotas.AllowedDomainSuffixes) > 0 {
+		for _, domain := range certificateDomains(vCertificate) {
+			if !hasAllowedSuffix(domain, s.quotas.AllowedDomainSuffixes) {
+				return "AllowedDomainSuffixes", fmt.Sprintf("Domain %s is not allowed, allowed are only subdomains of %s", domain, strings.Join(s.quotas.AllowedDomainSuffixes, ", ")), nil
+			}
+		}
+	}
+
+	if pCertificate == nil && s.quotas.MaxCertificates > 0 {
+		pCertificates := &certmanagerv1.CertificateList{}
+		err := ctx.PhysicalClient.List(ctx.Context, pCertificates, client.InNamespace(ctx.TargetNamespace), client.MatchingLabels{translate.MarkerLabel: translate.Suffix})
+		if err != nil {
+			return "", "", err
+		} else if len(pCertificates.Items) >= s.quotas.MaxCertificates {
+			return "MaxCertificates", fmt.Sprintf("Only %d certificates are allowed within this vcluster", s.quotas.MaxCertificates), nil
+		}
+	}
+	if s.quotas.MaxIssuancesPerHour > 0 && (pCertificate == nil || !equality.Semantic.DeepEqual(*s.rewriteSpec(&vCertificate.Spec, vCertificate.Namespace), pCertificate.Spec)) {
+		issuances, err := s.issuancesWithinLastHour(ctx)
+		if err != nil {
+			return "", "", err
+		} else if issuances >= s.quotas.MaxIssuancesPerHour {
+			return "MaxIssuancesPerHour", fmt.Sprintf("Only %d certificates can be issued per hour within this vcluster", s.quotas.MaxIssuancesPerHour), nil
+		}
+	}
+
+	return "", "", nil
+}
+
+// issuancesWithinLastHour returns the number of certificate requests created within the host namespace
+// during the last hour. Every issuance and renewal creates a certificate request, so the quota also
+// holds across restarts of the plugin.
+func (s *certificateSyncer) issuancesWithinLastHour(ctx *context.SyncContext) (int, error) {
+	pCertificateRequests := &certmanagerv1.CertificateRequestList{}
+	err := ctx.PhysicalClient.List(ctx.Context, pCertificateRequests, client.InNamespace(ctx.TargetNamespace))
+	if err != nil {
+		return 0, err
+	}
+
+	issuances := 0
+	for _, pCertificateRequest := range pCertificateRequests.Items {
+		if time.Since(pCertificateRequest.CreationTimestamp.Time) < time.Hour {
+			issuances++
+		}
+	}
+
+	return issuances, nil
+}
+
+func certificateDomains(vCertificate *certmanagerv1.Certificate) []string {
+	domains := []string{}
+	if vCertificate.Spec.CommonName != "" {
+		domains = append(domains, vCertificate.Spec.CommonName)
+	}
+
+	return append(domains, vCertificate.Spec.DNSNames...)
+}
+
+func hasAllowedSuffix(domain string, allowedSuffixes []string) bool {
+	domain = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(domain), "*."), ".")
+	for _, suffix := range allowedSuffixes {
+		suffix = strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(suffix), "."), ".")
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,171 @@
+package certificates
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+	"time"
+)
+
+const targetNamespace = "vcluster"
+
+func newTestSyncContext(t *testing.T, pObjs []client.Object) *context.SyncContext {
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+
+	return &context.SyncContext{
+		Context:         context2.TODO(),
+		PhysicalClient:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(pObjs...).Build(),
+		TargetNamespace: targetNamespace,
+		Log:             log.New(t.Name()),
+	}
+}
+
+// physicalCertificate returns a host certificate synced by the vcluster with the given suffix
+func physicalCertificate(name, suffix string) *certmanagerv1.Certificate {
+	return &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{
+		Namespace: targetNamespace,
+		Name:      name,
+		Labels:    map[string]string{translate.MarkerLabel: suffix},
+	}}
+}
+
+// physicalCertificateRequest returns a host certificate request created the given time ago
+func physicalCertificateRequest(name string, age time.Duration) *certmanagerv1.CertificateRequest {
+	return &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         targetNamespace,
+		Name:              name,
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+	}}
+}
+
+func TestCheckQuotas(t *testing.T) {
+	testCases := []struct {
+		name     string
+		quotas   config.Quotas
+		dnsNames []string
+		pObjs    []client.Object
+
+		// synced certificates are checked against a physical certificate, whose spec differs if the
+		// spec of the virtual certificate changed
+		synced      bool
+		specChanged bool
+
+		expectedReason string
+	}{
+		{
+			name:     "No quotas",
+			dnsNames: []string{"a.example.com", "b.example.com"},
+			pObjs:    []client.Object{physicalCertificate("a", translate.Suffix), physicalCertificateRequest("a-1", time.Minute)},
+		},
+		{
+			name:           "Too many dns names",
+			synced:         true,
+			quotas:         config.Quotas{MaxDNSNames: 1},
+			dnsNames:       []string{"a.example.com", "b.example.com"},
+			expectedReason: "MaxDNSNames",
+		},
+		{
+			name:     "Dns names within the limit",
+			quotas:   config.Quotas{MaxDNSNames: 2},
+			dnsNames: []string{"a.example.com", "b.example.com"},
+		},
+		{
+			name:           "Too many certificates",
+			quotas:         config.Quotas{MaxCertificates: 2},
+			pObjs:          []client.Object{physicalCertificate("a", translate.Suffix), physicalCertificate("b", translate.Suffix)},
+			expectedReason: "MaxCertificates",
+		},
+		{
+			name:   "Certificates of another vcluster",
+			quotas: config.Quotas{MaxCertificates: 2},
+			pObjs:  []client.Object{physicalCertificate("a", translate.Suffix), physicalCertificate("b", "other-vcluster")},
+		},
+		{
+			name:   "Too many certificates for an already synced certificate",
+			synced: true,
+			quotas: config.Quotas{MaxCertificates: 1},
+			pObjs:  []client.Object{physicalCertificate("a", translate.Suffix)},
+		},
+		{
+			name:           "Too many issuances",
+			quotas:         config.Quotas{MaxIssuancesPerHour: 2},
+			pObjs:          []client.Object{physicalCertificateRequest("a-1", time.Minute), physicalCertificateRequest("a-2", 59*time.Minute)},
+			expectedReason: "MaxIssuancesPerHour",
+		},
+		{
+			name:           "Too many issuances for a changed spec",
+			synced:         true,
+			specChanged:    true,
+			quotas:         config.Quotas{MaxIssuancesPerHour: 1},
+			pObjs:          []client.Object{physicalCertificateRequest("a-1", time.Minute)},
+			expectedReason: "MaxIssuancesPerHour",
+		},
+		{
+			name:   "Too many issuances for an unchanged spec",
+			synced: true,
+			quotas: config.Quotas{MaxIssuancesPerHour: 1},
+			pObjs:  []client.Object{physicalCertificateRequest("a-1", time.Minute)},
+		},
+		{
+			name:   "Issuances older than an hour",
+			quotas: config.Quotas{MaxIssuancesPerHour: 2},
+			pObjs:  []client.Object{physicalCertificateRequest("a-1", time.Minute), physicalCertificateRequest("a-2", 2*time.Hour)},
+		},
+		{
+			name:           "Domain not allowed",
+			synced:         true,
+			quotas:         config.Quotas{AllowedDomainSuffixes: []string{"tenant-a.example.com"}},
+			dnsNames:       []string{"web.tenant-a.example.com", "tenant-b.example.com"},
+			expectedReason: "AllowedDomainSuffixes",
+		},
+		{
+			name:     "Allowed domains",
+			quotas:   config.Quotas{AllowedDomainSuffixes: []string{".Tenant-A.example.com."}},
+			dnsNames: []string{"tenant-a.example.com", "*.web.tenant-a.example.com"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		s := &certificateSyncer{quotas: testCase.quotas}
+		vCertificate := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tls"},
+			Spec:       certmanagerv1.CertificateSpec{DNSNames: testCase.dnsNames},
+		}
+
+		var pCertificate *certmanagerv1.Certificate
+		if testCase.synced {
+			pCertificate = &certmanagerv1.Certificate{Spec: *s.rewriteSpec(&vCertificate.Spec, vCertificate.Namespace)}
+			if testCase.specChanged {
+				pCertificate.Spec.DNSNames = []string{"old.example.com"}
+			}
+		}
+
+		reason, message, err := s.checkQuotas(newTestSyncContext(t, testCase.pObjs), vCertificate, pCertificate)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if reason != testCase.expectedReason {
+			t.Errorf("Test case %s: expected reason %q, got %q (%s)", testCase.name, testCase.expectedReason, reason, message)
+		} else if (reason == "") != (message == "") {
+			t.Errorf("Test case %s: expected a message for reason %q, got %q", testCase.name, reason, message)
+		}
+	}
+}
+
+func TestHasAllowedSuffix(t *testing.T) {
+	allowed := []string{"tenant-a.example.com"}
+	if !hasAllowedSuffix("*.tenant-a.example.com", allowed) {
+		t.Errorf("Expected wildcard subdomain to be allowed")
+	}
+	if hasAllowedSuffix("evil-tenant-a.example.com", allowed) {
+		t.Errorf("Expected domain sharing only a string suffix not to be allowed")
+	}
+}
@@ -1,34 +1,77 @@
 package certificates
 
 import (
+	"fmt"
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/clusterissuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
 	"github.com/loft-sh/vcluster-sdk/syncer"
 	"github.com/loft-sh/vcluster-sdk/syncer/context"
 	"github.com/loft-sh/vcluster-sdk/syncer/translator"
 	"github.com/loft-sh/vcluster-sdk/translate"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	"time"
 )
 
-func New(ctx *context.RegisterContext) syncer.Syncer {
+func New(ctx *context.RegisterContext, cfg *config.Config) syncer.Syncer {
 	return &certificateSyncer{
 		NamespacedTranslator: translator.NewNamespacedTranslator(ctx, "certificate", &certmanagerv1.Certificate{}),
 
-		virtualClient: ctx.VirtualManager.GetClient(),
+		virtualClient:   ctx.VirtualManager.GetClient(),
+		targetNamespace: ctx.TargetNamespace,
+
+		allowedClusterIssuers: cfg.ClusterIssuers.Allowed,
+		ingressClasses:        cfg.HTTP01.IngressClasses,
+		externalIssuers:       cfg.ExternalIssuers,
+		quotas:                cfg.Quotas,
+		domainPolicy:          policy.NewDomainPolicy(ctx, cfg),
+		rejections:            admission.NewRejections(),
+		gatewaysSynced:        cfg.Gateways.Synced,
 	}
 }
 
 type certificateSyncer struct {
 	translator.NamespacedTranslator
 
-	virtualClient client.Client
+	virtualClient   client.Client
+	targetNamespace string
+
+	allowedClusterIssuers []string
+	ingressClasses        map[string]string
+	externalIssuers       config.ExternalIssuers
+	quotas                config.Quotas
+	domainPolicy          *policy.DomainPolicy
+	rejections            *admission.Rejections
+
+	// gatewaysSynced is true if gateways are synced to the host cluster by another plugin
+	gatewaysSynced bool
+
+	// gatewaysEnabled is true if gateways are synced and the gateway api is installed within the vcluster
+	gatewaysEnabled bool
 }
 
 var _ syncer.Initializer = &certificateSyncer{}
 
 func (s *certificateSyncer) Init(ctx *context.RegisterContext) error {
-	return translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), certmanagerv1.SchemeGroupVersion.WithKind("Certificate"))
+	err := translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), certmanagerv1.SchemeGroupVersion.WithKind("Certificate"))
+	if err != nil {
+		return err
+	}
+
+	if !s.gatewaysSynced {
+		return nil
+	}
+
+	s.gatewaysEnabled, err = translate.KindExists(ctx.VirtualManager.GetConfig(), gatewayv1alpha2.SchemeGroupVersion.WithKind("Gateway"))
+	return err
 }
 
 func (s *certificateSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
@@ -39,21 +82,99 @@ func (s *certificateSyncer) SyncDown(ctx *context.SyncContext, vObj client.Objec
 	if shouldSync {
 		// delete here as certificate is no longer needed
 		ctx.Log.Infof("delete virtual certificate %s/%s, because physical got deleted", vObj.GetNamespace(), vObj.GetName())
-		return ctrl.Result{}, ctx.VirtualClient.Delete(ctx.Context, vObj)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+			return ctx.VirtualClient.Delete(ctx.Context, vObj)
+		})
+	}
+
+	// is the referenced issuer kind synced from the vcluster?
+	message, supported := s.checkIssuerRef(vCertificate)
+	if !supported {
+		return s.refuseSync(ctx, vCertificate, &vCertificate.Status, CertificateConditionIssuerNotSupported, "IssuerNotSupported", message)
+	}
+
+	// is the certificate allowed to use the referenced cluster issuer?
+	allowed, err := s.clusterIssuerAllowed(ctx, vCertificate)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if !allowed {
+		return ctrl.Result{}, nil
+	}
+
+	// is the certificate allowed by the domain policy?
+	message, err = s.checkDomainPolicy(ctx, vCertificate)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if message != "" {
+		return s.refuseSync(ctx, vCertificate, &vCertificate.Status, CertificateConditionDomainPolicyViolated, "DomainNotAllowed", message)
+	}
+
+	// does the certificate exceed the quotas?
+	reason, message, err := s.checkQuotas(ctx, vCertificate, nil)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if reason != "" {
+		return s.refuseSync(ctx, vCertificate, &vCertificate.Status, CertificateConditionQuotaExceeded, reason, message)
+	}
+
+	// was the certificate refused by the host cluster before?
+	rejection := s.rejections.Get(vCertificate)
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
 	}
 
-	return s.SyncDownCreate(ctx, vObj, s.translate(vCertificate))
+	pCertificate := s.translate(vCertificate)
+	err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationCreate, func() error {
+		_, err := s.SyncDownCreate(ctx, vObj, pCertificate)
+		return err
+	})
+	if admission.IsRejected(err) {
+		return s.rejectSync(ctx, vCertificate, &vCertificate.Status, pCertificate, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vCertificate)
+	}
+	return ctrl.Result{}, err
 }
 
 func (s *certificateSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
 	vCertificate := vObj.(*certmanagerv1.Certificate)
 	pCertificate := pObj.(*certmanagerv1.Certificate)
 
-	if !equality.Semantic.DeepEqual(vCertificate.Status, pCertificate.Status) {
+	vStatus, err := s.translateStatus(ctx.Context, ctx.PhysicalClient, pCertificate, vCertificate)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	rejection := s.rejections.Get(vCertificate)
+	if rejection != nil {
+		vStatus = rejectedStatus(vCertificate, vStatus, rejection.Message)
+	}
+
+	// is the changed certificate allowed by the domain policy?
+	message, err := s.checkDomainPolicy(ctx, vCertificate)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if message != "" {
+		return s.refuseSync(ctx, vCertificate, vStatus, CertificateConditionDomainPolicyViolated, "DomainNotAllowed", message)
+	}
+
+	// does the changed certificate exceed the quotas?
+	shouldSync, _ := s.shouldSyncBackwards(pCertificate, vCertificate)
+	if !shouldSync {
+		reason, message, err := s.checkQuotas(ctx, vCertificate, pCertificate)
+		if err != nil {
+			return ctrl.Result{}, err
+		} else if reason != "" {
+			return s.refuseSync(ctx, vCertificate, vStatus, CertificateConditionQuotaExceeded, reason, message)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(vCertificate.Status, *vStatus) {
 		newIssuer := vCertificate.DeepCopy()
-		newIssuer.Status = pCertificate.Status
+		newIssuer.Status = *vStatus
 		ctx.Log.Infof("update virtual certificate %s/%s, because status is out of sync", vCertificate.Namespace, vCertificate.Name)
-		err := ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+		})
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -63,7 +184,6 @@ func (s *certificateSyncer) Sync(ctx *context.SyncContext, pObj client.Object, v
 	}
 
 	// was certificate created by ingress?
-	shouldSync, _ := s.shouldSyncBackwards(pCertificate, vCertificate)
 	if shouldSync {
 		updated, err := s.translateUpdateBackwards(pCertificate, vCertificate)
 		if err != nil {
@@ -71,14 +191,90 @@ func (s *certificateSyncer) Sync(ctx *context.SyncContext, pObj client.Object, v
 		}
 		if updated != nil {
 			ctx.Log.Infof("update virtual certificate %s/%s, because spec is out of sync", vCertificate.Namespace, vCertificate.Name)
-			return ctrl.Result{}, s.virtualClient.Update(ctx.Context, updated)
+			return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+				return s.virtualClient.Update(ctx.Context, updated)
+			})
 		}
 
 		return ctrl.Result{}, nil
 	}
 
+	// is the referenced issuer kind synced from the vcluster?
+	message, supported := s.checkIssuerRef(vCertificate)
+	if !supported {
+		return s.refuseSync(ctx, vCertificate, vStatus, CertificateConditionIssuerNotSupported, "IssuerNotSupported", message)
+	}
+
+	// is the certificate allowed to use the referenced cluster issuer?
+	allowed, err := s.clusterIssuerAllowed(ctx, vCertificate)
+	if err != nil {
+		return ctrl.Result{}, err
+	} else if !allowed {
+		return ctrl.Result{}, nil
+	}
+
+	// was the certificate refused by the host cluster before?
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
 	// did the certificate change?
-	return s.SyncDownUpdate(ctx, vObj, s.translateUpdate(pCertificate, vCertificate))
+	updated := s.translateUpdate(pCertificate, vCertificate)
+	if updated != nil {
+		err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+			_, err := s.SyncDownUpdate(ctx, vObj, updated)
+			return err
+		})
+	}
+	if admission.IsRejected(err) {
+		return s.rejectSync(ctx, vCertificate, vStatus, updated, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vCertificate)
+	}
+
+	return ctrl.Result{}, err
+}
+
+// checkDomainPolicy returns a message if the certificate violates the domain policy. In audit mode,
+// violations are only logged.
+func (s *certificateSyncer) checkDomainPolicy(ctx *context.SyncContext, vCertificate *certmanagerv1.Certificate) (string, error) {
+	message, err := s.domainPolicy.Check(ctx.Context, policy.Names{
+		DNSNames:       certificateDomains(vCertificate),
+		IPAddresses:    vCertificate.Spec.IPAddresses,
+		URIs:           vCertificate.Spec.URIs,
+		EmailAddresses: vCertificate.Spec.EmailAddresses,
+	})
+	if err != nil || message == "" {
+		return "", err
+	} else if s.domainPolicy.AuditOnly() {
+		ctx.Log.Infof("certificate %s/%s violates the domain policy: %s", vCertificate.Namespace, vCertificate.Name, message)
+		return "", nil
+	}
+
+	return message, nil
+}
+
+// checkIssuerRef returns a message and false if the certificate references an issuer kind that is
+// not synced from the vcluster, see issuers.IsSupportedRef
+func (s *certificateSyncer) checkIssuerRef(vCertificate *certmanagerv1.Certificate) (string, bool) {
+	if issuers.IsSupportedRef(vCertificate.Spec.IssuerRef, s.externalIssuers) {
+		return "", true
+	}
+
+	kind := schema.GroupKind{Group: vCertificate.Spec.IssuerRef.Group, Kind: vCertificate.Spec.IssuerRef.Kind}
+	return fmt.Sprintf("issuer kind %s is not supported within this vcluster", kind.String()), false
+}
+
+func (s *certificateSyncer) clusterIssuerAllowed(ctx *context.SyncContext, vCertificate *certmanagerv1.Certificate) (bool, error) {
+	issuerRef := issuers.TranslateIssuerRef(ctx.Context, ctx.VirtualClient, vCertificate.Spec.IssuerRef, vCertificate.Namespace, ctx.TargetNamespace, s.externalIssuers)
+	allowed, err := clusterissuers.IsAllowedRef(ctx.Context, ctx.PhysicalClient, issuerRef, s.allowedClusterIssuers)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	ctx.Log.Infof("skip syncing certificate %s/%s, because cluster issuer %s is not allowed", vCertificate.Namespace, vCertificate.Name, vCertificate.Spec.IssuerRef.Name)
+	s.EventRecorder().Eventf(vCertificate, "Warning", "SyncError", "ClusterIssuer %s is not allowed within this vcluster", vCertificate.Spec.IssuerRef.Name)
+	return false, nil
 }
 
 var _ syncer.UpSyncer = &certificateSyncer{}
@@ -95,7 +291,9 @@ func (s *certificateSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object)
 			return ctrl.Result{}, err
 		}
 
-		return ctrl.Result{}, s.virtualClient.Create(ctx.Context, vCertificate)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+			return s.virtualClient.Create(ctx.Context, vCertificate)
+		})
 	}
 
 	managed, err := s.IsManaged(pObj)
@@ -105,5 +303,9 @@ func (s *certificateSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object)
 	if !managed {
 		return ctrl.Result{}, nil
 	}
-	return syncer.DeleteObject(ctx, pObj)
+
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+		_, err := syncer.DeleteObject(ctx, pObj)
+		return err
+	})
 }
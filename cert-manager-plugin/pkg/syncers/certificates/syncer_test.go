@@ -0,0 +1,80 @@
+package certificates
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+func TestSyncDownIssuerRef(t *testing.T) {
+	cfg := &config.Config{ExternalIssuers: config.ExternalIssuers{{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"}}}
+
+	testCases := []struct {
+		name      string
+		issuerRef cmmeta.ObjectReference
+
+		expectedSynced bool
+	}{
+		{
+			name:           "Issuer",
+			issuerRef:      cmmeta.ObjectReference{Name: "letsencrypt"},
+			expectedSynced: true,
+		},
+		{
+			name:           "External issuer",
+			issuerRef:      cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAIssuer", Group: "awspca.cert-manager.io"},
+			expectedSynced: true,
+		},
+		{
+			name:      "External cluster issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"},
+		},
+		{
+			name:      "Translated issuer of another namespace",
+			issuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("step", "team-b"), Kind: "StepIssuer", Group: "certmanager.step.sm"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		vCertificate := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.Namespace, Name: "tls"},
+			Spec:       certmanagerv1.CertificateSpec{SecretName: "tls", DNSNames: []string{"example.com"}, IssuerRef: testCase.issuerRef},
+		}
+		ctx := testingutil.NewSyncContext(t, []client.Object{vCertificate}, nil)
+		s := New(testingutil.NewRegisterContext(ctx.VirtualClient, ctx.PhysicalClient), cfg).(*certificateSyncer)
+
+		_, err := s.SyncDown(ctx, vCertificate)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		pCertificates := &certmanagerv1.CertificateList{}
+		err = ctx.PhysicalClient.List(ctx.Context, pCertificates)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if synced := len(pCertificates.Items) > 0; synced != testCase.expectedSynced {
+			t.Errorf("Test case %s: expected synced %t, got %t", testCase.name, testCase.expectedSynced, synced)
+		}
+
+		updated := &certmanagerv1.Certificate{}
+		err = ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Namespace: vCertificate.Namespace, Name: vCertificate.Name}, updated)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		refused := false
+		for _, condition := range updated.Status.Conditions {
+			if condition.Type == CertificateConditionIssuerNotSupported && condition.Status == cmmeta.ConditionTrue {
+				refused = true
+			}
+		}
+		if refused == testCase.expectedSynced {
+			t.Errorf("Test case %s: expected condition %s to be set %t, got %v", testCase.name, CertificateConditionIssuerNotSupported, !testCase.expectedSynced, updated.Status.Conditions)
+		}
+	}
+}
@@ -2,21 +2,28 @@ package certificates
 
 import (
 	"context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/certificaterequests"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
 	"github.com/loft-sh/vcluster-sdk/clienthelper"
 	"github.com/loft-sh/vcluster-sdk/syncer/translator"
 	"github.com/loft-sh/vcluster-sdk/translate"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
 )
 
 func (s *certificateSyncer) translate(vObj client.Object) *certmanagerv1.Certificate {
 	pObj := s.TranslateMetadata(vObj).(*certmanagerv1.Certificate)
 	vCertificate := vObj.(*certmanagerv1.Certificate)
-	pObj.Spec = *rewriteSpec(&vCertificate.Spec, vCertificate.Namespace)
+	pObj.Spec = *s.rewriteSpec(&vCertificate.Spec, vCertificate.Namespace)
+	pObj.Annotations = issuers.TranslateIngressNameOverride(context.TODO(), s.virtualClient, vCertificate, pObj.Annotations, vCertificate.Spec.IssuerRef)
 	return pObj
 }
 
@@ -24,15 +31,16 @@ func (s *certificateSyncer) translateUpdate(pObj, vObj *certmanagerv1.Certificat
 	var updated *certmanagerv1.Certificate
 
 	// check annotations & labels
-	changed, updatedAnnotations, updatedLabels := s.TranslateMetadataUpdate(vObj, pObj)
-	if changed {
+	_, updatedAnnotations, updatedLabels := s.TranslateMetadataUpdate(vObj, pObj)
+	updatedAnnotations = issuers.TranslateIngressNameOverride(context.TODO(), s.virtualClient, vObj, updatedAnnotations, vObj.Spec.IssuerRef)
+	if !equality.Semantic.DeepEqual(updatedAnnotations, pObj.Annotations) || !equality.Semantic.DeepEqual(updatedLabels, pObj.Labels) {
 		updated = newIfNil(updated, pObj)
 		updated.Labels = updatedLabels
 		updated.Annotations = updatedAnnotations
 	}
 
 	// update spec
-	pSpec := rewriteSpec(&vObj.Spec, vObj.GetNamespace())
+	pSpec := s.rewriteSpec(&vObj.Spec, vObj.GetNamespace())
 	if !equality.Semantic.DeepEqual(*pSpec, pObj.Spec) {
 		updated = newIfNil(updated, pObj)
 		updated.Spec = *pSpec
@@ -41,17 +49,69 @@ func (s *certificateSyncer) translateUpdate(pObj, vObj *certmanagerv1.Certificat
 	return updated
 }
 
-func rewriteSpec(vObjSpec *certmanagerv1.CertificateSpec, namespace string) *certmanagerv1.CertificateSpec {
+// translateStatus replaces the physical certificate, secret, certificate request and issuer names within
+// the status of the physical certificate with their virtual names
+func (s *certificateSyncer) translateStatus(ctx context.Context, physicalClient client.Client, pObj, vObj *certmanagerv1.Certificate) (*certmanagerv1.CertificateStatus, error) {
+	t := statusTranslator(pObj, vObj)
+
+	// certificate requests are synced as <certificate>-<revision> into the vcluster. The status only
+	// refers to the certificate request of the current revision and the one that is being issued.
+	for _, revision := range revisions(pObj) {
+		pCertificateRequests := &certmanagerv1.CertificateRequestList{}
+		err := physicalClient.List(ctx, pCertificateRequests, client.InNamespace(pObj.Namespace), client.MatchingFields{certificaterequests.IndexByCertificateRevision: pObj.Name + "/" + revision})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pCertificateRequest := range pCertificateRequests.Items {
+			t.AddNamespacedName(types.NamespacedName{Namespace: pCertificateRequest.Namespace, Name: pCertificateRequest.Name}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Name + "-" + revision})
+		}
+	}
+
+	vObjStatus := pObj.Status.DeepCopy()
+	for i := range vObjStatus.Conditions {
+		vObjStatus.Conditions[i].Message = t.Translate(vObjStatus.Conditions[i].Message)
+	}
+	if vObjStatus.NextPrivateKeySecretName != nil {
+		vObjStatus.NextPrivateKeySecretName = pointer.String(t.Translate(*vObjStatus.NextPrivateKeySecretName))
+	}
+
+	return vObjStatus, nil
+}
+
+// revisions returns the current revision of the certificate and the revision that is issued next
+func revisions(pObj *certmanagerv1.Certificate) []string {
+	if pObj.Status.Revision == nil {
+		return []string{"1"}
+	}
+
+	return []string{strconv.Itoa(*pObj.Status.Revision), strconv.Itoa(*pObj.Status.Revision + 1)}
+}
+
+// statusTranslator returns a translator for the physical certificate, secret and issuer names
+func statusTranslator(pObj, vObj *certmanagerv1.Certificate) *status.Translator {
+	t := status.NewTranslator()
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Name}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Name})
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Spec.SecretName}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Spec.SecretName})
+	if pObj.Spec.IssuerRef.Name != vObj.Spec.IssuerRef.Name {
+		if vObj.Spec.IssuerRef.Kind == "ClusterIssuer" {
+			t.AddName(pObj.Namespace+"/"+pObj.Spec.IssuerRef.Name, vObj.Spec.IssuerRef.Name)
+			t.AddName(pObj.Spec.IssuerRef.Name, vObj.Spec.IssuerRef.Name)
+		} else {
+			t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Spec.IssuerRef.Name}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Spec.IssuerRef.Name})
+		}
+	}
+
+	return t
+}
+
+func (s *certificateSyncer) rewriteSpec(vObjSpec *certmanagerv1.CertificateSpec, namespace string) *certmanagerv1.CertificateSpec {
 	// translate secret names
 	vObjSpec = vObjSpec.DeepCopy()
 	if vObjSpec.SecretName != "" {
 		vObjSpec.SecretName = translate.PhysicalName(vObjSpec.SecretName, namespace)
 	}
-	if vObjSpec.IssuerRef.Kind == "Issuer" {
-		vObjSpec.IssuerRef.Name = translate.PhysicalName(vObjSpec.IssuerRef.Name, namespace)
-	} else if vObjSpec.IssuerRef.Kind == "ClusterIssuer" {
-		// TODO: rewrite ClusterIssuers
-	}
+	vObjSpec.IssuerRef = issuers.TranslateIssuerRef(context.TODO(), s.virtualClient, vObjSpec.IssuerRef, namespace, s.targetNamespace, s.externalIssuers)
 	if vObjSpec.Keystores != nil && vObjSpec.Keystores.JKS != nil {
 		vObjSpec.Keystores.JKS.PasswordSecretRef.Name = translate.PhysicalName(vObjSpec.Keystores.JKS.PasswordSecretRef.Name, namespace)
 	}
@@ -68,14 +128,10 @@ func (s *certificateSyncer) translateBackwards(pObj *certmanagerv1.Certificate,
 			Name:        name.Name,
 			Namespace:   name.Namespace,
 			Labels:      pObj.Labels,
-			Annotations: map[string]string{},
+			Annotations: s.translateAnnotationsBackwards(pObj),
 		},
 		Spec: certmanagerv1.CertificateSpec{},
 	}
-	for k, v := range pObj.Annotations {
-		vCertificate.Annotations[k] = v
-	}
-	vCertificate.Annotations[constants.BackwardSyncAnnotation] = "true"
 
 	// rewrite spec
 	vCertificateSpec, err := s.rewriteSpecBackwards(&pObj.Spec, name)
@@ -97,11 +153,7 @@ func (s *certificateSyncer) translateUpdateBackwards(pObj, vObj *certmanagerv1.C
 	}
 
 	// check annotations
-	newAnnotations := map[string]string{}
-	for k, v := range pObj.Annotations {
-		newAnnotations[k] = v
-	}
-	newAnnotations[constants.BackwardSyncAnnotation] = "true"
+	newAnnotations := s.translateAnnotationsBackwards(pObj)
 	if !equality.Semantic.DeepEqual(newAnnotations, vObj.Annotations) {
 		updated = newIfNil(updated, vObj)
 		updated.Annotations = newAnnotations
@@ -120,17 +172,54 @@ func (s *certificateSyncer) translateUpdateBackwards(pObj, vObj *certmanagerv1.C
 	return updated, nil
 }
 
+// translateAnnotationsBackwards translates the http01 override annotations the ingress-shim sets
+// on certificates of ingresses back to the virtual ingress name and ingress class
+func (s *certificateSyncer) translateAnnotationsBackwards(pObj *certmanagerv1.Certificate) map[string]string {
+	newAnnotations := map[string]string{}
+	for k, v := range pObj.Annotations {
+		newAnnotations[k] = v
+	}
+	newAnnotations[constants.BackwardSyncAnnotation] = "true"
+
+	ingressName := newAnnotations[cmacme.ACMECertificateHTTP01IngressNameOverride]
+	if ingressName != "" {
+		vIngress := s.ingressByCertificate(pObj)
+		if vIngress != nil && translate.PhysicalName(vIngress.Name, vIngress.Namespace) == ingressName {
+			newAnnotations[cmacme.ACMECertificateHTTP01IngressNameOverride] = vIngress.Name
+		}
+	}
+	ingressClass := newAnnotations[cmacme.ACMECertificateHTTP01IngressClassOverride]
+	if ingressClass != "" {
+		for vIngressClass, pIngressClass := range s.ingressClasses {
+			if pIngressClass == ingressClass {
+				newAnnotations[cmacme.ACMECertificateHTTP01IngressClassOverride] = vIngressClass
+				break
+			}
+		}
+	}
+
+	return newAnnotations
+}
+
 func (s *certificateSyncer) rewriteSpecBackwards(pObjSpec *certmanagerv1.CertificateSpec, vName types.NamespacedName) (*certmanagerv1.CertificateSpec, error) {
 	vObjSpec := pObjSpec.DeepCopy()
 
 	// find issuer
 	vObjSpec.SecretName = vName.Name
-	if vObjSpec.IssuerRef.Kind == "Issuer" {
+	if vObjSpec.IssuerRef.Kind == "" || vObjSpec.IssuerRef.Kind == "Issuer" {
 		// try to find issuer
 		issuer := &certmanagerv1.Issuer{}
 		err := clienthelper.GetByIndex(context.TODO(), s.virtualClient, issuer, translator.IndexByPhysicalName, vObjSpec.IssuerRef.Name)
 		if err == nil {
 			vObjSpec.IssuerRef.Name = issuer.Name
+		} else {
+			// try to find cluster issuer
+			clusterIssuer := &certmanagerv1.ClusterIssuer{}
+			err = clienthelper.GetByIndex(context.TODO(), s.virtualClient, clusterIssuer, translator.IndexByPhysicalName, vObjSpec.IssuerRef.Name)
+			if err == nil {
+				vObjSpec.IssuerRef.Kind = "ClusterIssuer"
+				vObjSpec.IssuerRef.Name = clusterIssuer.Name
+			}
 		}
 	}
 
@@ -0,0 +1,92 @@
+package certificates
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"testing"
+)
+
+func TestTranslateStatus(t *testing.T) {
+	pName := translate.PhysicalName("tls", testingutil.Namespace)
+	pSecretName := translate.PhysicalName("tls-secret", testingutil.Namespace)
+	pIssuerName := translate.PhysicalName("letsencrypt", testingutil.Namespace)
+	pCertificateRequest := &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.TargetNamespace, Name: pName + "-8xk2p"}}
+
+	testCases := []struct {
+		name      string
+		issuerRef cmmeta.ObjectReference
+		pIssuer   string
+		message   string
+
+		expectedMessage string
+	}{
+		{
+			name:            "Certificate and certificate request",
+			issuerRef:       cmmeta.ObjectReference{Name: "letsencrypt"},
+			pIssuer:         pIssuerName,
+			message:         "Waiting for CertificateRequest \"" + pCertificateRequest.Name + "\" of certificate " + testingutil.TargetNamespace + "/" + pName + " to complete",
+			expectedMessage: "Waiting for CertificateRequest \"tls-1\" of certificate " + testingutil.Namespace + "/tls to complete",
+		},
+		{
+			name:            "Secret and issuer",
+			issuerRef:       cmmeta.ObjectReference{Name: "letsencrypt"},
+			pIssuer:         pIssuerName,
+			message:         "Issuing certificate as Secret " + pSecretName + " does not exist, issuer " + testingutil.TargetNamespace + "/" + pIssuerName + " is not ready",
+			expectedMessage: "Issuing certificate as Secret tls-secret does not exist, issuer " + testingutil.Namespace + "/letsencrypt is not ready",
+		},
+		{
+			name:            "Cluster issuer",
+			issuerRef:       cmmeta.ObjectReference{Name: "ca", Kind: "ClusterIssuer"},
+			pIssuer:         translate.PhysicalName("ca", "vcluster-cluster-issuers"),
+			message:         "Issuer " + testingutil.TargetNamespace + "/" + translate.PhysicalName("ca", "vcluster-cluster-issuers") + " is not ready",
+			expectedMessage: "Issuer ca is not ready",
+		},
+		{
+			name:            "Host cluster issuer",
+			issuerRef:       cmmeta.ObjectReference{Name: "letsencrypt-prod", Kind: "ClusterIssuer"},
+			pIssuer:         "letsencrypt-prod",
+			message:         "ClusterIssuer letsencrypt-prod is not ready",
+			expectedMessage: "ClusterIssuer letsencrypt-prod is not ready",
+		},
+	}
+
+	for _, testCase := range testCases {
+		vCertificate := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.Namespace, Name: "tls"},
+			Spec:       certmanagerv1.CertificateSpec{SecretName: "tls-secret", IssuerRef: testCase.issuerRef},
+		}
+		pIssuerRef := testCase.issuerRef
+		pIssuerRef.Name = testCase.pIssuer
+		if pIssuerRef.Kind == "ClusterIssuer" && testCase.pIssuer != testCase.issuerRef.Name {
+			pIssuerRef.Kind = "Issuer"
+		}
+		pCertificate := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Namespace: testingutil.TargetNamespace, Name: pName},
+			Spec:       certmanagerv1.CertificateSpec{SecretName: pSecretName, IssuerRef: pIssuerRef},
+			Status: certmanagerv1.CertificateStatus{
+				Conditions:               []certmanagerv1.CertificateCondition{{Type: certmanagerv1.CertificateConditionReady, Status: cmmeta.ConditionFalse, Message: testCase.message}},
+				NextPrivateKeySecretName: pointer.String(pName + "-4vzmd"),
+			},
+		}
+		s := &certificateSyncer{}
+
+		vStatus, err := s.translateStatus(context2.TODO(), testingutil.NewFakeClient(pCertificateRequest), pCertificate, vCertificate)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if vStatus.Conditions[0].Message != testCase.expectedMessage {
+			t.Errorf("Test case %s: expected message %q, got %q", testCase.name, testCase.expectedMessage, vStatus.Conditions[0].Message)
+		}
+		if *vStatus.NextPrivateKeySecretName != "tls-4vzmd" {
+			t.Errorf("Test case %s: expected next private key secret name tls-4vzmd, got %s", testCase.name, *vStatus.NextPrivateKeySecretName)
+		}
+		if pCertificate.Status.Conditions[0].Message != testCase.message {
+			t.Errorf("Test case %s: expected physical status to be left unchanged, got %q", testCase.name, pCertificate.Status.Conditions[0].Message)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package challenges
+
+import (
+	context2 "context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/clienthelper"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+var (
+	IndexByOrder = "indexbyorder"
+)
+
+var _ syncer.IndicesRegisterer = &challengeSyncer{}
+
+func (s *challengeSyncer) RegisterIndices(ctx *context.RegisterContext) error {
+	return ctx.PhysicalManager.GetFieldIndexer().IndexField(ctx.Context, &cmacme.Challenge{}, IndexByOrder, func(rawObj client.Object) []string {
+		name := orderSuffix(rawObj)
+		if name == "" {
+			return nil
+		}
+
+		return []string{name}
+	})
+}
+
+func (s *challengeSyncer) IsManaged(pObj client.Object) (bool, error) {
+	// only challenges created for orders of the vcluster are managed
+	return s.PhysicalToVirtual(pObj).Name != "", nil
+}
+
+// VirtualToPhysical translates the virtual challenge name, which is <order>-<hash>,
+// to the physical challenge that was created by cert-manager for the physical order
+func (s *challengeSyncer) VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName {
+	idx := strings.LastIndex(req.Name, "-")
+	if idx <= 0 {
+		return types.NamespacedName{}
+	}
+
+	vOrder := &cmacme.Order{}
+	err := s.virtualClient.Get(context2.TODO(), types.NamespacedName{Namespace: req.Namespace, Name: req.Name[:idx]}, vOrder)
+	if err != nil {
+		return types.NamespacedName{}
+	}
+
+	pOrderName := s.orders.VirtualToPhysical(types.NamespacedName{Namespace: req.Namespace, Name: req.Name[:idx]}, vOrder)
+	if pOrderName.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	pChallenge := &cmacme.Challenge{}
+	err = clienthelper.GetByIndex(context2.TODO(), s.physicalClient, pChallenge, IndexByOrder, pOrderName.Name+"/"+req.Name[idx+1:])
+	if err != nil || pChallenge.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	return types.NamespacedName{
+		Namespace: pChallenge.Namespace,
+		Name:      pChallenge.Name,
+	}
+}
+
+func (s *challengeSyncer) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
+	if pObj.GetNamespace() != s.targetNamespace {
+		return types.NamespacedName{}
+	}
+
+	pOrder := s.physicalOrder(pObj)
+	if pOrder == nil {
+		return types.NamespacedName{}
+	}
+
+	vOrderName := s.orders.PhysicalToVirtual(pOrder)
+	if vOrderName.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	return types.NamespacedName{
+		Namespace: vOrderName.Namespace,
+		Name:      vOrderName.Name + "-" + nameSuffix(pObj.GetName()),
+	}
+}
+
+// orderByChallenge returns the virtual order the physical challenge was created for by
+// cert-manager in the host cluster
+func (s *challengeSyncer) orderByChallenge(pObj client.Object) *cmacme.Order {
+	pOrder := s.physicalOrder(pObj)
+	if pOrder == nil {
+		return nil
+	}
+
+	vOrderName := s.orders.PhysicalToVirtual(pOrder)
+	if vOrderName.Name == "" {
+		return nil
+	}
+
+	vOrder := &cmacme.Order{}
+	err := s.virtualClient.Get(context2.TODO(), vOrderName, vOrder)
+	if err != nil {
+		return nil
+	}
+
+	return vOrder
+}
+
+func (s *challengeSyncer) physicalOrder(pObj client.Object) *cmacme.Order {
+	owner := orderOwner(pObj)
+	if owner == nil {
+		return nil
+	}
+
+	pOrder := &cmacme.Order{}
+	err := s.physicalClient.Get(context2.TODO(), types.NamespacedName{Namespace: pObj.GetNamespace(), Name: owner.Name}, pOrder)
+	if err != nil || pOrder.UID != owner.UID {
+		return nil
+	}
+
+	return pOrder
+}
+
+func orderOwner(pObj client.Object) *metav1.OwnerReference {
+	for _, owner := range pObj.GetOwnerReferences() {
+		if owner.Kind == "Order" && strings.HasPrefix(owner.APIVersion, cmacme.SchemeGroupVersion.Group+"/") {
+			return &owner
+		}
+	}
+
+	return nil
+}
+
+// orderSuffix returns <order>/<hash> for the given physical challenge
+func orderSuffix(pObj client.Object) string {
+	owner := orderOwner(pObj)
+	if owner == nil {
+		return ""
+	}
+
+	return owner.Name + "/" + nameSuffix(pObj.GetName())
+}
+
+// nameSuffix returns the hash cert-manager appends to the names of generated challenges
+func nameSuffix(name string) string {
+	return name[strings.LastIndex(name, "-")+1:]
+}
+
+func isBackward(vObj client.Object) bool {
+	return vObj.GetAnnotations() != nil && vObj.GetAnnotations()[constants.BackwardSyncAnnotation] == "true"
+}
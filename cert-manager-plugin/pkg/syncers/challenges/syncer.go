@@ -0,0 +1,112 @@
+package challenges
+
+import (
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// New creates a syncer that mirrors challenges created by cert-manager in the host cluster for
+// orders of the vcluster read-only into the vcluster. The given order translator is used to
+// find the virtual order a challenge belongs to.
+func New(ctx *context.RegisterContext, orders translator.NameTranslator) syncer.Syncer {
+	return &challengeSyncer{
+		Translator: translator.NewMirrorPhysicalTranslator("challenge", &cmacme.Challenge{}),
+
+		orders: orders,
+
+		virtualClient:   ctx.VirtualManager.GetClient(),
+		physicalClient:  ctx.PhysicalManager.GetClient(),
+		targetNamespace: ctx.TargetNamespace,
+	}
+}
+
+type challengeSyncer struct {
+	translator.Translator
+
+	orders translator.NameTranslator
+
+	virtualClient   client.Client
+	physicalClient  client.Client
+	targetNamespace string
+}
+
+var _ syncer.Initializer = &challengeSyncer{}
+
+func (s *challengeSyncer) Init(ctx *context.RegisterContext) error {
+	return translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), cmacme.SchemeGroupVersion.WithKind("Challenge"))
+}
+
+func (s *challengeSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
+	if !isBackward(vObj) {
+		return ctrl.Result{}, nil
+	}
+
+	// delete here as challenge is no longer there
+	ctx.Log.Infof("delete virtual challenge %s/%s, because physical got deleted", vObj.GetNamespace(), vObj.GetName())
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+		return ctx.VirtualClient.Delete(ctx.Context, vObj)
+	})
+}
+
+func (s *challengeSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
+	vChallenge := vObj.(*cmacme.Challenge)
+	pChallenge := pObj.(*cmacme.Challenge)
+	if !isBackward(vChallenge) {
+		return ctrl.Result{}, nil
+	}
+
+	vOrder := s.orderByChallenge(pChallenge)
+	if vOrder == nil {
+		return ctrl.Result{}, nil
+	}
+
+	vStatus := rewriteStatus(&pChallenge.Status, pChallenge, vChallenge.Name, vOrder)
+	if !equality.Semantic.DeepEqual(vChallenge.Status, *vStatus) {
+		newChallenge := vChallenge.DeepCopy()
+		newChallenge.Status = *vStatus
+		ctx.Log.Infof("update virtual challenge %s/%s, because status is out of sync", vChallenge.Namespace, vChallenge.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newChallenge)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	// challenges are read-only, so revert any changes
+	updated := s.translateUpdate(ctx.Context, pChallenge, vChallenge, vOrder)
+	if updated != nil {
+		ctx.Log.Infof("update virtual challenge %s/%s, because it is out of sync with the physical one", vChallenge.Namespace, vChallenge.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Update(ctx.Context, updated)
+		})
+	}
+
+	return ctrl.Result{}, nil
+}
+
+var _ syncer.UpSyncer = &challengeSyncer{}
+
+func (s *challengeSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object) (ctrl.Result, error) {
+	pChallenge := pObj.(*cmacme.Challenge)
+	vOrder := s.orderByChallenge(pChallenge)
+	if vOrder == nil {
+		return ctrl.Result{}, nil
+	}
+
+	vChallenge := s.translate(ctx.Context, pChallenge, vOrder)
+	ctx.Log.Infof("create virtual challenge %s/%s, because physical is there and virtual is missing", vChallenge.Namespace, vChallenge.Name)
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+		return ctx.VirtualClient.Create(ctx.Context, vChallenge)
+	})
+}
@@ -0,0 +1,193 @@
+package challenges
+
+import (
+	"context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func (s *challengeSyncer) translate(ctx context.Context, pObj *cmacme.Challenge, vOrder *cmacme.Order) *cmacme.Challenge {
+	vName := s.PhysicalToVirtual(pObj)
+	vObj := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            vName.Name,
+			Namespace:       vName.Namespace,
+			Labels:          translateLabels(vOrder),
+			Annotations:     translateAnnotations(pObj.Annotations),
+			OwnerReferences: ownerReferences(vOrder),
+		},
+		Spec: *s.rewriteSpec(ctx, &pObj.Spec, vOrder),
+	}
+
+	return vObj
+}
+
+func (s *challengeSyncer) translateUpdate(ctx context.Context, pObj, vObj *cmacme.Challenge, vOrder *cmacme.Order) *cmacme.Challenge {
+	var updated *cmacme.Challenge
+
+	// check labels
+	newLabels := translateLabels(vOrder)
+	if !equality.Semantic.DeepEqual(newLabels, vObj.Labels) {
+		updated = newIfNil(updated, vObj)
+		updated.Labels = newLabels
+	}
+
+	// check annotations
+	newAnnotations := translateAnnotations(pObj.Annotations)
+	if !equality.Semantic.DeepEqual(newAnnotations, vObj.Annotations) {
+		updated = newIfNil(updated, vObj)
+		updated.Annotations = newAnnotations
+	}
+
+	// check owner references
+	newOwnerReferences := ownerReferences(vOrder)
+	if !equality.Semantic.DeepEqual(newOwnerReferences, vObj.OwnerReferences) {
+		updated = newIfNil(updated, vObj)
+		updated.OwnerReferences = newOwnerReferences
+	}
+
+	// check spec
+	vSpec := s.rewriteSpec(ctx, &pObj.Spec, vOrder)
+	if !equality.Semantic.DeepEqual(*vSpec, vObj.Spec) {
+		updated = newIfNil(updated, vObj)
+		updated.Spec = *vSpec
+	}
+
+	return updated
+}
+
+func (s *challengeSyncer) rewriteSpec(ctx context.Context, pObjSpec *cmacme.ChallengeSpec, vOrder *cmacme.Order) *cmacme.ChallengeSpec {
+	vObjSpec := pObjSpec.DeepCopy()
+
+	// the challenge was created for the virtual order, so it uses the same issuer
+	vObjSpec.IssuerRef = vOrder.Spec.IssuerRef
+	vObjSpec.Solver = s.rewriteSolver(ctx, pObjSpec, vOrder)
+	return vObjSpec
+}
+
+// rewriteSolver returns the solver of the virtual issuer that corresponds to the selected solver of the
+// physical issuer, as the physical solver might contain translated secret and resource names. If the
+// solver cannot be found, e.g. because the issuer is gone, an empty solver is returned instead of the
+// physical one, so that host names do not leak into the vcluster.
+func (s *challengeSyncer) rewriteSolver(ctx context.Context, pObjSpec *cmacme.ChallengeSpec, vOrder *cmacme.Order) cmacme.ACMEChallengeSolver {
+	pIssuerSpec := getIssuerSpec(ctx, s.physicalClient, pObjSpec.IssuerRef, s.targetNamespace)
+	vIssuerSpec := getIssuerSpec(ctx, s.virtualClient, vOrder.Spec.IssuerRef, vOrder.Namespace)
+	if pIssuerSpec == nil || vIssuerSpec == nil || pIssuerSpec.ACME == nil || vIssuerSpec.ACME == nil {
+		return cmacme.ACMEChallengeSolver{}
+	}
+
+	for i := range pIssuerSpec.ACME.Solvers {
+		if matchesSolver(pIssuerSpec.ACME.Solvers[i], pObjSpec.Solver) && i < len(vIssuerSpec.ACME.Solvers) {
+			return vIssuerSpec.ACME.Solvers[i]
+		}
+	}
+
+	return cmacme.ACMEChallengeSolver{}
+}
+
+// matchesSolver returns true if the solver of the challenge was created from the given solver of the
+// issuer. cert-manager applies the http01 override annotations of the certificate to the ingress name
+// and class of the challenge solver, so these are ignored.
+func matchesSolver(issuerSolver, challengeSolver cmacme.ACMEChallengeSolver) bool {
+	if issuerSolver.HTTP01 != nil && issuerSolver.HTTP01.Ingress != nil && challengeSolver.HTTP01 != nil && challengeSolver.HTTP01.Ingress != nil {
+		challengeSolver = *challengeSolver.DeepCopy()
+		challengeSolver.HTTP01.Ingress.Name = issuerSolver.HTTP01.Ingress.Name
+		challengeSolver.HTTP01.Ingress.Class = issuerSolver.HTTP01.Ingress.Class
+	}
+
+	return equality.Semantic.DeepEqual(issuerSolver, challengeSolver)
+}
+
+// rewriteStatus replaces the physical names within the status with the virtual ones
+func rewriteStatus(pObjStatus *cmacme.ChallengeStatus, pObj *cmacme.Challenge, vName string, vOrder *cmacme.Order) *cmacme.ChallengeStatus {
+	vObjStatus := pObjStatus.DeepCopy()
+	if vObjStatus.Reason == "" {
+		return vObjStatus
+	}
+
+	t := status.NewTranslator()
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Name}, types.NamespacedName{Namespace: vOrder.Namespace, Name: vName})
+	if owner := orderOwner(pObj); owner != nil {
+		t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: owner.Name}, types.NamespacedName{Namespace: vOrder.Namespace, Name: vOrder.Name})
+	}
+
+	vObjStatus.Reason = t.Translate(vObjStatus.Reason)
+	return vObjStatus
+}
+
+func getIssuerSpec(ctx context.Context, kubeClient client.Client, issuerRef cmmeta.ObjectReference, namespace string) *certmanagerv1.IssuerSpec {
+	if issuerRef.Group != "" && issuerRef.Group != certmanagerv1.SchemeGroupVersion.Group {
+		return nil
+	}
+
+	switch issuerRef.Kind {
+	case "", "Issuer":
+		issuer := &certmanagerv1.Issuer{}
+		err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: issuerRef.Name}, issuer)
+		if err != nil {
+			return nil
+		}
+
+		return &issuer.Spec
+	case "ClusterIssuer":
+		clusterIssuer := &certmanagerv1.ClusterIssuer{}
+		err := kubeClient.Get(ctx, types.NamespacedName{Name: issuerRef.Name}, clusterIssuer)
+		if err != nil {
+			return nil
+		}
+
+		return &clusterIssuer.Spec
+	}
+
+	return nil
+}
+
+func translateLabels(vOrder *cmacme.Order) map[string]string {
+	if len(vOrder.Labels) == 0 {
+		return nil
+	}
+
+	// cert-manager copies the labels of the order to the challenge
+	newLabels := map[string]string{}
+	for k, v := range vOrder.Labels {
+		newLabels[k] = v
+	}
+	return newLabels
+}
+
+func translateAnnotations(pAnnotations map[string]string) map[string]string {
+	newAnnotations := map[string]string{}
+	for k, v := range pAnnotations {
+		newAnnotations[k] = v
+	}
+
+	newAnnotations[constants.BackwardSyncAnnotation] = "true"
+	return newAnnotations
+}
+
+func ownerReferences(vOrder *cmacme.Order) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			APIVersion: cmacme.SchemeGroupVersion.String(),
+			Kind:       "Order",
+			Name:       vOrder.Name,
+			UID:        vOrder.UID,
+			Controller: pointer.Bool(true),
+		},
+	}
+}
+
+func newIfNil(updated *cmacme.Challenge, obj *cmacme.Challenge) *cmacme.Challenge {
+	if updated == nil {
+		return obj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,96 @@
+package challenges
+
+import (
+	"context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const (
+	targetNamespace = "vcluster"
+	orderNamespace  = "default"
+)
+
+func dns01Solver(secretName string) cmacme.ACMEChallengeSolver {
+	return cmacme.ACMEChallengeSolver{DNS01: &cmacme.ACMEChallengeSolverDNS01{Cloudflare: &cmacme.ACMEIssuerDNS01ProviderCloudflare{
+		APIToken: &cmmeta.SecretKeySelector{LocalObjectReference: cmmeta.LocalObjectReference{Name: secretName}, Key: "token"},
+	}}}
+}
+
+func http01Solver(ingressName, class string) cmacme.ACMEChallengeSolver {
+	return cmacme.ACMEChallengeSolver{HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Name: ingressName, Class: pointer.String(class)}}}
+}
+
+func issuer(namespace, name string, solvers ...cmacme.ACMEChallengeSolver) *certmanagerv1.Issuer {
+	return &certmanagerv1.Issuer{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{ACME: &cmacme.ACMEIssuer{Solvers: solvers}}},
+	}
+}
+
+func TestRewriteSolver(t *testing.T) {
+	pIssuerName := translate.PhysicalName("issuer", orderNamespace)
+	pSecretName := translate.PhysicalName("cloudflare", orderNamespace)
+	vObjs := []client.Object{issuer(orderNamespace, "issuer", http01Solver("web", "nginx"), dns01Solver("cloudflare"))}
+	pObjs := []client.Object{issuer(targetNamespace, pIssuerName, http01Solver(translate.PhysicalName("web", orderNamespace), "nginx"), dns01Solver(pSecretName))}
+
+	testCases := []struct {
+		name     string
+		pSolver  cmacme.ACMEChallengeSolver
+		issuer   string
+		pObjs    []client.Object
+		expected cmacme.ACMEChallengeSolver
+	}{
+		{
+			name:     "DNS01 solver",
+			pSolver:  dns01Solver(pSecretName),
+			issuer:   pIssuerName,
+			pObjs:    pObjs,
+			expected: dns01Solver("cloudflare"),
+		},
+		{
+			name:     "HTTP01 solver with ingress overrides",
+			pSolver:  http01Solver("override", "host-nginx"),
+			issuer:   pIssuerName,
+			pObjs:    pObjs,
+			expected: http01Solver("web", "nginx"),
+		},
+		{
+			name:    "Missing physical issuer",
+			pSolver: dns01Solver(pSecretName),
+			issuer:  pIssuerName,
+		},
+		{
+			name:    "Unknown solver",
+			pSolver: dns01Solver("other"),
+			issuer:  pIssuerName,
+			pObjs:   pObjs,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+	for _, testCase := range testCases {
+		s := &challengeSyncer{
+			virtualClient:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(vObjs...).Build(),
+			physicalClient:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(testCase.pObjs...).Build(),
+			targetNamespace: targetNamespace,
+		}
+		pObjSpec := &cmacme.ChallengeSpec{IssuerRef: cmmeta.ObjectReference{Name: testCase.issuer}, Solver: testCase.pSolver}
+		vOrder := &cmacme.Order{ObjectMeta: metav1.ObjectMeta{Namespace: orderNamespace, Name: "order"}, Spec: cmacme.OrderSpec{IssuerRef: cmmeta.ObjectReference{Name: "issuer"}}}
+
+		solver := s.rewriteSolver(context.TODO(), pObjSpec, vOrder)
+		if !reflect.DeepEqual(solver, testCase.expected) {
+			t.Errorf("Test case %s: expected solver %#+v, got %#+v", testCase.name, testCase.expected, solver)
+		}
+	}
+}
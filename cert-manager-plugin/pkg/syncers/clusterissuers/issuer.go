@@ -0,0 +1,342 @@
+package clusterissuers
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"time"
+)
+
+// NewIssuerSyncer creates a syncer that translates cluster issuers created within the vcluster
+// into namespaced issuers within the host target namespace. As the virtual and physical kinds
+// differ, this cannot be implemented as a regular syncer.
+func NewIssuerSyncer(ctx *context.RegisterContext, cfg *config.Config) translator.Translator {
+	return &issuerSyncer{
+		Translator: translator.NewClusterTranslator(ctx, "clusterissuer-issuer", &certmanagerv1.ClusterIssuer{}, func(vName string, vObj client.Object) string {
+			return translate.PhysicalNameClusterScoped(vName, ctx.TargetNamespace)
+		}),
+
+		clusterResourceNamespace: cfg.ClusterIssuers.ClusterResourceNamespace,
+		ingressClasses:           cfg.HTTP01.IngressClasses,
+		issuerPolicy:             policy.NewIssuerPolicy(cfg),
+		rejections:               admission.NewRejections(),
+		eventRecorder:            ctx.VirtualManager.GetEventRecorderFor("clusterissuer-issuer-syncer"),
+	}
+}
+
+type issuerSyncer struct {
+	translator.Translator
+
+	clusterResourceNamespace string
+	ingressClasses           map[string]string
+	issuerPolicy             *policy.IssuerPolicy
+	rejections               *admission.Rejections
+	eventRecorder            record.EventRecorder
+
+	syncContext *context.SyncContext
+}
+
+var _ syncer.IndicesRegisterer = &issuerSyncer{}
+
+func (s *issuerSyncer) RegisterIndices(ctx *context.RegisterContext) error {
+	return ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, &certmanagerv1.ClusterIssuer{}, translator.IndexByPhysicalName, func(rawObj client.Object) []string {
+		if isMirrored(rawObj) {
+			return nil
+		}
+
+		return []string{translate.PhysicalNameClusterScoped(rawObj.GetName(), ctx.TargetNamespace)}
+	})
+}
+
+var _ syncer.ControllerStarter = &issuerSyncer{}
+
+func (s *issuerSyncer) Register(ctx *context.RegisterContext) error {
+	s.syncContext = context.ConvertContext(ctx, s.Name())
+	return ctrl.NewControllerManagedBy(ctx.VirtualManager).
+		Named(s.Name()).
+		For(&certmanagerv1.ClusterIssuer{}).
+		Watches(source.NewKindWithCache(&certmanagerv1.Issuer{}, ctx.PhysicalManager.GetCache()), handler.EnqueueRequestsFromMapFunc(s.mapIssuers)).
+		Complete(s)
+}
+
+func (s *issuerSyncer) mapIssuers(obj client.Object) []reconcile.Request {
+	managed, err := s.IsManaged(obj)
+	if err != nil || !managed {
+		return nil
+	}
+
+	name := s.PhysicalToVirtual(obj)
+	if name.Name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: name}}
+}
+
+func (s *issuerSyncer) Reconcile(ctx context2.Context, req ctrl.Request) (ctrl.Result, error) {
+	syncContext := *s.syncContext
+	syncContext.Context = ctx
+	syncContext.Log = log.NewFromExisting(s.syncContext.Log.Base(), req.Name)
+
+	// get virtual cluster issuer
+	vClusterIssuer := &certmanagerv1.ClusterIssuer{}
+	err := syncContext.VirtualClient.Get(ctx, types.NamespacedName{Name: req.Name}, vClusterIssuer)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		vClusterIssuer = nil
+	} else if isMirrored(vClusterIssuer) {
+		// mirrored cluster issuers are handled by the cluster issuer syncer
+		return ctrl.Result{}, nil
+	}
+
+	// get physical issuer
+	pIssuer := &certmanagerv1.Issuer{}
+	err = syncContext.PhysicalClient.Get(ctx, s.physicalName(req.Name), pIssuer)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+
+		pIssuer = nil
+	}
+
+	if vClusterIssuer != nil && pIssuer == nil {
+		return s.SyncDown(&syncContext, vClusterIssuer)
+	} else if vClusterIssuer != nil && pIssuer != nil {
+		return s.Sync(&syncContext, pIssuer, vClusterIssuer)
+	} else if vClusterIssuer == nil && pIssuer != nil {
+		managed, err := s.IsManaged(pIssuer)
+		if err != nil || !managed {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+			_, err := syncer.DeleteObject(&syncContext, pIssuer)
+			return err
+		})
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (s *issuerSyncer) SyncDown(ctx *context.SyncContext, vClusterIssuer *certmanagerv1.ClusterIssuer) (ctrl.Result, error) {
+	// is the cluster issuer allowed by the issuer policy?
+	message := s.issuerPolicy.Check(&vClusterIssuer.Spec)
+	if message != "" {
+		return ctrl.Result{}, s.deny(ctx, vClusterIssuer, message)
+	}
+
+	// was the cluster issuer refused by the host cluster before?
+	rejection := s.rejections.Get(vClusterIssuer)
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	pIssuer := s.translateIssuer(ctx, vClusterIssuer)
+	ctx.Log.Infof("create physical issuer %s/%s", pIssuer.Namespace, pIssuer.Name)
+	err := metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationCreate, func() error {
+		return ctx.PhysicalClient.Create(ctx.Context, pIssuer)
+	})
+	if admission.IsRejected(err) {
+		return s.reject(ctx, vClusterIssuer, &vClusterIssuer.Status, pIssuer, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vClusterIssuer)
+	}
+
+	return ctrl.Result{}, err
+}
+
+func (s *issuerSyncer) Sync(ctx *context.SyncContext, pIssuer *certmanagerv1.Issuer, vClusterIssuer *certmanagerv1.ClusterIssuer) (ctrl.Result, error) {
+	// is the cluster issuer still allowed by the issuer policy?
+	message := s.issuerPolicy.Check(&vClusterIssuer.Spec)
+	if message != "" {
+		err := s.deny(ctx, vClusterIssuer, message)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		ctx.Log.Infof("delete physical issuer %s/%s, because it is denied by the issuer policy", pIssuer.Namespace, pIssuer.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+			return ctx.PhysicalClient.Delete(ctx.Context, pIssuer)
+		})
+	}
+
+	vStatus := s.translateStatus(pIssuer, vClusterIssuer)
+	rejection := s.rejections.Get(vClusterIssuer)
+	if rejection != nil {
+		vStatus = issuers.RejectedStatus(&vClusterIssuer.Status, vStatus, rejection.Message, vClusterIssuer.Generation)
+	}
+	if !equality.Semantic.DeepEqual(vClusterIssuer.Status, *vStatus) {
+		newClusterIssuer := vClusterIssuer.DeepCopy()
+		newClusterIssuer.Status = *vStatus
+		ctx.Log.Infof("update virtual cluster issuer %s, because status is out of sync", vClusterIssuer.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newClusterIssuer)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	// was the cluster issuer refused by the host cluster before?
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	// did the cluster issuer change?
+	updated := s.translateIssuerUpdate(pIssuer, vClusterIssuer)
+	if updated != nil {
+		ctx.Log.Infof("updating physical issuer %s/%s, because virtual cluster issuer has changed", updated.Namespace, updated.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+			return ctx.PhysicalClient.Update(ctx.Context, updated)
+		})
+		if admission.IsRejected(err) {
+			return s.reject(ctx, vClusterIssuer, vStatus, updated, err)
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if rejection != nil {
+		s.rejections.Forget(vClusterIssuer)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// deny sets the Ready condition of the virtual cluster issuer, which is denied by the issuer policy, to false
+func (s *issuerSyncer) deny(ctx *context.SyncContext, vClusterIssuer *certmanagerv1.ClusterIssuer, message string) error {
+	vStatus := issuers.DeniedStatus(&vClusterIssuer.Status, message, vClusterIssuer.Generation)
+	if equality.Semantic.DeepEqual(vClusterIssuer.Status, *vStatus) {
+		return nil
+	}
+
+	newClusterIssuer := vClusterIssuer.DeepCopy()
+	newClusterIssuer.Status = *vStatus
+	ctx.Log.Infof("update virtual cluster issuer %s, because it is denied by the issuer policy: %s", vClusterIssuer.Name, message)
+	s.eventRecorder.Eventf(vClusterIssuer, "Warning", issuers.PolicyDeniedReason, message)
+	return metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+		return ctx.VirtualClient.Status().Update(ctx.Context, newClusterIssuer)
+	})
+}
+
+// reject sets the Ready condition of the virtual cluster issuer, which the host cluster refused, to false
+// and records a warning event. The cluster issuer is synced again after an exponential backoff.
+func (s *issuerSyncer) reject(ctx *context.SyncContext, vClusterIssuer *certmanagerv1.ClusterIssuer, vStatus *certmanagerv1.IssuerStatus, pIssuer *certmanagerv1.Issuer, err error) (ctrl.Result, error) {
+	message := s.statusTranslator(pIssuer, vClusterIssuer).Translate(err.Error())
+	backoff := s.rejections.Reject(vClusterIssuer, message)
+	newStatus := issuers.RejectedStatus(&vClusterIssuer.Status, vStatus, message, vClusterIssuer.Generation)
+	if !equality.Semantic.DeepEqual(vClusterIssuer.Status, *newStatus) {
+		newClusterIssuer := vClusterIssuer.DeepCopy()
+		newClusterIssuer.Status = *newStatus
+		ctx.Log.Infof("update virtual cluster issuer %s, because host cluster refused it: %s", vClusterIssuer.Name, message)
+		s.eventRecorder.Eventf(vClusterIssuer, "Warning", admission.HostValidationFailedReason, message)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newClusterIssuer)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+func (s *issuerSyncer) physicalName(vName string) types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: s.syncContext.TargetNamespace,
+		Name:      translate.PhysicalNameClusterScoped(vName, s.syncContext.TargetNamespace),
+	}
+}
+
+func (s *issuerSyncer) translateIssuer(ctx *context.SyncContext, vClusterIssuer *certmanagerv1.ClusterIssuer) *certmanagerv1.Issuer {
+	pClusterIssuer := s.TranslateMetadata(vClusterIssuer).(*certmanagerv1.ClusterIssuer)
+	pIssuer := &certmanagerv1.Issuer{
+		ObjectMeta: pClusterIssuer.ObjectMeta,
+		Spec:       *s.rewriteSpec(&vClusterIssuer.Spec),
+	}
+	pIssuer.Namespace = ctx.TargetNamespace
+	pIssuer.OwnerReferences = translate.GetOwnerReference()
+	return pIssuer
+}
+
+func (s *issuerSyncer) translateIssuerUpdate(pObj *certmanagerv1.Issuer, vObj *certmanagerv1.ClusterIssuer) *certmanagerv1.Issuer {
+	var updated *certmanagerv1.Issuer
+
+	// check annotations & labels
+	changed, updatedAnnotations, updatedLabels := s.TranslateMetadataUpdate(vObj, pObj)
+	if changed {
+		updated = newIssuerIfNil(updated, pObj)
+		updated.Labels = updatedLabels
+		updated.Annotations = updatedAnnotations
+	}
+
+	// update spec
+	pSpec := s.rewriteSpec(&vObj.Spec)
+	if !equality.Semantic.DeepEqual(*pSpec, pObj.Spec) {
+		updated = newIssuerIfNil(updated, pObj)
+		updated.Spec = *pSpec
+	}
+
+	return updated
+}
+
+// rewriteSpec translates the spec of the cluster issuer, whose secrets are located in the cluster resource
+// namespace. Ingress names of http01 solvers refer to an ingress within the namespace of each certificate,
+// so they are removed and set through the http01 ingress name override annotation of the physical
+// certificates and certificate requests instead.
+func (s *issuerSyncer) rewriteSpec(vObjSpec *certmanagerv1.IssuerSpec) *certmanagerv1.IssuerSpec {
+	pSpec := issuers.RewriteSpec(vObjSpec, s.clusterResourceNamespace, s.ingressClasses)
+	if pSpec.ACME != nil {
+		for i := range pSpec.ACME.Solvers {
+			if pSpec.ACME.Solvers[i].HTTP01 != nil && pSpec.ACME.Solvers[i].HTTP01.Ingress != nil {
+				pSpec.ACME.Solvers[i].HTTP01.Ingress.Name = ""
+			}
+		}
+	}
+
+	return pSpec
+}
+
+// translateStatus replaces the physical issuer and secret names within the status of the physical issuer
+func (s *issuerSyncer) translateStatus(pObj *certmanagerv1.Issuer, vObj *certmanagerv1.ClusterIssuer) *certmanagerv1.IssuerStatus {
+	return issuers.TranslateStatus(&pObj.Status, s.statusTranslator(pObj, vObj))
+}
+
+func (s *issuerSyncer) statusTranslator(pObj *certmanagerv1.Issuer, vObj *certmanagerv1.ClusterIssuer) *status.Translator {
+	t := issuers.StatusTranslator(&vObj.Spec, s.clusterResourceNamespace, pObj.Namespace)
+	t.AddName(pObj.Namespace+"/"+pObj.Name, vObj.Name)
+	t.AddName(pObj.Name, vObj.Name)
+	return t
+}
+
+func newIssuerIfNil(updated *certmanagerv1.Issuer, pObj *certmanagerv1.Issuer) *certmanagerv1.Issuer {
+	if updated == nil {
+		return pObj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,146 @@
+package clusterissuers
+
+import (
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const clusterResourceNamespace = "cert-manager"
+
+// newTestIssuerSyncer returns a virtual cluster issuer syncer and a sync context with fake clients
+func newTestIssuerSyncer(t *testing.T, cfg *config.Config, vObjs, pObjs []client.Object) (*issuerSyncer, *context.SyncContext) {
+	ctx := testingutil.NewSyncContext(t, vObjs, pObjs)
+	cfg.ClusterIssuers.ClusterResourceNamespace = clusterResourceNamespace
+	s := NewIssuerSyncer(testingutil.NewRegisterContext(ctx.VirtualClient, ctx.PhysicalClient), cfg).(*issuerSyncer)
+	s.syncContext = ctx
+	return s, ctx
+}
+
+func virtualClusterIssuer() *certmanagerv1.ClusterIssuer {
+	return &certmanagerv1.ClusterIssuer{
+		ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt", Labels: map[string]string{"team": "a"}},
+		Spec: certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{ACME: &cmacme.ACMEIssuer{
+			Server:     "https://acme-v02.api.letsencrypt.org/directory",
+			PrivateKey: cmmeta.SecretKeySelector{LocalObjectReference: cmmeta.LocalObjectReference{Name: "account-key"}},
+			Solvers: []cmacme.ACMEChallengeSolver{
+				{DNS01: &cmacme.ACMEChallengeSolverDNS01{Cloudflare: &cmacme.ACMEIssuerDNS01ProviderCloudflare{
+					APIToken: &cmmeta.SecretKeySelector{LocalObjectReference: cmmeta.LocalObjectReference{Name: "cloudflare"}, Key: "token"},
+				}}},
+				{HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "web"}}},
+			},
+		}}},
+	}
+}
+
+func TestSyncDownClusterIssuer(t *testing.T) {
+	vClusterIssuer := virtualClusterIssuer()
+	s, ctx := newTestIssuerSyncer(t, &config.Config{}, []client.Object{vClusterIssuer.DeepCopy()}, nil)
+	_, err := s.SyncDown(ctx, vClusterIssuer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the cluster issuer is created as namespaced issuer within the target namespace
+	pIssuer := &certmanagerv1.Issuer{}
+	pName := s.physicalName(vClusterIssuer.Name)
+	if pName.Namespace != testingutil.TargetNamespace || pName.Name != translate.PhysicalNameClusterScoped(vClusterIssuer.Name, testingutil.TargetNamespace) {
+		t.Errorf("Expected physical issuer %s/%s, got %s", testingutil.TargetNamespace, translate.PhysicalNameClusterScoped(vClusterIssuer.Name, testingutil.TargetNamespace), pName.String())
+	}
+	err = ctx.PhysicalClient.Get(ctx.Context, pName, pIssuer)
+	if err != nil {
+		t.Fatalf("Expected physical issuer to be created, got %v", err)
+	}
+
+	// secrets are looked up within the cluster resource namespace
+	acme := pIssuer.Spec.ACME
+	if expected := translate.PhysicalName("account-key", clusterResourceNamespace); acme.PrivateKey.Name != expected {
+		t.Errorf("Expected private key secret %s, got %s", expected, acme.PrivateKey.Name)
+	}
+	if expected := translate.PhysicalName("cloudflare", clusterResourceNamespace); acme.Solvers[0].DNS01.Cloudflare.APIToken.Name != expected {
+		t.Errorf("Expected api token secret %s, got %s", expected, acme.Solvers[0].DNS01.Cloudflare.APIToken.Name)
+	}
+
+	// the ingress name is set per certificate instead
+	if acme.Solvers[1].HTTP01.Ingress.Name != "" {
+		t.Errorf("Expected no ingress name, got %s", acme.Solvers[1].HTTP01.Ingress.Name)
+	}
+
+	// the virtual cluster issuer is found through the annotations of the physical issuer
+	if name := s.PhysicalToVirtual(pIssuer); name.Name != vClusterIssuer.Name {
+		t.Errorf("Expected virtual cluster issuer %s, got %s", vClusterIssuer.Name, name.String())
+	}
+}
+
+func TestSyncClusterIssuer(t *testing.T) {
+	vClusterIssuer := virtualClusterIssuer()
+	s, ctx := newTestIssuerSyncer(t, &config.Config{}, []client.Object{vClusterIssuer.DeepCopy()}, nil)
+	pIssuer := s.translateIssuer(ctx, vClusterIssuer)
+	pIssuer.Status = certmanagerv1.IssuerStatus{Conditions: []certmanagerv1.IssuerCondition{{
+		Type:    certmanagerv1.IssuerConditionReady,
+		Status:  cmmeta.ConditionFalse,
+		Message: "Failed to verify ACME account of issuer " + pIssuer.Name,
+	}}}
+	err := ctx.PhysicalClient.Create(ctx.Context, pIssuer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// the status is synced with the physical names translated
+	_, err = s.Sync(ctx, pIssuer, getClusterIssuer(t, ctx, vClusterIssuer.Name))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	updated := getClusterIssuer(t, ctx, vClusterIssuer.Name)
+	if len(updated.Status.Conditions) != 1 || updated.Status.Conditions[0].Message != "Failed to verify ACME account of issuer "+vClusterIssuer.Name {
+		t.Errorf("Expected translated status, got %v", updated.Status)
+	}
+
+	// changes of the spec are synced down
+	updated.Spec.ACME.Solvers[0].DNS01.Cloudflare.APIToken.Name = "other"
+	_, err = s.Sync(ctx, pIssuer, updated)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pUpdated := &certmanagerv1.Issuer{}
+	err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: pIssuer.Namespace, Name: pIssuer.Name}, pUpdated)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := translate.PhysicalName("other", clusterResourceNamespace); pUpdated.Spec.ACME.Solvers[0].DNS01.Cloudflare.APIToken.Name != expected {
+		t.Errorf("Expected api token secret %s, got %s", expected, pUpdated.Spec.ACME.Solvers[0].DNS01.Cloudflare.APIToken.Name)
+	}
+
+	// denied cluster issuers are deleted from the host cluster
+	s, ctx = newTestIssuerSyncer(t, &config.Config{IssuerPolicy: config.IssuerPolicy{AllowedTypes: []string{"CA"}}}, []client.Object{updated}, []client.Object{pUpdated})
+	_, err = s.Sync(ctx, pUpdated, getClusterIssuer(t, ctx, vClusterIssuer.Name))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: pIssuer.Namespace, Name: pIssuer.Name}, &certmanagerv1.Issuer{})
+	if !kerrors.IsNotFound(err) {
+		t.Errorf("Expected denied issuer to be deleted, got %v", err)
+	}
+	if denied := getClusterIssuer(t, ctx, vClusterIssuer.Name); len(denied.Status.Conditions) != 1 || denied.Status.Conditions[0].Reason != issuers.PolicyDeniedReason {
+		t.Errorf("Expected denied status, got %v", denied.Status)
+	}
+}
+
+func getClusterIssuer(t *testing.T, ctx *context.SyncContext, name string) *certmanagerv1.ClusterIssuer {
+	vClusterIssuer := &certmanagerv1.ClusterIssuer{}
+	err := ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Name: name}, vClusterIssuer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return vClusterIssuer
+}
@@ -0,0 +1,154 @@
+package clusterissuers
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func New(ctx *context.RegisterContext, cfg *config.Config) syncer.Syncer {
+	return &clusterIssuerSyncer{
+		Translator: translator.NewMirrorPhysicalTranslator("clusterissuer", &certmanagerv1.ClusterIssuer{}),
+
+		allowed: cfg.ClusterIssuers.Allowed,
+	}
+}
+
+type clusterIssuerSyncer struct {
+	translator.Translator
+
+	allowed []string
+}
+
+var _ syncer.Initializer = &clusterIssuerSyncer{}
+
+func (s *clusterIssuerSyncer) Init(ctx *context.RegisterContext) error {
+	return translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), certmanagerv1.SchemeGroupVersion.WithKind("ClusterIssuer"))
+}
+
+func (s *clusterIssuerSyncer) IsManaged(pObj client.Object) (bool, error) {
+	return IsAllowed(pObj, s.allowed), nil
+}
+
+func (s *clusterIssuerSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
+	// was cluster issuer mirrored from the host?
+	if !isMirrored(vObj) {
+		return ctrl.Result{}, nil
+	}
+
+	// delete here as cluster issuer is no longer there
+	ctx.Log.Infof("delete virtual cluster issuer %s, because physical got deleted", vObj.GetName())
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+		return ctx.VirtualClient.Delete(ctx.Context, vObj)
+	})
+}
+
+func (s *clusterIssuerSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
+	vClusterIssuer := vObj.(*certmanagerv1.ClusterIssuer)
+	pClusterIssuer := pObj.(*certmanagerv1.ClusterIssuer)
+
+	// cluster issuers created within the vcluster are not touched
+	if !isMirrored(vClusterIssuer) {
+		return ctrl.Result{}, nil
+	}
+
+	// was the cluster issuer removed from the allowed ones?
+	if !IsAllowed(pClusterIssuer, s.allowed) {
+		ctx.Log.Infof("delete virtual cluster issuer %s, because physical is not allowed anymore", vClusterIssuer.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+			return ctx.VirtualClient.Delete(ctx.Context, vClusterIssuer)
+		})
+	}
+
+	if !equality.Semantic.DeepEqual(vClusterIssuer.Status, pClusterIssuer.Status) {
+		newClusterIssuer := vClusterIssuer.DeepCopy()
+		newClusterIssuer.Status = pClusterIssuer.Status
+		ctx.Log.Infof("update virtual cluster issuer %s, because status is out of sync", vClusterIssuer.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newClusterIssuer)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	// mirrored cluster issuers are read-only, so revert any changes
+	updated := s.translateUpdate(pClusterIssuer, vClusterIssuer)
+	if updated != nil {
+		ctx.Log.Infof("update virtual cluster issuer %s, because it is out of sync with the physical one", vClusterIssuer.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Update(ctx.Context, updated)
+		})
+	}
+
+	return ctrl.Result{}, nil
+}
+
+var _ syncer.UpSyncer = &clusterIssuerSyncer{}
+
+func (s *clusterIssuerSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object) (ctrl.Result, error) {
+	pClusterIssuer := pObj.(*certmanagerv1.ClusterIssuer)
+	if !IsAllowed(pClusterIssuer, s.allowed) {
+		return ctrl.Result{}, nil
+	}
+
+	ctx.Log.Infof("create virtual cluster issuer %s, because physical is there and virtual is missing", pClusterIssuer.Name)
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+		return ctx.VirtualClient.Create(ctx.Context, s.translate(pClusterIssuer))
+	})
+}
+
+// IsAllowed returns true if the given host cluster issuer was opted in to be used within
+// the vcluster, either through the allowed list or the constants.ClusterIssuerSyncLabel
+func IsAllowed(pObj client.Object, allowed []string) bool {
+	if pObj.GetLabels() != nil && pObj.GetLabels()[constants.ClusterIssuerSyncLabel] == "true" {
+		return true
+	}
+
+	for _, name := range allowed {
+		if name == pObj.GetName() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsAllowedRef returns true if the given translated issuer reference does not reference a host
+// cluster issuer or the referenced host cluster issuer is allowed to be used within the vcluster
+func IsAllowedRef(ctx context2.Context, physicalClient client.Client, issuerRef cmmeta.ObjectReference, allowed []string) (bool, error) {
+	if issuerRef.Kind != "ClusterIssuer" {
+		return true, nil
+	}
+
+	pClusterIssuer := &certmanagerv1.ClusterIssuer{}
+	err := physicalClient.Get(ctx, types.NamespacedName{Name: issuerRef.Name}, pClusterIssuer)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return IsAllowed(pClusterIssuer, allowed), nil
+}
+
+func isMirrored(vObj client.Object) bool {
+	return vObj.GetAnnotations() != nil && vObj.GetAnnotations()[constants.BackwardSyncAnnotation] == "true"
+}
@@ -0,0 +1,161 @@
+package clusterissuers
+
+import (
+	"context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestIsAllowed(t *testing.T) {
+	testCases := []struct {
+		name    string
+		labels  map[string]string
+		allowed []string
+
+		expected bool
+	}{
+		{
+			name:     "Not opted in",
+			expected: false,
+		},
+		{
+			name:     "Sync label",
+			labels:   map[string]string{constants.ClusterIssuerSyncLabel: "true"},
+			expected: true,
+		},
+		{
+			name:     "Sync label set to false",
+			labels:   map[string]string{constants.ClusterIssuerSyncLabel: "false"},
+			expected: false,
+		},
+		{
+			name:     "Allowed list",
+			allowed:  []string{"other", "letsencrypt-prod"},
+			expected: true,
+		},
+		{
+			name:     "Not part of the allowed list",
+			allowed:  []string{"other"},
+			expected: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		pClusterIssuer := &certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "letsencrypt-prod", Labels: testCase.labels}}
+		allowed := IsAllowed(pClusterIssuer, testCase.allowed)
+		if allowed != testCase.expected {
+			t.Errorf("Test case %s: expected %t, got %t", testCase.name, testCase.expected, allowed)
+		}
+	}
+}
+
+func TestIsAllowedRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+	physicalClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "labeled", Labels: map[string]string{constants.ClusterIssuerSyncLabel: "true"}}},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "listed"}},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "private"}},
+	).Build()
+
+	testCases := []struct {
+		name      string
+		issuerRef cmmeta.ObjectReference
+
+		expected bool
+	}{
+		{
+			name:      "Issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "private"},
+			expected:  true,
+		},
+		{
+			name:      "Labeled cluster issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "labeled", Kind: "ClusterIssuer"},
+			expected:  true,
+		},
+		{
+			name:      "Listed cluster issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "listed", Kind: "ClusterIssuer"},
+			expected:  true,
+		},
+		{
+			name:      "Cluster issuer that is not shared",
+			issuerRef: cmmeta.ObjectReference{Name: "private", Kind: "ClusterIssuer"},
+			expected:  false,
+		},
+		{
+			name:      "Missing cluster issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "missing", Kind: "ClusterIssuer"},
+			expected:  false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		allowed, err := IsAllowedRef(context.TODO(), physicalClient, testCase.issuerRef, []string{"listed"})
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if allowed != testCase.expected {
+			t.Errorf("Test case %s: expected %t, got %t", testCase.name, testCase.expected, allowed)
+		}
+	}
+}
+
+func TestTranslateMirrored(t *testing.T) {
+	s := &clusterIssuerSyncer{Translator: translator.NewMirrorPhysicalTranslator("clusterissuer", &certmanagerv1.ClusterIssuer{})}
+	pClusterIssuer := &certmanagerv1.ClusterIssuer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "letsencrypt-prod",
+			ResourceVersion: "1",
+			Labels:          map[string]string{constants.ClusterIssuerSyncLabel: "true"},
+			Annotations:     map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+		},
+		Spec: certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{CA: &certmanagerv1.CAIssuer{SecretName: "host-ca"}}},
+		Status: certmanagerv1.IssuerStatus{
+			Conditions: []certmanagerv1.IssuerCondition{{Type: certmanagerv1.IssuerConditionReady, Status: cmmeta.ConditionTrue}},
+		},
+	}
+
+	vClusterIssuer := s.translate(pClusterIssuer)
+	if vClusterIssuer.ResourceVersion != "" || len(vClusterIssuer.Status.Conditions) != 0 {
+		t.Errorf("Expected no resource version and status, got %q and %v", vClusterIssuer.ResourceVersion, vClusterIssuer.Status)
+	}
+	if vClusterIssuer.Name != pClusterIssuer.Name || !reflect.DeepEqual(vClusterIssuer.Labels, pClusterIssuer.Labels) {
+		t.Errorf("Expected name %s and labels %v, got %s and %v", pClusterIssuer.Name, pClusterIssuer.Labels, vClusterIssuer.Name, vClusterIssuer.Labels)
+	}
+	expectedAnnotations := map[string]string{constants.BackwardSyncAnnotation: "true"}
+	if !reflect.DeepEqual(vClusterIssuer.Annotations, expectedAnnotations) {
+		t.Errorf("Expected annotations %v, got %v", expectedAnnotations, vClusterIssuer.Annotations)
+	}
+	if !reflect.DeepEqual(vClusterIssuer.Spec, certmanagerv1.IssuerSpec{}) {
+		t.Errorf("Expected the spec of the physical cluster issuer not to be mirrored, got %v", vClusterIssuer.Spec)
+	}
+	if !isMirrored(vClusterIssuer) {
+		t.Errorf("Expected virtual cluster issuer to be mirrored")
+	}
+
+	// the mirrored cluster issuer is in sync
+	if updated := s.translateUpdate(pClusterIssuer, vClusterIssuer); updated != nil {
+		t.Errorf("Expected no update, got %v", updated)
+	}
+
+	// changes within the vcluster are reverted
+	changed := vClusterIssuer.DeepCopy()
+	changed.Labels = map[string]string{"other": "label"}
+	changed.Annotations = map[string]string{}
+	changed.Spec = certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{CA: &certmanagerv1.CAIssuer{SecretName: "ca"}}}
+	updated := s.translateUpdate(pClusterIssuer, changed)
+	if updated == nil {
+		t.Fatalf("Expected changes to be reverted")
+	}
+	if !reflect.DeepEqual(updated.Labels, pClusterIssuer.Labels) || !reflect.DeepEqual(updated.Annotations, expectedAnnotations) || !reflect.DeepEqual(updated.Spec, certmanagerv1.IssuerSpec{}) {
+		t.Errorf("Expected labels and annotations of the physical cluster issuer and an empty spec, got %v, %v and %v", updated.Labels, updated.Annotations, updated.Spec)
+	}
+}
@@ -0,0 +1,57 @@
+package clusterissuers
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// translate returns the mirrored virtual cluster issuer. Only the name, labels and status are mirrored,
+// as the spec and annotations of the host cluster issuer contain secret names, the cluster resource
+// namespace and solvers of the host cluster, which must not be exposed to the vcluster.
+func (s *clusterIssuerSyncer) translate(pObj *certmanagerv1.ClusterIssuer) *certmanagerv1.ClusterIssuer {
+	return &certmanagerv1.ClusterIssuer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pObj.Name,
+			Labels:      pObj.Labels,
+			Annotations: translateAnnotations(),
+		},
+	}
+}
+
+func (s *clusterIssuerSyncer) translateUpdate(pObj, vObj *certmanagerv1.ClusterIssuer) *certmanagerv1.ClusterIssuer {
+	var updated *certmanagerv1.ClusterIssuer
+
+	// check labels
+	if !equality.Semantic.DeepEqual(pObj.Labels, vObj.Labels) {
+		updated = newIfNil(updated, vObj)
+		updated.Labels = pObj.Labels
+	}
+
+	// check annotations
+	newAnnotations := translateAnnotations()
+	if !equality.Semantic.DeepEqual(newAnnotations, vObj.Annotations) {
+		updated = newIfNil(updated, vObj)
+		updated.Annotations = newAnnotations
+	}
+
+	// check spec, the spec of the host cluster issuer is never mirrored
+	if !equality.Semantic.DeepEqual(certmanagerv1.IssuerSpec{}, vObj.Spec) {
+		updated = newIfNil(updated, vObj)
+		updated.Spec = certmanagerv1.IssuerSpec{}
+	}
+
+	return updated
+}
+
+func translateAnnotations() map[string]string {
+	return map[string]string{constants.BackwardSyncAnnotation: "true"}
+}
+
+func newIfNil(updated *certmanagerv1.ClusterIssuer, vObj *certmanagerv1.ClusterIssuer) *certmanagerv1.ClusterIssuer {
+	if updated == nil {
+		return vObj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,179 @@
+package externalissuers
+
+import (
+	"fmt"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"time"
+)
+
+// New creates a syncer for an out-of-tree issuer kind, such as the AWSPCAIssuer. The issuer is
+// handled as unstructured object, only the configured secret references are translated.
+func New(ctx *context.RegisterContext, cfg *config.Config, externalIssuer config.ExternalIssuer) syncer.Syncer {
+	return &externalIssuerSyncer{
+		NamespacedTranslator: translator.NewNamespacedTranslator(ctx, "externalissuer-"+strings.ToLower(externalIssuer.Kind), NewObject(externalIssuer)),
+
+		externalIssuer: externalIssuer,
+		issuerPolicy:   policy.NewIssuerPolicy(cfg),
+		rejections:     admission.NewRejections(),
+	}
+}
+
+type externalIssuerSyncer struct {
+	translator.NamespacedTranslator
+
+	externalIssuer config.ExternalIssuer
+	issuerPolicy   *policy.IssuerPolicy
+	rejections     *admission.Rejections
+}
+
+var _ syncer.Initializer = &externalIssuerSyncer{}
+
+func (s *externalIssuerSyncer) Init(ctx *context.RegisterContext) error {
+	gvk := s.externalIssuer.GroupVersionKind()
+	err := translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), gvk)
+	if err != nil {
+		return err
+	}
+
+	// cluster scoped issuers of the host cluster cannot be translated into the target namespace
+	mapping, err := ctx.PhysicalManager.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return err
+	} else if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("external issuer %s is cluster scoped, only namespaced issuers are supported", gvk.String())
+	}
+
+	return nil
+}
+
+func (s *externalIssuerSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
+	// is the issuer kind allowed by the issuer policy? The status of external issuers is not known to
+	// the plugin, so only an event is recorded.
+	message := s.issuerPolicy.CheckType(s.externalIssuer.Kind)
+	if message != "" {
+		ctx.Log.Infof("skip syncing %s %s/%s, because it is denied by the issuer policy: %s", strings.ToLower(s.externalIssuer.Kind), vObj.GetNamespace(), vObj.GetName(), message)
+		s.EventRecorder().Eventf(vObj, "Warning", issuers.PolicyDeniedReason, message)
+		return ctrl.Result{}, nil
+	}
+
+	// was the issuer refused by the host cluster before?
+	rejection := s.rejections.Get(vObj)
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	pIssuer := s.translate(vObj.(*unstructured.Unstructured))
+	err := metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationCreate, func() error {
+		_, err := s.SyncDownCreate(ctx, vObj, pIssuer)
+		return err
+	})
+	if admission.IsRejected(err) {
+		return s.reject(ctx, vObj, pIssuer, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vObj)
+	}
+
+	return ctrl.Result{}, err
+}
+
+func (s *externalIssuerSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
+	vIssuer := vObj.(*unstructured.Unstructured)
+	pIssuer := pObj.(*unstructured.Unstructured)
+
+	// is the issuer kind still allowed by the issuer policy?
+	message := s.issuerPolicy.CheckType(s.externalIssuer.Kind)
+	if message != "" {
+		ctx.Log.Infof("delete physical %s %s/%s, because it is denied by the issuer policy: %s", strings.ToLower(s.externalIssuer.Kind), pIssuer.GetNamespace(), pIssuer.GetName(), message)
+		s.EventRecorder().Eventf(vIssuer, "Warning", issuers.PolicyDeniedReason, message)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+			return ctx.PhysicalClient.Delete(ctx.Context, pIssuer)
+		})
+	}
+
+	vStatus := s.translateStatus(pIssuer, vIssuer)
+	if !equality.Semantic.DeepEqual(vIssuer.Object["status"], vStatus) {
+		newIssuer := vIssuer.DeepCopy()
+		if vStatus != nil {
+			newIssuer.Object["status"] = vStatus
+		} else {
+			delete(newIssuer.Object, "status")
+		}
+		ctx.Log.Infof("update virtual %s %s/%s, because status is out of sync", strings.ToLower(s.externalIssuer.Kind), vIssuer.GetNamespace(), vIssuer.GetName())
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	// was the issuer refused by the host cluster before?
+	rejection := s.rejections.Get(vIssuer)
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	// did the issuer change?
+	updated := s.translateUpdate(pIssuer, vIssuer)
+	var err error
+	if updated != nil {
+		err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+			_, err := s.SyncDownUpdate(ctx, vObj, updated)
+			return err
+		})
+	}
+	if admission.IsRejected(err) {
+		return s.reject(ctx, vIssuer, updated, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vIssuer)
+	}
+
+	return ctrl.Result{}, err
+}
+
+// reject records a warning event for the virtual issuer, which the host cluster refused. The status of
+// external issuers is not known to the plugin, so no condition is set. The issuer is synced again after
+// an exponential backoff.
+func (s *externalIssuerSyncer) reject(ctx *context.SyncContext, vIssuer client.Object, pIssuer *unstructured.Unstructured, err error) (ctrl.Result, error) {
+	t := status.NewTranslator()
+	t.AddNamespacedName(types.NamespacedName{Namespace: pIssuer.GetNamespace(), Name: pIssuer.GetName()}, types.NamespacedName{Namespace: vIssuer.GetNamespace(), Name: vIssuer.GetName()})
+	message := t.Translate(err.Error())
+	backoff := s.rejections.Reject(vIssuer, message)
+
+	ctx.Log.Infof("skip syncing %s %s/%s, because host cluster refused it: %s", strings.ToLower(s.externalIssuer.Kind), vIssuer.GetNamespace(), vIssuer.GetName(), message)
+	s.EventRecorder().Eventf(vIssuer, "Warning", admission.HostValidationFailedReason, message)
+	return ctrl.Result{RequeueAfter: backoff}, nil
+}
+
+// NewList returns an empty unstructured list of the external issuer kind
+func NewList(externalIssuer config.ExternalIssuer) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(externalIssuer.GroupVersionKind().GroupVersion().WithKind(externalIssuer.Kind + "List"))
+	return list
+}
+
+// NewObject returns an empty unstructured object of the external issuer kind
+func NewObject(externalIssuer config.ExternalIssuer) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(externalIssuer.GroupVersionKind())
+	return obj
+}
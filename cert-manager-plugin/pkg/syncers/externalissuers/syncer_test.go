@@ -0,0 +1,154 @@
+package externalissuers
+
+import (
+	context2 "context"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+// testSyncTranslator additionally creates and updates physical objects like the namespaced translator of the sdk
+type testSyncTranslator struct {
+	testNamespacedTranslator
+
+	eventRecorder *record.FakeRecorder
+}
+
+func (t *testSyncTranslator) Name() string {
+	return "externalissuer-awspcaissuer"
+}
+
+func (t *testSyncTranslator) EventRecorder() record.EventRecorder {
+	return t.eventRecorder
+}
+
+func (t *testSyncTranslator) SyncDownCreate(ctx *context.SyncContext, vObj, pObj client.Object) (ctrl.Result, error) {
+	return ctrl.Result{}, ctx.PhysicalClient.Create(ctx.Context, pObj)
+}
+
+func (t *testSyncTranslator) SyncDownUpdate(ctx *context.SyncContext, vObj, pObj client.Object) (ctrl.Result, error) {
+	if pObj == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, ctx.PhysicalClient.Update(ctx.Context, pObj)
+}
+
+// newTestSyncContext returns an external issuer syncer and a sync context with fake clients
+func newTestSyncContext(t *testing.T, cfg *config.Config, vObjs, pObjs []client.Object) (*externalIssuerSyncer, *context.SyncContext) {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(externalIssuer.GroupVersionKind(), &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(externalIssuer.GroupVersionKind().GroupVersion().WithKind(externalIssuer.Kind+"List"), &unstructured.UnstructuredList{})
+
+	ctx := &context.SyncContext{
+		Context:         context2.TODO(),
+		VirtualClient:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(vObjs...).Build(),
+		PhysicalClient:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(pObjs...).Build(),
+		TargetNamespace: targetNamespace,
+		Log:             log.New(t.Name()),
+	}
+
+	return &externalIssuerSyncer{
+		NamespacedTranslator: &testSyncTranslator{eventRecorder: record.NewFakeRecorder(10)},
+		externalIssuer:       externalIssuer,
+		issuerPolicy:         policy.NewIssuerPolicy(cfg),
+		rejections:           admission.NewRejections(),
+	}, ctx
+}
+
+func TestSyncDown(t *testing.T) {
+	vIssuer := virtualIssuer(map[string]interface{}{"secretRef": map[string]interface{}{"name": "aws-credentials"}}, nil)
+	pName := types.NamespacedName{Namespace: targetNamespace, Name: newTestSyncer().translate(vIssuer).GetName()}
+
+	// denied issuer kinds are not synced
+	s, ctx := newTestSyncContext(t, &config.Config{IssuerPolicy: config.IssuerPolicy{AllowedTypes: []string{"CA"}}}, []client.Object{vIssuer.DeepCopy()}, nil)
+	_, err := s.SyncDown(ctx, vIssuer.DeepCopy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = ctx.PhysicalClient.Get(ctx.Context, pName, NewObject(externalIssuer))
+	if !kerrors.IsNotFound(err) {
+		t.Errorf("Expected denied issuer not to be created, got %v", err)
+	}
+	if len(s.EventRecorder().(*record.FakeRecorder).Events) != 1 {
+		t.Errorf("Expected a warning event for the denied issuer")
+	}
+
+	// allowed issuer kinds are created with translated secret refs
+	s, ctx = newTestSyncContext(t, &config.Config{IssuerPolicy: config.IssuerPolicy{AllowedTypes: []string{"awspcaissuer"}}}, []client.Object{vIssuer.DeepCopy()}, nil)
+	_, err = s.SyncDown(ctx, vIssuer.DeepCopy())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	pIssuer := NewObject(externalIssuer)
+	err = ctx.PhysicalClient.Get(ctx.Context, pName, pIssuer)
+	if err != nil {
+		t.Fatalf("Expected issuer to be created, got %v", err)
+	}
+	if names := SecretNames(pIssuer, externalIssuer.SecretRefs); len(names) != 1 || names[0] == "aws-credentials" {
+		t.Errorf("Expected translated secret ref, got %v", names)
+	}
+}
+
+func TestSync(t *testing.T) {
+	vIssuer := virtualIssuer(map[string]interface{}{"secretRef": map[string]interface{}{"name": "aws-credentials"}}, nil)
+	pIssuer := newTestSyncer().translate(vIssuer)
+	pIssuer.Object["status"] = map[string]interface{}{"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "False", "message": "issuer " + pIssuer.GetName() + " failed"}}}
+
+	// the status is synced with the physical names translated
+	s, ctx := newTestSyncContext(t, &config.Config{}, []client.Object{vIssuer.DeepCopy()}, []client.Object{pIssuer.DeepCopy()})
+	vIssuer, pIssuer = get(t, ctx.VirtualClient, vIssuer), get(t, ctx.PhysicalClient, pIssuer)
+	_, err := s.Sync(ctx, pIssuer, vIssuer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	updated := get(t, ctx.VirtualClient, vIssuer)
+	conditions, _, _ := unstructured.NestedSlice(updated.Object, "status", "conditions")
+	if len(conditions) != 1 || conditions[0].(map[string]interface{})["message"] != "issuer "+issuerName+" failed" {
+		t.Errorf("Expected translated status, got %v", updated.Object["status"])
+	}
+
+	// changes of the spec are synced down
+	vIssuer = updated.DeepCopy()
+	vIssuer.Object["spec"] = map[string]interface{}{"secretRef": map[string]interface{}{"name": "other-credentials"}}
+	_, err = s.Sync(ctx, pIssuer, vIssuer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	updated = get(t, ctx.PhysicalClient, pIssuer)
+	if names := SecretNames(updated, externalIssuer.SecretRefs); len(names) != 1 || names[0] == "other-credentials" {
+		t.Errorf("Expected translated secret ref of the changed spec, got %v", names)
+	}
+
+	// denied issuer kinds are deleted from the host cluster
+	s, ctx = newTestSyncContext(t, &config.Config{IssuerPolicy: config.IssuerPolicy{AllowedTypes: []string{"CA"}}}, nil, []client.Object{updated})
+	_, err = s.Sync(ctx, get(t, ctx.PhysicalClient, pIssuer), vIssuer)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: targetNamespace, Name: pIssuer.GetName()}, NewObject(externalIssuer))
+	if !kerrors.IsNotFound(err) {
+		t.Errorf("Expected denied issuer to be deleted, got %v", err)
+	}
+}
+
+// get returns the current state of the issuer from the given client
+func get(t *testing.T, c client.Client, issuer *unstructured.Unstructured) *unstructured.Unstructured {
+	current := NewObject(externalIssuer)
+	err := c.Get(context2.TODO(), types.NamespacedName{Namespace: issuer.GetNamespace(), Name: issuer.GetName()}, current)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return current
+}
@@ -0,0 +1,109 @@
+package externalissuers
+
+import (
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"strings"
+)
+
+func (s *externalIssuerSyncer) translate(vObj *unstructured.Unstructured) *unstructured.Unstructured {
+	pObj := s.TranslateMetadata(vObj).(*unstructured.Unstructured)
+	s.rewriteSecretRefs(pObj, vObj.GetNamespace())
+	delete(pObj.Object, "status")
+	return pObj
+}
+
+func (s *externalIssuerSyncer) translateUpdate(pObj, vObj *unstructured.Unstructured) *unstructured.Unstructured {
+	var updated *unstructured.Unstructured
+
+	// check annotations & labels
+	changed, updatedAnnotations, updatedLabels := s.TranslateMetadataUpdate(vObj, pObj)
+	if changed {
+		updated = newIfNil(updated, pObj)
+		updated.SetLabels(updatedLabels)
+		updated.SetAnnotations(updatedAnnotations)
+	}
+
+	// update spec
+	translated := vObj.DeepCopy()
+	s.rewriteSecretRefs(translated, vObj.GetNamespace())
+	if !equality.Semantic.DeepEqual(translated.Object["spec"], pObj.Object["spec"]) {
+		updated = newIfNil(updated, pObj)
+		updated.Object["spec"] = translated.Object["spec"]
+	}
+
+	return updated
+}
+
+// translateStatus returns the status of the physical issuer with the physical issuer and secret names
+// replaced by their virtual names. The status of external issuers is not known to the plugin, so all
+// strings within it are translated.
+func (s *externalIssuerSyncer) translateStatus(pObj, vObj *unstructured.Unstructured) interface{} {
+	if pObj.Object["status"] == nil {
+		return nil
+	}
+
+	t := status.NewTranslator()
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.GetNamespace(), Name: pObj.GetName()}, types.NamespacedName{Namespace: vObj.GetNamespace(), Name: vObj.GetName()})
+	for _, name := range SecretNames(vObj, s.externalIssuer.SecretRefs) {
+		t.AddNamespacedName(types.NamespacedName{Namespace: pObj.GetNamespace(), Name: translate.PhysicalName(name, vObj.GetNamespace())}, types.NamespacedName{Namespace: vObj.GetNamespace(), Name: name})
+	}
+
+	return translateValue(runtime.DeepCopyJSONValue(pObj.Object["status"]), t)
+}
+
+// translateValue translates all strings within the given unstructured value
+func translateValue(value interface{}, t *status.Translator) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return t.Translate(typed)
+	case map[string]interface{}:
+		for key := range typed {
+			typed[key] = translateValue(typed[key], t)
+		}
+	case []interface{}:
+		for i := range typed {
+			typed[i] = translateValue(typed[i], t)
+		}
+	}
+
+	return value
+}
+
+// rewriteSecretRefs translates the configured secret references of the issuer, whose secrets are
+// located in the given virtual namespace
+func (s *externalIssuerSyncer) rewriteSecretRefs(obj *unstructured.Unstructured, namespace string) {
+	for _, secretRef := range s.externalIssuer.SecretRefs {
+		fields := strings.Split(secretRef, ".")
+		name, found, err := unstructured.NestedString(obj.Object, fields...)
+		if err != nil || !found || name == "" {
+			continue
+		}
+
+		_ = unstructured.SetNestedField(obj.Object, translate.PhysicalName(name, namespace), fields...)
+	}
+}
+
+// SecretNames returns the names of the secrets referenced by the given external issuer
+func SecretNames(obj *unstructured.Unstructured, secretRefs []string) []string {
+	names := []string{}
+	for _, secretRef := range secretRefs {
+		name, found, err := unstructured.NestedString(obj.Object, strings.Split(secretRef, ".")...)
+		if err == nil && found && name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func newIfNil(updated *unstructured.Unstructured, pObj *unstructured.Unstructured) *unstructured.Unstructured {
+	if updated == nil {
+		return pObj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,146 @@
+package externalissuers
+
+import (
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const (
+	targetNamespace = "vcluster"
+	issuerNamespace = "default"
+	issuerName      = "pca"
+)
+
+var externalIssuer = config.ExternalIssuer{
+	Group:      "awspca.cert-manager.io",
+	Version:    "v1beta1",
+	Kind:       "AWSPCAIssuer",
+	SecretRefs: []string{"spec.secretRef.name", "spec.missing.name"},
+}
+
+// testNamespacedTranslator translates metadata like the namespaced translator of the sdk
+type testNamespacedTranslator struct {
+	translator.NamespacedTranslator
+}
+
+func (t *testNamespacedTranslator) TranslateMetadata(vObj client.Object) client.Object {
+	return translator.TranslateMetadata(targetNamespace, vObj)
+}
+
+func (t *testNamespacedTranslator) TranslateMetadataUpdate(vObj client.Object, pObj client.Object) (bool, map[string]string, map[string]string) {
+	return translator.TranslateMetadataUpdate(vObj, pObj)
+}
+
+func newTestSyncer() *externalIssuerSyncer {
+	return &externalIssuerSyncer{
+		NamespacedTranslator: &testNamespacedTranslator{},
+		externalIssuer:       externalIssuer,
+	}
+}
+
+func virtualIssuer(spec, status map[string]interface{}) *unstructured.Unstructured {
+	vIssuer := NewObject(externalIssuer)
+	vIssuer.SetNamespace(issuerNamespace)
+	vIssuer.SetName(issuerName)
+	vIssuer.Object["spec"] = spec
+	if status != nil {
+		vIssuer.Object["status"] = status
+	}
+	return vIssuer
+}
+
+func TestTranslate(t *testing.T) {
+	s := newTestSyncer()
+	vIssuer := virtualIssuer(map[string]interface{}{
+		"arn":       "arn:aws:acm-pca:eu-central-1:123456789012:certificate-authority/ca",
+		"secretRef": map[string]interface{}{"name": "aws-credentials", "namespace": issuerNamespace},
+	}, map[string]interface{}{"conditions": []interface{}{}})
+
+	pIssuer := s.translate(vIssuer)
+	if pIssuer.GetNamespace() != targetNamespace || pIssuer.GetName() != translate.PhysicalName(issuerName, issuerNamespace) {
+		t.Errorf("Expected physical issuer %s/%s, got %s/%s", targetNamespace, translate.PhysicalName(issuerName, issuerNamespace), pIssuer.GetNamespace(), pIssuer.GetName())
+	}
+	if _, ok := pIssuer.Object["status"]; ok {
+		t.Errorf("Expected no status, got %v", pIssuer.Object["status"])
+	}
+
+	expectedSpec := map[string]interface{}{
+		"arn":       "arn:aws:acm-pca:eu-central-1:123456789012:certificate-authority/ca",
+		"secretRef": map[string]interface{}{"name": translate.PhysicalName("aws-credentials", issuerNamespace), "namespace": issuerNamespace},
+	}
+	if !reflect.DeepEqual(expectedSpec, pIssuer.Object["spec"]) {
+		t.Errorf("Expected spec %v, got %v", expectedSpec, pIssuer.Object["spec"])
+	}
+
+	// the virtual issuer is left untouched
+	if vIssuer.Object["spec"].(map[string]interface{})["secretRef"].(map[string]interface{})["name"] != "aws-credentials" {
+		t.Errorf("Expected virtual secret ref to be unchanged, got %v", vIssuer.Object["spec"])
+	}
+}
+
+func TestTranslateUpdate(t *testing.T) {
+	s := newTestSyncer()
+	vIssuer := virtualIssuer(map[string]interface{}{"secretRef": map[string]interface{}{"name": "aws-credentials"}}, nil)
+	pIssuer := s.translate(vIssuer)
+	if updated := s.translateUpdate(pIssuer, vIssuer); updated != nil {
+		t.Errorf("Expected no update, got %v", updated.Object)
+	}
+
+	vIssuer.Object["spec"] = map[string]interface{}{"secretRef": map[string]interface{}{"name": "other-credentials"}}
+	updated := s.translateUpdate(pIssuer, vIssuer)
+	if updated == nil {
+		t.Fatalf("Expected update of the changed spec")
+	}
+	name, _, _ := unstructured.NestedString(updated.Object, "spec", "secretRef", "name")
+	if name != translate.PhysicalName("other-credentials", issuerNamespace) {
+		t.Errorf("Expected secret ref %s, got %s", translate.PhysicalName("other-credentials", issuerNamespace), name)
+	}
+}
+
+func TestTranslateStatus(t *testing.T) {
+	s := newTestSyncer()
+	vIssuer := virtualIssuer(map[string]interface{}{"secretRef": map[string]interface{}{"name": "aws-credentials"}}, nil)
+	pIssuer := s.translate(vIssuer)
+	pName := pIssuer.GetName()
+	pSecretName := translate.PhysicalName("aws-credentials", issuerNamespace)
+
+	if vStatus := s.translateStatus(pIssuer, vIssuer); vStatus != nil {
+		t.Errorf("Expected no status, got %v", vStatus)
+	}
+
+	pIssuer.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "message": "failed to read secret " + targetNamespace + "/" + pSecretName + " of issuer " + pName},
+		},
+		"observedGeneration": int64(1),
+	}
+	expectedStatus := map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "message": "failed to read secret " + issuerNamespace + "/aws-credentials of issuer " + issuerName},
+		},
+		"observedGeneration": int64(1),
+	}
+	vStatus := s.translateStatus(pIssuer, vIssuer)
+	if !reflect.DeepEqual(expectedStatus, vStatus) {
+		t.Errorf("Expected status %v, got %v", expectedStatus, vStatus)
+	}
+
+	// the physical status is left untouched
+	message := pIssuer.Object["status"].(map[string]interface{})["conditions"].([]interface{})[0].(map[string]interface{})["message"]
+	if message != "failed to read secret "+targetNamespace+"/"+pSecretName+" of issuer "+pName {
+		t.Errorf("Expected physical status to be unchanged, got %v", message)
+	}
+}
+
+func TestSecretNames(t *testing.T) {
+	vIssuer := virtualIssuer(map[string]interface{}{"secretRef": map[string]interface{}{"name": "aws-credentials"}}, nil)
+	names := SecretNames(vIssuer, externalIssuer.SecretRefs)
+	if !reflect.DeepEqual([]string{"aws-credentials"}, names) {
+		t.Errorf("Expected secret names [aws-credentials], got %v", names)
+	}
+}
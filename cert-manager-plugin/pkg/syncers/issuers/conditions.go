@@ -0,0 +1,34 @@
+package issuers
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/conditions"
+)
+
+// NotReadyStatus returns the given status with the Ready condition set to false
+func NotReadyStatus(vObjStatus *certmanagerv1.IssuerStatus, reason, message string, generation int64) *certmanagerv1.IssuerStatus {
+	vObjStatus = vObjStatus.DeepCopy()
+	vObjStatus.Conditions = conditions.Set(vObjStatus.Conditions, notReadyCondition(reason, message, generation))
+	return vObjStatus
+}
+
+// RejectedStatus returns the translated status vStatus of an issuer the host cluster refused. The Ready
+// condition is based on the current status vObjStatus, so that it does not flap between the condition
+// of the physical issuer and the rejection.
+func RejectedStatus(vObjStatus, vStatus *certmanagerv1.IssuerStatus, message string, generation int64) *certmanagerv1.IssuerStatus {
+	vStatus = vStatus.DeepCopy()
+	vStatus.Conditions = conditions.SetFrom(vStatus.Conditions, vObjStatus.Conditions, notReadyCondition(admission.HostValidationFailedReason, message, generation))
+	return vStatus
+}
+
+func notReadyCondition(reason, message string, generation int64) certmanagerv1.IssuerCondition {
+	return certmanagerv1.IssuerCondition{
+		Type:               certmanagerv1.IssuerConditionReady,
+		Status:             cmmeta.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	}
+}
@@ -0,0 +1,16 @@
+package issuers
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+)
+
+const (
+	// PolicyDeniedReason is the reason of the Ready condition of issuers denied by the issuer policy
+	PolicyDeniedReason = "PolicyDenied"
+)
+
+// DeniedStatus returns the status of an issuer that is denied by the issuer policy and therefore
+// not synced to the host cluster
+func DeniedStatus(vObjStatus *certmanagerv1.IssuerStatus, message string, generation int64) *certmanagerv1.IssuerStatus {
+	return NotReadyStatus(vObjStatus, PolicyDeniedReason, message, generation)
+}
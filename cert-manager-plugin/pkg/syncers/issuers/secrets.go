@@ -0,0 +1,153 @@
+package issuers
+
+import (
+	"encoding/json"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"strings"
+)
+
+// SecretNames returns the names of all secrets an issuer spec references and that need to be
+// synced to the host cluster. The acme account private key is not included, as it is created
+// by cert-manager itself.
+func SecretNames(spec *certmanagerv1.IssuerSpec) []string {
+	names := []string{}
+	for _, name := range secretRefs(spec) {
+		if *name != "" {
+			names = append(names, *name)
+		}
+	}
+
+	if spec.ACME != nil {
+		for _, solver := range spec.ACME.Solvers {
+			if solver.DNS01 != nil && solver.DNS01.Webhook != nil {
+				rewriteWebhookConfig(solver.DNS01.Webhook.Config, func(name string) string {
+					names = append(names, name)
+					return name
+				})
+			}
+		}
+	}
+
+	return names
+}
+
+// secretRefs returns pointers to the names of all secrets referenced by the issuer spec,
+// except the acme account private key and secrets within webhook solver configs
+func secretRefs(spec *certmanagerv1.IssuerSpec) []*string {
+	names := []*string{}
+	if spec.ACME != nil {
+		if spec.ACME.ExternalAccountBinding != nil {
+			names = append(names, &spec.ACME.ExternalAccountBinding.Key.Name)
+		}
+		for i := range spec.ACME.Solvers {
+			names = append(names, dns01SecretRefs(spec.ACME.Solvers[i].DNS01)...)
+		}
+	}
+	if spec.CA != nil {
+		names = append(names, &spec.CA.SecretName)
+	}
+	if spec.Vault != nil {
+		if spec.Vault.Auth.TokenSecretRef != nil {
+			names = append(names, &spec.Vault.Auth.TokenSecretRef.Name)
+		}
+		if spec.Vault.Auth.AppRole != nil {
+			names = append(names, &spec.Vault.Auth.AppRole.SecretRef.Name)
+		}
+		if spec.Vault.Auth.Kubernetes != nil {
+			names = append(names, &spec.Vault.Auth.Kubernetes.SecretRef.Name)
+		}
+	}
+	if spec.Venafi != nil && spec.Venafi.TPP != nil {
+		names = append(names, &spec.Venafi.TPP.CredentialsRef.Name)
+	}
+	if spec.Venafi != nil && spec.Venafi.Cloud != nil {
+		names = append(names, &spec.Venafi.Cloud.APITokenSecretRef.Name)
+	}
+	return names
+}
+
+func dns01SecretRefs(dns01 *cmacme.ACMEChallengeSolverDNS01) []*string {
+	if dns01 == nil {
+		return nil
+	}
+
+	names := []*string{}
+	if dns01.Akamai != nil {
+		names = append(names, &dns01.Akamai.ClientToken.Name, &dns01.Akamai.ClientSecret.Name, &dns01.Akamai.AccessToken.Name)
+	}
+	if dns01.CloudDNS != nil && dns01.CloudDNS.ServiceAccount != nil {
+		names = append(names, &dns01.CloudDNS.ServiceAccount.Name)
+	}
+	if dns01.Cloudflare != nil {
+		if dns01.Cloudflare.APIKey != nil {
+			names = append(names, &dns01.Cloudflare.APIKey.Name)
+		}
+		if dns01.Cloudflare.APIToken != nil {
+			names = append(names, &dns01.Cloudflare.APIToken.Name)
+		}
+	}
+	if dns01.Route53 != nil {
+		names = append(names, &dns01.Route53.SecretAccessKey.Name)
+	}
+	if dns01.AzureDNS != nil && dns01.AzureDNS.ClientSecret != nil {
+		names = append(names, &dns01.AzureDNS.ClientSecret.Name)
+	}
+	if dns01.DigitalOcean != nil {
+		names = append(names, &dns01.DigitalOcean.Token.Name)
+	}
+	if dns01.AcmeDNS != nil {
+		names = append(names, &dns01.AcmeDNS.AccountSecret.Name)
+	}
+	if dns01.RFC2136 != nil {
+		names = append(names, &dns01.RFC2136.TSIGSecret.Name)
+	}
+	return names
+}
+
+// rewriteWebhookConfig calls rewrite for every secret name within the free form config of a webhook
+// solver. Webhook solvers by convention reference secrets via objects with a name field whose key
+// ends with SecretRef, e.g. apiKeySecretRef: {name: my-secret, key: api-key}
+func rewriteWebhookConfig(config *apiextensionsv1.JSON, rewrite func(name string) string) *apiextensionsv1.JSON {
+	if config == nil || len(config.Raw) == 0 {
+		return config
+	}
+
+	var obj interface{}
+	err := json.Unmarshal(config.Raw, &obj)
+	if err != nil {
+		return config
+	}
+
+	raw, err := json.Marshal(rewriteSecretRefs(obj, rewrite))
+	if err != nil {
+		return config
+	}
+
+	return &apiextensionsv1.JSON{Raw: raw}
+}
+
+func rewriteSecretRefs(obj interface{}, rewrite func(name string) string) interface{} {
+	switch t := obj.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			ref, ok := v.(map[string]interface{})
+			if ok && strings.HasSuffix(strings.ToLower(k), "secretref") {
+				name, ok := ref["name"].(string)
+				if ok && name != "" {
+					ref["name"] = rewrite(name)
+				}
+				continue
+			}
+
+			t[k] = rewriteSecretRefs(v, rewrite)
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = rewriteSecretRefs(t[i], rewrite)
+		}
+	}
+
+	return obj
+}
@@ -2,6 +2,10 @@ package issuers
 
 import (
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/admission"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
 	"github.com/loft-sh/vcluster-sdk/syncer"
 	"github.com/loft-sh/vcluster-sdk/syncer/context"
 	"github.com/loft-sh/vcluster-sdk/syncer/translator"
@@ -9,16 +13,25 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"time"
 )
 
-func New(ctx *context.RegisterContext) syncer.Syncer {
+func New(ctx *context.RegisterContext, cfg *config.Config) syncer.Syncer {
 	return &issuerSyncer{
 		NamespacedTranslator: translator.NewNamespacedTranslator(ctx, "issuer", &certmanagerv1.Issuer{}),
+
+		ingressClasses: cfg.HTTP01.IngressClasses,
+		issuerPolicy:   policy.NewIssuerPolicy(cfg),
+		rejections:     admission.NewRejections(),
 	}
 }
 
 type issuerSyncer struct {
 	translator.NamespacedTranslator
+
+	ingressClasses map[string]string
+	issuerPolicy   *policy.IssuerPolicy
+	rejections     *admission.Rejections
 }
 
 var _ syncer.Initializer = &issuerSyncer{}
@@ -28,18 +41,64 @@ func (s *issuerSyncer) Init(ctx *context.RegisterContext) error {
 }
 
 func (s *issuerSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
-	return s.SyncDownCreate(ctx, vObj, s.translate(vObj.(*certmanagerv1.Issuer)))
+	vIssuer := vObj.(*certmanagerv1.Issuer)
+
+	// is the issuer allowed by the issuer policy?
+	message := s.issuerPolicy.Check(&vIssuer.Spec)
+	if message != "" {
+		return ctrl.Result{}, s.deny(ctx, vIssuer, message)
+	}
+
+	// was the issuer refused by the host cluster before?
+	rejection := s.rejections.Get(vIssuer)
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	pIssuer := s.translate(vIssuer)
+	err := metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationCreate, func() error {
+		_, err := s.SyncDownCreate(ctx, vObj, pIssuer)
+		return err
+	})
+	if admission.IsRejected(err) {
+		return s.reject(ctx, vIssuer, &vIssuer.Status, pIssuer, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vIssuer)
+	}
+
+	return ctrl.Result{}, err
 }
 
 func (s *issuerSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
 	vIssuer := vObj.(*certmanagerv1.Issuer)
 	pIssuer := pObj.(*certmanagerv1.Issuer)
 
-	if !equality.Semantic.DeepEqual(vIssuer.Status, pIssuer.Status) {
+	// is the issuer still allowed by the issuer policy?
+	message := s.issuerPolicy.Check(&vIssuer.Spec)
+	if message != "" {
+		err := s.deny(ctx, vIssuer, message)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		ctx.Log.Infof("delete physical issuer %s/%s, because it is denied by the issuer policy", pIssuer.Namespace, pIssuer.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+			return ctx.PhysicalClient.Delete(ctx.Context, pIssuer)
+		})
+	}
+
+	vStatus := s.translateStatus(pIssuer, vIssuer)
+	rejection := s.rejections.Get(vIssuer)
+	if rejection != nil {
+		vStatus = RejectedStatus(&vIssuer.Status, vStatus, rejection.Message, vIssuer.Generation)
+	}
+	if !equality.Semantic.DeepEqual(vIssuer.Status, *vStatus) {
 		newIssuer := vIssuer.DeepCopy()
-		newIssuer.Status = pIssuer.Status
+		newIssuer.Status = *vStatus
 		ctx.Log.Infof("update virtual issuer %s/%s, because status is out of sync", vIssuer.Namespace, vIssuer.Name)
-		err := ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+		})
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -48,6 +107,63 @@ func (s *issuerSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj c
 		return ctrl.Result{}, nil
 	}
 
-	// did the certificate change?
-	return s.SyncDownUpdate(ctx, vObj, s.translateUpdate(pIssuer, vIssuer))
+	// was the issuer refused by the host cluster before?
+	if rejection != nil && time.Now().Before(rejection.RetryAt) {
+		return ctrl.Result{RequeueAfter: time.Until(rejection.RetryAt)}, nil
+	}
+
+	// did the issuer change?
+	updated := s.translateUpdate(pIssuer, vIssuer)
+	var err error
+	if updated != nil {
+		err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+			_, err := s.SyncDownUpdate(ctx, vObj, updated)
+			return err
+		})
+	}
+	if admission.IsRejected(err) {
+		return s.reject(ctx, vIssuer, vStatus, updated, err)
+	} else if err == nil && rejection != nil {
+		s.rejections.Forget(vIssuer)
+	}
+
+	return ctrl.Result{}, err
+}
+
+// deny sets the Ready condition of the virtual issuer, which is denied by the issuer policy, to false
+func (s *issuerSyncer) deny(ctx *context.SyncContext, vIssuer *certmanagerv1.Issuer, message string) error {
+	vStatus := DeniedStatus(&vIssuer.Status, message, vIssuer.Generation)
+	if equality.Semantic.DeepEqual(vIssuer.Status, *vStatus) {
+		return nil
+	}
+
+	newIssuer := vIssuer.DeepCopy()
+	newIssuer.Status = *vStatus
+	ctx.Log.Infof("update virtual issuer %s/%s, because it is denied by the issuer policy: %s", vIssuer.Namespace, vIssuer.Name, message)
+	s.EventRecorder().Eventf(vIssuer, "Warning", PolicyDeniedReason, message)
+	return metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+		return ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+	})
+}
+
+// reject sets the Ready condition of the virtual issuer, which the host cluster refused, to false and
+// records a warning event. The issuer is synced again after an exponential backoff.
+func (s *issuerSyncer) reject(ctx *context.SyncContext, vIssuer *certmanagerv1.Issuer, vStatus *certmanagerv1.IssuerStatus, pIssuer *certmanagerv1.Issuer, err error) (ctrl.Result, error) {
+	message := s.statusTranslator(pIssuer, vIssuer).Translate(err.Error())
+	backoff := s.rejections.Reject(vIssuer, message)
+	newStatus := RejectedStatus(&vIssuer.Status, vStatus, message, vIssuer.Generation)
+	if !equality.Semantic.DeepEqual(vIssuer.Status, *newStatus) {
+		newIssuer := vIssuer.DeepCopy()
+		newIssuer.Status = *newStatus
+		ctx.Log.Infof("update virtual issuer %s/%s, because host cluster refused it: %s", vIssuer.Namespace, vIssuer.Name, message)
+		s.EventRecorder().Eventf(vIssuer, "Warning", admission.HostValidationFailedReason, message)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newIssuer)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: backoff}, nil
 }
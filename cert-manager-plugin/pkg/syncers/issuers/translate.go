@@ -1,16 +1,25 @@
 package issuers
 
 import (
+	"context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
 	"github.com/loft-sh/vcluster-sdk/translate"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (s *issuerSyncer) translate(vObj client.Object) *certmanagerv1.Issuer {
 	pObj := s.TranslateMetadata(vObj).(*certmanagerv1.Issuer)
 	vIssuer := vObj.(*certmanagerv1.Issuer)
-	pObj.Spec = *rewriteSpec(&vIssuer.Spec, vIssuer.Namespace)
+	pObj.Spec = *RewriteSpec(&vIssuer.Spec, vIssuer.Namespace, s.ingressClasses)
 	return pObj
 }
 
@@ -26,7 +35,7 @@ func (s *issuerSyncer) translateUpdate(pObj, vObj *certmanagerv1.Issuer) *certma
 	}
 
 	// update secret name if necessary
-	pSpec := rewriteSpec(&vObj.Spec, vObj.GetNamespace())
+	pSpec := RewriteSpec(&vObj.Spec, vObj.GetNamespace(), s.ingressClasses)
 	if !equality.Semantic.DeepEqual(*pSpec, pObj.Spec) {
 		updated = newIfNil(updated, pObj)
 		updated.Spec = *pSpec
@@ -35,25 +44,203 @@ func (s *issuerSyncer) translateUpdate(pObj, vObj *certmanagerv1.Issuer) *certma
 	return updated
 }
 
-func rewriteSpec(vObjSpec *certmanagerv1.IssuerSpec, namespace string) *certmanagerv1.IssuerSpec {
+// translateStatus replaces the physical issuer and secret names within the status of the physical issuer
+func (s *issuerSyncer) translateStatus(pObj, vObj *certmanagerv1.Issuer) *certmanagerv1.IssuerStatus {
+	return TranslateStatus(&pObj.Status, s.statusTranslator(pObj, vObj))
+}
+
+func (s *issuerSyncer) statusTranslator(pObj, vObj *certmanagerv1.Issuer) *status.Translator {
+	t := StatusTranslator(&vObj.Spec, vObj.Namespace, pObj.Namespace)
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Name}, types.NamespacedName{Namespace: vObj.Namespace, Name: vObj.Name})
+	return t
+}
+
+// RewriteSpec translates the secret references and http01 solvers of an issuer spec, whose
+// secrets and ingresses are located in the given virtual namespace
+func RewriteSpec(vObjSpec *certmanagerv1.IssuerSpec, namespace string, ingressClasses map[string]string) *certmanagerv1.IssuerSpec {
 	// translate secret names
 	vObjSpec = vObjSpec.DeepCopy()
 	if vObjSpec.ACME != nil {
 		vObjSpec.ACME.PrivateKey.Name = translate.PhysicalName(vObjSpec.ACME.PrivateKey.Name, namespace)
+		for i := range vObjSpec.ACME.Solvers {
+			if vObjSpec.ACME.Solvers[i].HTTP01 != nil {
+				rewriteHTTP01(vObjSpec.ACME.Solvers[i].HTTP01, namespace, ingressClasses)
+			}
+			if vObjSpec.ACME.Solvers[i].DNS01 != nil && vObjSpec.ACME.Solvers[i].DNS01.Webhook != nil {
+				vObjSpec.ACME.Solvers[i].DNS01.Webhook.Config = rewriteWebhookConfig(vObjSpec.ACME.Solvers[i].DNS01.Webhook.Config, func(name string) string {
+					return translate.PhysicalName(name, namespace)
+				})
+			}
+		}
 	}
-	if vObjSpec.CA != nil {
-		vObjSpec.CA.SecretName = translate.PhysicalName(vObjSpec.CA.SecretName, namespace)
+	for _, name := range secretRefs(vObjSpec) {
+		*name = translate.PhysicalName(*name, namespace)
 	}
-	if vObjSpec.Vault != nil && vObjSpec.Vault.Auth.TokenSecretRef != nil {
-		vObjSpec.Vault.Auth.TokenSecretRef.Name = translate.PhysicalName(vObjSpec.Vault.Auth.TokenSecretRef.Name, namespace)
+	return vObjSpec
+}
+
+// IsSupportedRef returns true if the issuer reference refers to an issuer or cluster issuer of
+// cert-manager or to a configured external issuer. All other issuers live in the host cluster
+// only, so objects of the vcluster must not reference them.
+func IsSupportedRef(issuerRef cmmeta.ObjectReference, externalIssuers config.ExternalIssuers) bool {
+	if issuerRef.Group != "" && issuerRef.Group != certmanagerv1.SchemeGroupVersion.Group {
+		return externalIssuers.Contains(issuerRef.Group, issuerRef.Kind)
 	}
-	if vObjSpec.Venafi != nil && vObjSpec.Venafi.TPP != nil {
-		vObjSpec.Venafi.TPP.CredentialsRef.Name = translate.PhysicalName(vObjSpec.Venafi.TPP.CredentialsRef.Name, namespace)
+
+	return issuerRef.Kind == "" || issuerRef.Kind == "Issuer" || issuerRef.Kind == "ClusterIssuer"
+}
+
+// TranslateIssuerRef translates the issuer reference of an object within the given virtual namespace
+// to the issuer that should be referenced in the host cluster. References that are not supported,
+// see IsSupportedRef, are returned unchanged and must not be synced.
+func TranslateIssuerRef(ctx context.Context, virtualClient client.Client, issuerRef cmmeta.ObjectReference, namespace, targetNamespace string, externalIssuers config.ExternalIssuers) cmmeta.ObjectReference {
+	if issuerRef.Group != "" && issuerRef.Group != certmanagerv1.SchemeGroupVersion.Group {
+		// external issuers are synced like issuers
+		if externalIssuers.Contains(issuerRef.Group, issuerRef.Kind) {
+			issuerRef.Name = translate.PhysicalName(issuerRef.Name, namespace)
+		}
+
+		return issuerRef
 	}
-	if vObjSpec.Venafi != nil && vObjSpec.Venafi.Cloud != nil {
-		vObjSpec.Venafi.Cloud.APITokenSecretRef.Name = translate.PhysicalName(vObjSpec.Venafi.Cloud.APITokenSecretRef.Name, namespace)
+
+	switch issuerRef.Kind {
+	case "", "Issuer":
+		issuerRef.Name = translate.PhysicalName(issuerRef.Name, namespace)
+	case "ClusterIssuer":
+		// cluster issuers created within the vcluster are translated to an issuer in the host namespace,
+		// while cluster issuers mirrored from the host cluster are referenced directly
+		vClusterIssuer := &certmanagerv1.ClusterIssuer{}
+		err := virtualClient.Get(ctx, types.NamespacedName{Name: issuerRef.Name}, vClusterIssuer)
+		if err == nil && (vClusterIssuer.Annotations == nil || vClusterIssuer.Annotations[constants.BackwardSyncAnnotation] != "true") {
+			issuerRef.Kind = "Issuer"
+			issuerRef.Name = translate.PhysicalNameClusterScoped(issuerRef.Name, targetNamespace)
+		}
 	}
-	return vObjSpec
+
+	return issuerRef
+}
+
+// TranslateIngressNameOverride returns the physical annotations of a certificate or certificate request with
+// the http01 ingress name override translated to the physical ingress within the namespace of the virtual
+// object. Ingress names of http01 solvers of cluster issuers created within the vcluster refer to an ingress
+// within the namespace of each certificate, so they are set through the annotation as well.
+func TranslateIngressNameOverride(ctx context.Context, virtualClient client.Client, vObj client.Object, pAnnotations map[string]string, issuerRef cmmeta.ObjectReference) map[string]string {
+	ingressName := ""
+	if vObj.GetAnnotations() != nil {
+		ingressName = vObj.GetAnnotations()[cmacme.ACMECertificateHTTP01IngressNameOverride]
+	}
+	if ingressName == "" {
+		ingressName = clusterIssuerIngressName(ctx, virtualClient, issuerRef)
+	}
+	if ingressName != "" {
+		ingressName = translate.PhysicalName(ingressName, vObj.GetNamespace())
+	}
+
+	// keep the annotations untouched, if the override is already up to date
+	if pAnnotations[cmacme.ACMECertificateHTTP01IngressNameOverride] == ingressName {
+		return pAnnotations
+	}
+
+	newAnnotations := map[string]string{}
+	for k, v := range pAnnotations {
+		newAnnotations[k] = v
+	}
+	if ingressName != "" {
+		newAnnotations[cmacme.ACMECertificateHTTP01IngressNameOverride] = ingressName
+	} else {
+		delete(newAnnotations, cmacme.ACMECertificateHTTP01IngressNameOverride)
+	}
+	return newAnnotations
+}
+
+// clusterIssuerIngressName returns the http01 ingress name of the referenced cluster issuer, if it was
+// created within the vcluster
+func clusterIssuerIngressName(ctx context.Context, virtualClient client.Client, issuerRef cmmeta.ObjectReference) string {
+	if issuerRef.Kind != "ClusterIssuer" || (issuerRef.Group != "" && issuerRef.Group != certmanagerv1.SchemeGroupVersion.Group) {
+		return ""
+	}
+
+	vClusterIssuer := &certmanagerv1.ClusterIssuer{}
+	err := virtualClient.Get(ctx, types.NamespacedName{Name: issuerRef.Name}, vClusterIssuer)
+	if err != nil || (vClusterIssuer.Annotations != nil && vClusterIssuer.Annotations[constants.BackwardSyncAnnotation] == "true") {
+		return ""
+	}
+
+	return HTTP01IngressName(&vClusterIssuer.Spec)
+}
+
+// HTTP01IngressName returns the name of the existing ingress the http01 solvers of the issuer spec use.
+// The annotation cert-manager provides to override the ingress name applies to all solvers, so the first
+// name is returned.
+func HTTP01IngressName(spec *certmanagerv1.IssuerSpec) string {
+	if spec.ACME == nil {
+		return ""
+	}
+
+	for _, solver := range spec.ACME.Solvers {
+		if solver.HTTP01 != nil && solver.HTTP01.Ingress != nil && solver.HTTP01.Ingress.Name != "" {
+			return solver.HTTP01.Ingress.Name
+		}
+	}
+
+	return ""
+}
+
+// StatusTranslator returns a status translator that replaces the physical names of all secrets the
+// issuer spec references within the given virtual namespace
+func StatusTranslator(vObjSpec *certmanagerv1.IssuerSpec, namespace, targetNamespace string) *status.Translator {
+	t := status.NewTranslator()
+	names := SecretNames(vObjSpec)
+	if vObjSpec.ACME != nil && vObjSpec.ACME.PrivateKey.Name != "" {
+		names = append(names, vObjSpec.ACME.PrivateKey.Name)
+	}
+	for _, name := range names {
+		t.AddNamespacedName(types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName(name, namespace)}, types.NamespacedName{Namespace: namespace, Name: name})
+	}
+
+	return t
+}
+
+// TranslateStatus replaces the physical names within the conditions of the issuer status
+func TranslateStatus(pObjStatus *certmanagerv1.IssuerStatus, t *status.Translator) *certmanagerv1.IssuerStatus {
+	vObjStatus := pObjStatus.DeepCopy()
+	for i := range vObjStatus.Conditions {
+		vObjStatus.Conditions[i].Message = t.Translate(vObjStatus.Conditions[i].Message)
+	}
+
+	return vObjStatus
+}
+
+// rewriteHTTP01 translates the ingress that should be edited to solve the challenge, maps the
+// ingress class to a host ingress class and translates the labels of the generated resources
+func rewriteHTTP01(http01 *cmacme.ACMEChallengeSolverHTTP01, namespace string, ingressClasses map[string]string) {
+	if http01.Ingress != nil {
+		http01.Ingress.Name = translate.PhysicalName(http01.Ingress.Name, namespace)
+		if http01.Ingress.Class != nil && ingressClasses[*http01.Ingress.Class] != "" {
+			http01.Ingress.Class = pointer.String(ingressClasses[*http01.Ingress.Class])
+		}
+		if http01.Ingress.PodTemplate != nil {
+			http01.Ingress.PodTemplate.Labels = translateLabels(http01.Ingress.PodTemplate.Labels)
+		}
+		if http01.Ingress.IngressTemplate != nil {
+			http01.Ingress.IngressTemplate.Labels = translateLabels(http01.Ingress.IngressTemplate.Labels)
+		}
+	}
+	if http01.GatewayHTTPRoute != nil {
+		http01.GatewayHTTPRoute.Labels = translateLabels(http01.GatewayHTTPRoute.Labels)
+	}
+}
+
+func translateLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+
+	newLabels := map[string]string{}
+	for k, v := range labels {
+		newLabels[translator.ConvertLabelKey(k)] = v
+	}
+	return newLabels
 }
 
 func newIfNil(updated *certmanagerv1.Issuer, pObj *certmanagerv1.Issuer) *certmanagerv1.Issuer {
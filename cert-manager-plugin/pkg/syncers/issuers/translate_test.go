@@ -0,0 +1,464 @@
+package issuers
+
+import (
+	"context"
+	"encoding/json"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sort"
+	"testing"
+)
+
+const (
+	namespace       = "test"
+	targetNamespace = "vcluster"
+)
+
+func secretKeySelector(name string) cmmeta.SecretKeySelector {
+	return cmmeta.SecretKeySelector{LocalObjectReference: cmmeta.LocalObjectReference{Name: name}, Key: "key"}
+}
+
+func secretKeySelectorRef(name string) *cmmeta.SecretKeySelector {
+	ref := secretKeySelector(name)
+	return &ref
+}
+
+func acmeSpec(dns01 *cmacme.ACMEChallengeSolverDNS01) *certmanagerv1.IssuerSpec {
+	return &certmanagerv1.IssuerSpec{
+		IssuerConfig: certmanagerv1.IssuerConfig{
+			ACME: &cmacme.ACMEIssuer{
+				PrivateKey: secretKeySelector("account-key"),
+				Solvers:    []cmacme.ACMEChallengeSolver{{DNS01: dns01}},
+			},
+		},
+	}
+}
+
+func TestRewriteSpec(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec *certmanagerv1.IssuerSpec
+
+		// names returns pointers to all referenced secret names of the spec
+		names func(spec *certmanagerv1.IssuerSpec) []*string
+	}{
+		{
+			name: "ACME private key",
+			spec: acmeSpec(nil),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{}
+			},
+		},
+		{
+			name: "ACME external account binding",
+			spec: &certmanagerv1.IssuerSpec{
+				IssuerConfig: certmanagerv1.IssuerConfig{
+					ACME: &cmacme.ACMEIssuer{
+						PrivateKey:             secretKeySelector("account-key"),
+						ExternalAccountBinding: &cmacme.ACMEExternalAccountBinding{KeyID: "id", Key: secretKeySelector("eab")},
+					},
+				},
+			},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.ExternalAccountBinding.Key.Name}
+			},
+		},
+		{
+			name: "DNS01 Akamai",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{Akamai: &cmacme.ACMEIssuerDNS01ProviderAkamai{
+				ClientToken:  secretKeySelector("client-token"),
+				ClientSecret: secretKeySelector("client-secret"),
+				AccessToken:  secretKeySelector("access-token"),
+			}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				akamai := spec.ACME.Solvers[0].DNS01.Akamai
+				return []*string{&akamai.ClientToken.Name, &akamai.ClientSecret.Name, &akamai.AccessToken.Name}
+			},
+		},
+		{
+			name: "DNS01 CloudDNS",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{CloudDNS: &cmacme.ACMEIssuerDNS01ProviderCloudDNS{ServiceAccount: secretKeySelectorRef("clouddns")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.CloudDNS.ServiceAccount.Name}
+			},
+		},
+		{
+			name: "DNS01 Cloudflare",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{Cloudflare: &cmacme.ACMEIssuerDNS01ProviderCloudflare{APIKey: secretKeySelectorRef("api-key"), APIToken: secretKeySelectorRef("api-token")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.Cloudflare.APIKey.Name, &spec.ACME.Solvers[0].DNS01.Cloudflare.APIToken.Name}
+			},
+		},
+		{
+			name: "DNS01 Route53",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{Route53: &cmacme.ACMEIssuerDNS01ProviderRoute53{Region: "eu-central-1", SecretAccessKey: secretKeySelector("route53")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.Route53.SecretAccessKey.Name}
+			},
+		},
+		{
+			name: "DNS01 Route53 ambient credentials",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{Route53: &cmacme.ACMEIssuerDNS01ProviderRoute53{Region: "eu-central-1"}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{}
+			},
+		},
+		{
+			name: "DNS01 AzureDNS",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{AzureDNS: &cmacme.ACMEIssuerDNS01ProviderAzureDNS{ClientSecret: secretKeySelectorRef("azuredns")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.AzureDNS.ClientSecret.Name}
+			},
+		},
+		{
+			name: "DNS01 DigitalOcean",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{DigitalOcean: &cmacme.ACMEIssuerDNS01ProviderDigitalOcean{Token: secretKeySelector("digitalocean")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.DigitalOcean.Token.Name}
+			},
+		},
+		{
+			name: "DNS01 AcmeDNS",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{AcmeDNS: &cmacme.ACMEIssuerDNS01ProviderAcmeDNS{AccountSecret: secretKeySelector("acmedns")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.AcmeDNS.AccountSecret.Name}
+			},
+		},
+		{
+			name: "DNS01 RFC2136",
+			spec: acmeSpec(&cmacme.ACMEChallengeSolverDNS01{RFC2136: &cmacme.ACMEIssuerDNS01ProviderRFC2136{Nameserver: "1.2.3.4", TSIGSecret: secretKeySelector("tsig")}}),
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.ACME.Solvers[0].DNS01.RFC2136.TSIGSecret.Name}
+			},
+		},
+		{
+			name: "CA",
+			spec: &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{CA: &certmanagerv1.CAIssuer{SecretName: "ca"}}},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.CA.SecretName}
+			},
+		},
+		{
+			name: "Vault token",
+			spec: &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{Vault: &certmanagerv1.VaultIssuer{Auth: certmanagerv1.VaultAuth{TokenSecretRef: secretKeySelectorRef("vault-token")}}}},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.Vault.Auth.TokenSecretRef.Name}
+			},
+		},
+		{
+			name: "Vault AppRole",
+			spec: &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{Vault: &certmanagerv1.VaultIssuer{Auth: certmanagerv1.VaultAuth{AppRole: &certmanagerv1.VaultAppRole{RoleId: "role", SecretRef: secretKeySelector("vault-approle")}}}}},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.Vault.Auth.AppRole.SecretRef.Name}
+			},
+		},
+		{
+			name: "Vault Kubernetes",
+			spec: &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{Vault: &certmanagerv1.VaultIssuer{Auth: certmanagerv1.VaultAuth{Kubernetes: &certmanagerv1.VaultKubernetesAuth{Role: "role", SecretRef: secretKeySelector("vault-sa")}}}}},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.Vault.Auth.Kubernetes.SecretRef.Name}
+			},
+		},
+		{
+			name: "Venafi TPP",
+			spec: &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{Venafi: &certmanagerv1.VenafiIssuer{TPP: &certmanagerv1.VenafiTPP{CredentialsRef: cmmeta.LocalObjectReference{Name: "tpp"}}}}},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.Venafi.TPP.CredentialsRef.Name}
+			},
+		},
+		{
+			name: "Venafi Cloud",
+			spec: &certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{Venafi: &certmanagerv1.VenafiIssuer{Cloud: &certmanagerv1.VenafiCloud{APITokenSecretRef: secretKeySelector("cloud")}}}},
+			names: func(spec *certmanagerv1.IssuerSpec) []*string {
+				return []*string{&spec.Venafi.Cloud.APITokenSecretRef.Name}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		pSpec := RewriteSpec(testCase.spec, namespace, nil)
+
+		// check that all secret names are translated
+		vNames := testCase.names(testCase.spec)
+		pNames := testCase.names(pSpec)
+		for i := range vNames {
+			expected := translate.PhysicalName(*vNames[i], namespace)
+			if *pNames[i] != expected {
+				t.Errorf("Test case %s: expected secret name %s, got %s", testCase.name, expected, *pNames[i])
+			}
+		}
+		if pSpec.ACME != nil && pSpec.ACME.PrivateKey.Name != translate.PhysicalName(testCase.spec.ACME.PrivateKey.Name, namespace) {
+			t.Errorf("Test case %s: expected private key secret name %s, got %s", testCase.name, translate.PhysicalName(testCase.spec.ACME.PrivateKey.Name, namespace), pSpec.ACME.PrivateKey.Name)
+		}
+
+		// check that the same secrets are synced
+		expectedSecretNames := []string{}
+		for _, name := range vNames {
+			expectedSecretNames = append(expectedSecretNames, *name)
+		}
+		secretNames := SecretNames(testCase.spec)
+		sort.Strings(expectedSecretNames)
+		sort.Strings(secretNames)
+		if !reflect.DeepEqual(expectedSecretNames, secretNames) {
+			t.Errorf("Test case %s: expected secret names %v, got %v", testCase.name, expectedSecretNames, secretNames)
+		}
+	}
+}
+
+func TestRewriteSpecWebhook(t *testing.T) {
+	spec := acmeSpec(&cmacme.ACMEChallengeSolverDNS01{Webhook: &cmacme.ACMEIssuerDNS01ProviderWebhook{
+		GroupName:  "acme.example.com",
+		SolverName: "example",
+		Config:     &apiextensionsv1.JSON{Raw: []byte(`{"apiKeySecretRef":{"name":"webhook","key":"api-key"},"zones":[{"tokenSecretRef":{"name":"zone"}}],"name":"unchanged"}`)},
+	}})
+
+	pSpec := RewriteSpec(spec, namespace, nil)
+	config := map[string]interface{}{}
+	err := json.Unmarshal(pSpec.ACME.Solvers[0].DNS01.Webhook.Config.Raw, &config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"apiKeySecretRef": map[string]interface{}{"name": translate.PhysicalName("webhook", namespace), "key": "api-key"},
+		"zones":           []interface{}{map[string]interface{}{"tokenSecretRef": map[string]interface{}{"name": translate.PhysicalName("zone", namespace)}}},
+		"name":            "unchanged",
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Errorf("Expected webhook config %v, got %v", expected, config)
+	}
+
+	secretNames := SecretNames(spec)
+	sort.Strings(secretNames)
+	if !reflect.DeepEqual([]string{"webhook", "zone"}, secretNames) {
+		t.Errorf("Expected secret names [webhook zone], got %v", secretNames)
+	}
+}
+
+func TestRewriteSpecHTTP01(t *testing.T) {
+	spec := &certmanagerv1.IssuerSpec{
+		IssuerConfig: certmanagerv1.IssuerConfig{
+			ACME: &cmacme.ACMEIssuer{
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						Name:            "my-ingress",
+						Class:           pointer.String("nginx"),
+						PodTemplate:     &cmacme.ACMEChallengeSolverHTTP01IngressPodTemplate{ACMEChallengeSolverHTTP01IngressPodObjectMeta: cmacme.ACMEChallengeSolverHTTP01IngressPodObjectMeta{Labels: map[string]string{"app": "solver"}}},
+						IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{ACMEChallengeSolverHTTP01IngressObjectMeta: cmacme.ACMEChallengeSolverHTTP01IngressObjectMeta{Labels: map[string]string{"app": "solver"}}},
+					}}},
+					{HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Class: pointer.String("traefik")}}},
+				},
+			},
+		},
+	}
+
+	pSpec := RewriteSpec(spec, namespace, map[string]string{"nginx": "host-nginx"})
+	ingress := pSpec.ACME.Solvers[0].HTTP01.Ingress
+	if ingress.Name != translate.PhysicalName("my-ingress", namespace) {
+		t.Errorf("Expected ingress name %s, got %s", translate.PhysicalName("my-ingress", namespace), ingress.Name)
+	}
+	if *ingress.Class != "host-nginx" {
+		t.Errorf("Expected ingress class host-nginx, got %s", *ingress.Class)
+	}
+	expectedLabels := map[string]string{translator.ConvertLabelKey("app"): "solver"}
+	if !reflect.DeepEqual(expectedLabels, ingress.PodTemplate.Labels) {
+		t.Errorf("Expected pod template labels %v, got %v", expectedLabels, ingress.PodTemplate.Labels)
+	}
+	if !reflect.DeepEqual(expectedLabels, ingress.IngressTemplate.Labels) {
+		t.Errorf("Expected ingress template labels %v, got %v", expectedLabels, ingress.IngressTemplate.Labels)
+	}
+
+	// unmapped ingress classes are used as is
+	if *pSpec.ACME.Solvers[1].HTTP01.Ingress.Class != "traefik" {
+		t.Errorf("Expected ingress class traefik, got %s", *pSpec.ACME.Solvers[1].HTTP01.Ingress.Class)
+	}
+	if pSpec.ACME.Solvers[1].HTTP01.Ingress.Name != "" {
+		t.Errorf("Expected empty ingress name, got %s", pSpec.ACME.Solvers[1].HTTP01.Ingress.Name)
+	}
+}
+
+func TestTranslateIssuerRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+	virtualClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "virtual"}},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Annotations: map[string]string{constants.BackwardSyncAnnotation: "true"}}},
+	).Build()
+	externalIssuers := config.ExternalIssuers{{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"}}
+
+	testCases := []struct {
+		name      string
+		issuerRef cmmeta.ObjectReference
+
+		expectedIssuerRef cmmeta.ObjectReference
+	}{
+		{
+			name:              "Issuer",
+			issuerRef:         cmmeta.ObjectReference{Name: "issuer"},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("issuer", namespace)},
+		},
+		{
+			name:              "Issuer of cert-manager group",
+			issuerRef:         cmmeta.ObjectReference{Name: "issuer", Kind: "Issuer", Group: certmanagerv1.SchemeGroupVersion.Group},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("issuer", namespace), Kind: "Issuer", Group: certmanagerv1.SchemeGroupVersion.Group},
+		},
+		{
+			name:              "Cluster issuer of the vcluster",
+			issuerRef:         cmmeta.ObjectReference{Name: "virtual", Kind: "ClusterIssuer"},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalNameClusterScoped("virtual", targetNamespace), Kind: "Issuer"},
+		},
+		{
+			name:              "Cluster issuer mirrored from the host",
+			issuerRef:         cmmeta.ObjectReference{Name: "mirrored", Kind: "ClusterIssuer"},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: "mirrored", Kind: "ClusterIssuer"},
+		},
+		{
+			name:              "External issuer",
+			issuerRef:         cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAIssuer", Group: "awspca.cert-manager.io"},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("pca", namespace), Kind: "AWSPCAIssuer", Group: "awspca.cert-manager.io"},
+		},
+		{
+			name:              "External cluster issuer",
+			issuerRef:         cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"},
+		},
+		{
+			name:              "External issuer of unknown group",
+			issuerRef:         cmmeta.ObjectReference{Name: "step", Kind: "StepIssuer", Group: "certmanager.step.sm"},
+			expectedIssuerRef: cmmeta.ObjectReference{Name: "step", Kind: "StepIssuer", Group: "certmanager.step.sm"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		issuerRef := TranslateIssuerRef(context.TODO(), virtualClient, testCase.issuerRef, namespace, targetNamespace, externalIssuers)
+		if !reflect.DeepEqual(issuerRef, testCase.expectedIssuerRef) {
+			t.Errorf("Test case %s: expected issuer ref %#+v, got %#+v", testCase.name, testCase.expectedIssuerRef, issuerRef)
+		}
+	}
+}
+
+func TestIsSupportedRef(t *testing.T) {
+	externalIssuers := config.ExternalIssuers{{Group: "awspca.cert-manager.io", Version: "v1beta1", Kind: "AWSPCAIssuer"}}
+
+	testCases := []struct {
+		name      string
+		issuerRef cmmeta.ObjectReference
+
+		expected bool
+	}{
+		{
+			name:      "Issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "issuer"},
+			expected:  true,
+		},
+		{
+			name:      "Cluster issuer of cert-manager group",
+			issuerRef: cmmeta.ObjectReference{Name: "issuer", Kind: "ClusterIssuer", Group: certmanagerv1.SchemeGroupVersion.Group},
+			expected:  true,
+		},
+		{
+			name:      "Unknown kind of cert-manager group",
+			issuerRef: cmmeta.ObjectReference{Name: "issuer", Kind: "Certificate"},
+		},
+		{
+			name:      "External issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAIssuer", Group: "awspca.cert-manager.io"},
+			expected:  true,
+		},
+		{
+			name:      "External cluster issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "pca", Kind: "AWSPCAClusterIssuer", Group: "awspca.cert-manager.io"},
+		},
+		{
+			name:      "External issuer of unknown group",
+			issuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("step", "team-b"), Kind: "StepIssuer", Group: "certmanager.step.sm"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		supported := IsSupportedRef(testCase.issuerRef, externalIssuers)
+		if supported != testCase.expected {
+			t.Errorf("Test case %s: expected %t, got %t", testCase.name, testCase.expected, supported)
+		}
+	}
+}
+
+func TestTranslateIngressNameOverride(t *testing.T) {
+	http01Spec := func(ingressName string) certmanagerv1.IssuerSpec {
+		return certmanagerv1.IssuerSpec{IssuerConfig: certmanagerv1.IssuerConfig{ACME: &cmacme.ACMEIssuer{
+			Solvers: []cmacme.ACMEChallengeSolver{
+				{DNS01: &cmacme.ACMEChallengeSolverDNS01{}},
+				{HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Name: ingressName}}},
+			},
+		}}}
+	}
+
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+	virtualClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "virtual"}, Spec: http01Spec("web")},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "without-name"}, Spec: http01Spec("")},
+		&certmanagerv1.ClusterIssuer{ObjectMeta: metav1.ObjectMeta{Name: "mirrored", Annotations: map[string]string{constants.BackwardSyncAnnotation: "true"}}, Spec: http01Spec("web")},
+	).Build()
+
+	testCases := []struct {
+		name         string
+		vAnnotations map[string]string
+		pAnnotations map[string]string
+		issuerRef    cmmeta.ObjectReference
+
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:                "Issuer without override",
+			pAnnotations:        map[string]string{"a": "b"},
+			issuerRef:           cmmeta.ObjectReference{Name: "issuer"},
+			expectedAnnotations: map[string]string{"a": "b"},
+		},
+		{
+			name:                "Override of the virtual object",
+			vAnnotations:        map[string]string{cmacme.ACMECertificateHTTP01IngressNameOverride: "other"},
+			pAnnotations:        map[string]string{cmacme.ACMECertificateHTTP01IngressNameOverride: "other"},
+			issuerRef:           cmmeta.ObjectReference{Name: "virtual", Kind: "ClusterIssuer"},
+			expectedAnnotations: map[string]string{cmacme.ACMECertificateHTTP01IngressNameOverride: translate.PhysicalName("other", namespace)},
+		},
+		{
+			name:                "Cluster issuer of the vcluster",
+			issuerRef:           cmmeta.ObjectReference{Name: "virtual", Kind: "ClusterIssuer"},
+			expectedAnnotations: map[string]string{cmacme.ACMECertificateHTTP01IngressNameOverride: translate.PhysicalName("web", namespace)},
+		},
+		{
+			name:                "Cluster issuer without ingress name",
+			pAnnotations:        map[string]string{cmacme.ACMECertificateHTTP01IngressNameOverride: translate.PhysicalName("web", namespace)},
+			issuerRef:           cmmeta.ObjectReference{Name: "without-name", Kind: "ClusterIssuer"},
+			expectedAnnotations: map[string]string{},
+		},
+		{
+			name:      "Cluster issuer mirrored from the host",
+			issuerRef: cmmeta.ObjectReference{Name: "mirrored", Kind: "ClusterIssuer"},
+		},
+		{
+			name:      "Missing cluster issuer",
+			issuerRef: cmmeta.ObjectReference{Name: "missing", Kind: "ClusterIssuer"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		vObj := &certmanagerv1.Certificate{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "certificate", Annotations: testCase.vAnnotations}}
+		annotations := TranslateIngressNameOverride(context.TODO(), virtualClient, vObj, testCase.pAnnotations, testCase.issuerRef)
+		if !reflect.DeepEqual(annotations, testCase.expectedAnnotations) {
+			t.Errorf("Test case %s: expected annotations %v, got %v", testCase.name, testCase.expectedAnnotations, annotations)
+		}
+	}
+}
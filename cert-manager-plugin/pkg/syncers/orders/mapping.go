@@ -0,0 +1,155 @@
+package orders
+
+import (
+	context2 "context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/clienthelper"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+var (
+	IndexByCertificateRequest = "indexbycertificaterequest"
+)
+
+var _ syncer.IndicesRegisterer = &orderSyncer{}
+
+func (s *orderSyncer) RegisterIndices(ctx *context.RegisterContext) error {
+	return ctx.PhysicalManager.GetFieldIndexer().IndexField(ctx.Context, &cmacme.Order{}, IndexByCertificateRequest, func(rawObj client.Object) []string {
+		name := certificateRequestSuffix(rawObj)
+		if name == "" {
+			return nil
+		}
+
+		return []string{name}
+	})
+}
+
+func (s *orderSyncer) IsManaged(pObj client.Object) (bool, error) {
+	// only orders created for certificate requests of the vcluster are managed
+	return s.PhysicalToVirtual(pObj).Name != "", nil
+}
+
+// VirtualToPhysical translates the virtual order name, which is <certificate request>-<hash>,
+// to the physical order that was created by cert-manager for the physical certificate request
+func (s *orderSyncer) VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName {
+	idx := strings.LastIndex(req.Name, "-")
+	if idx <= 0 {
+		return types.NamespacedName{}
+	}
+
+	vCertificateRequest := &certmanagerv1.CertificateRequest{}
+	err := s.virtualClient.Get(context2.TODO(), types.NamespacedName{Namespace: req.Namespace, Name: req.Name[:idx]}, vCertificateRequest)
+	if err != nil {
+		return types.NamespacedName{}
+	}
+
+	pCertificateRequestName := s.certificateRequests.VirtualToPhysical(types.NamespacedName{Namespace: req.Namespace, Name: req.Name[:idx]}, vCertificateRequest)
+	if pCertificateRequestName.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	pOrder := &cmacme.Order{}
+	err = clienthelper.GetByIndex(context2.TODO(), s.physicalClient, pOrder, IndexByCertificateRequest, pCertificateRequestName.Name+"/"+req.Name[idx+1:])
+	if err != nil || pOrder.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	return types.NamespacedName{
+		Namespace: pOrder.Namespace,
+		Name:      pOrder.Name,
+	}
+}
+
+func (s *orderSyncer) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
+	if pObj.GetNamespace() != s.targetNamespace {
+		return types.NamespacedName{}
+	}
+
+	pCertificateRequest := s.physicalCertificateRequest(pObj)
+	if pCertificateRequest == nil {
+		return types.NamespacedName{}
+	}
+
+	vCertificateRequestName := s.certificateRequests.PhysicalToVirtual(pCertificateRequest)
+	if vCertificateRequestName.Name == "" {
+		return types.NamespacedName{}
+	}
+
+	return types.NamespacedName{
+		Namespace: vCertificateRequestName.Namespace,
+		Name:      vCertificateRequestName.Name + "-" + nameSuffix(pObj.GetName()),
+	}
+}
+
+// certificateRequestByOrder returns the virtual certificate request the physical order was
+// created for by cert-manager in the host cluster
+func (s *orderSyncer) certificateRequestByOrder(pObj client.Object) *certmanagerv1.CertificateRequest {
+	pCertificateRequest := s.physicalCertificateRequest(pObj)
+	if pCertificateRequest == nil {
+		return nil
+	}
+
+	vCertificateRequestName := s.certificateRequests.PhysicalToVirtual(pCertificateRequest)
+	if vCertificateRequestName.Name == "" {
+		return nil
+	}
+
+	vCertificateRequest := &certmanagerv1.CertificateRequest{}
+	err := s.virtualClient.Get(context2.TODO(), vCertificateRequestName, vCertificateRequest)
+	if err != nil {
+		return nil
+	}
+
+	return vCertificateRequest
+}
+
+func (s *orderSyncer) physicalCertificateRequest(pObj client.Object) *certmanagerv1.CertificateRequest {
+	owner := certificateRequestOwner(pObj)
+	if owner == nil {
+		return nil
+	}
+
+	pCertificateRequest := &certmanagerv1.CertificateRequest{}
+	err := s.physicalClient.Get(context2.TODO(), types.NamespacedName{Namespace: pObj.GetNamespace(), Name: owner.Name}, pCertificateRequest)
+	if err != nil || pCertificateRequest.UID != owner.UID {
+		return nil
+	}
+
+	return pCertificateRequest
+}
+
+func certificateRequestOwner(pObj client.Object) *metav1.OwnerReference {
+	for _, owner := range pObj.GetOwnerReferences() {
+		if owner.Kind == "CertificateRequest" && strings.HasPrefix(owner.APIVersion, certmanagerv1.SchemeGroupVersion.Group+"/") {
+			return &owner
+		}
+	}
+
+	return nil
+}
+
+// certificateRequestSuffix returns <certificate request>/<hash> for the given physical order
+func certificateRequestSuffix(pObj client.Object) string {
+	owner := certificateRequestOwner(pObj)
+	if owner == nil {
+		return ""
+	}
+
+	return owner.Name + "/" + nameSuffix(pObj.GetName())
+}
+
+// nameSuffix returns the hash cert-manager appends to the names of generated orders
+func nameSuffix(name string) string {
+	return name[strings.LastIndex(name, "-")+1:]
+}
+
+func isBackward(vObj client.Object) bool {
+	return vObj.GetAnnotations() != nil && vObj.GetAnnotations()[constants.BackwardSyncAnnotation] == "true"
+}
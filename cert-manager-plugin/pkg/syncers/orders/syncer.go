@@ -0,0 +1,112 @@
+package orders
+
+import (
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-sdk/syncer"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/equality"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// New creates a syncer that mirrors orders created by cert-manager in the host cluster for
+// certificate requests of the vcluster read-only into the vcluster. The given certificate
+// request translator is used to find the virtual certificate request an order belongs to.
+func New(ctx *context.RegisterContext, certificateRequests translator.NameTranslator) syncer.Syncer {
+	return &orderSyncer{
+		Translator: translator.NewMirrorPhysicalTranslator("order", &cmacme.Order{}),
+
+		certificateRequests: certificateRequests,
+
+		virtualClient:   ctx.VirtualManager.GetClient(),
+		physicalClient:  ctx.PhysicalManager.GetClient(),
+		targetNamespace: ctx.TargetNamespace,
+	}
+}
+
+type orderSyncer struct {
+	translator.Translator
+
+	certificateRequests translator.NameTranslator
+
+	virtualClient   client.Client
+	physicalClient  client.Client
+	targetNamespace string
+}
+
+var _ syncer.Initializer = &orderSyncer{}
+
+func (s *orderSyncer) Init(ctx *context.RegisterContext) error {
+	return translate.EnsureCRDFromPhysicalCluster(ctx.Context, ctx.PhysicalManager.GetConfig(), ctx.VirtualManager.GetConfig(), cmacme.SchemeGroupVersion.WithKind("Order"))
+}
+
+func (s *orderSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
+	if !isBackward(vObj) {
+		return ctrl.Result{}, nil
+	}
+
+	// delete here as order is no longer there
+	ctx.Log.Infof("delete virtual order %s/%s, because physical got deleted", vObj.GetNamespace(), vObj.GetName())
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+		return ctx.VirtualClient.Delete(ctx.Context, vObj)
+	})
+}
+
+func (s *orderSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
+	vOrder := vObj.(*cmacme.Order)
+	pOrder := pObj.(*cmacme.Order)
+	if !isBackward(vOrder) {
+		return ctrl.Result{}, nil
+	}
+
+	vCertificateRequest := s.certificateRequestByOrder(pOrder)
+	if vCertificateRequest == nil {
+		return ctrl.Result{}, nil
+	}
+
+	vStatus := rewriteStatus(&pOrder.Status, pOrder, vOrder.Name, vCertificateRequest)
+	if !equality.Semantic.DeepEqual(vOrder.Status, *vStatus) {
+		newOrder := vOrder.DeepCopy()
+		newOrder.Status = *vStatus
+		ctx.Log.Infof("update virtual order %s/%s, because status is out of sync", vOrder.Namespace, vOrder.Name)
+		err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Status().Update(ctx.Context, newOrder)
+		})
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// we will requeue anyways
+		return ctrl.Result{}, nil
+	}
+
+	// orders are read-only, so revert any changes
+	updated := s.translateUpdate(pOrder, vOrder, vCertificateRequest)
+	if updated != nil {
+		ctx.Log.Infof("update virtual order %s/%s, because it is out of sync with the physical one", vOrder.Namespace, vOrder.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Update(ctx.Context, updated)
+		})
+	}
+
+	return ctrl.Result{}, nil
+}
+
+var _ syncer.UpSyncer = &orderSyncer{}
+
+func (s *orderSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object) (ctrl.Result, error) {
+	pOrder := pObj.(*cmacme.Order)
+	vCertificateRequest := s.certificateRequestByOrder(pOrder)
+	if vCertificateRequest == nil {
+		return ctrl.Result{}, nil
+	}
+
+	vOrder := s.translate(pOrder, vCertificateRequest)
+	ctx.Log.Infof("create virtual order %s/%s, because physical is there and virtual is missing", vOrder.Namespace, vOrder.Name)
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+		return ctx.VirtualClient.Create(ctx.Context, vOrder)
+	})
+}
@@ -0,0 +1,129 @@
+package orders
+
+import (
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/status"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+)
+
+func (s *orderSyncer) translate(pObj *cmacme.Order, vCertificateRequest *certmanagerv1.CertificateRequest) *cmacme.Order {
+	vName := s.PhysicalToVirtual(pObj)
+	vObj := &cmacme.Order{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            vName.Name,
+			Namespace:       vName.Namespace,
+			Labels:          translateLabels(vCertificateRequest),
+			Annotations:     translateAnnotations(pObj.Annotations),
+			OwnerReferences: ownerReferences(vCertificateRequest),
+		},
+		Spec: *rewriteSpec(&pObj.Spec, vCertificateRequest),
+	}
+
+	return vObj
+}
+
+func (s *orderSyncer) translateUpdate(pObj, vObj *cmacme.Order, vCertificateRequest *certmanagerv1.CertificateRequest) *cmacme.Order {
+	var updated *cmacme.Order
+
+	// check labels
+	newLabels := translateLabels(vCertificateRequest)
+	if !equality.Semantic.DeepEqual(newLabels, vObj.Labels) {
+		updated = newIfNil(updated, vObj)
+		updated.Labels = newLabels
+	}
+
+	// check annotations
+	newAnnotations := translateAnnotations(pObj.Annotations)
+	if !equality.Semantic.DeepEqual(newAnnotations, vObj.Annotations) {
+		updated = newIfNil(updated, vObj)
+		updated.Annotations = newAnnotations
+	}
+
+	// check owner references
+	newOwnerReferences := ownerReferences(vCertificateRequest)
+	if !equality.Semantic.DeepEqual(newOwnerReferences, vObj.OwnerReferences) {
+		updated = newIfNil(updated, vObj)
+		updated.OwnerReferences = newOwnerReferences
+	}
+
+	// check spec
+	vSpec := rewriteSpec(&pObj.Spec, vCertificateRequest)
+	if !equality.Semantic.DeepEqual(*vSpec, vObj.Spec) {
+		updated = newIfNil(updated, vObj)
+		updated.Spec = *vSpec
+	}
+
+	return updated
+}
+
+func rewriteSpec(pObjSpec *cmacme.OrderSpec, vCertificateRequest *certmanagerv1.CertificateRequest) *cmacme.OrderSpec {
+	vObjSpec := pObjSpec.DeepCopy()
+
+	// the order was created for the virtual certificate request, so it uses the same issuer
+	vObjSpec.IssuerRef = vCertificateRequest.Spec.IssuerRef
+	return vObjSpec
+}
+
+// rewriteStatus replaces the physical names within the status with the virtual ones
+func rewriteStatus(pObjStatus *cmacme.OrderStatus, pObj *cmacme.Order, vName string, vCertificateRequest *certmanagerv1.CertificateRequest) *cmacme.OrderStatus {
+	vObjStatus := pObjStatus.DeepCopy()
+	if vObjStatus.Reason == "" {
+		return vObjStatus
+	}
+
+	t := status.NewTranslator()
+	t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: pObj.Name}, types.NamespacedName{Namespace: vCertificateRequest.Namespace, Name: vName})
+	if owner := certificateRequestOwner(pObj); owner != nil {
+		t.AddNamespacedName(types.NamespacedName{Namespace: pObj.Namespace, Name: owner.Name}, types.NamespacedName{Namespace: vCertificateRequest.Namespace, Name: vCertificateRequest.Name})
+	}
+
+	vObjStatus.Reason = t.Translate(vObjStatus.Reason)
+	return vObjStatus
+}
+
+func translateLabels(vCertificateRequest *certmanagerv1.CertificateRequest) map[string]string {
+	if len(vCertificateRequest.Labels) == 0 {
+		return nil
+	}
+
+	// cert-manager copies the labels of the certificate request to the order
+	newLabels := map[string]string{}
+	for k, v := range vCertificateRequest.Labels {
+		newLabels[k] = v
+	}
+	return newLabels
+}
+
+func translateAnnotations(pAnnotations map[string]string) map[string]string {
+	newAnnotations := map[string]string{}
+	for k, v := range pAnnotations {
+		newAnnotations[k] = v
+	}
+
+	newAnnotations[constants.BackwardSyncAnnotation] = "true"
+	return newAnnotations
+}
+
+func ownerReferences(vCertificateRequest *certmanagerv1.CertificateRequest) []metav1.OwnerReference {
+	return []metav1.OwnerReference{
+		{
+			APIVersion: certmanagerv1.SchemeGroupVersion.String(),
+			Kind:       "CertificateRequest",
+			Name:       vCertificateRequest.Name,
+			UID:        vCertificateRequest.UID,
+			Controller: pointer.Bool(true),
+		},
+	}
+}
+
+func newIfNil(updated *cmacme.Order, obj *cmacme.Order) *cmacme.Order {
+	if updated == nil {
+		return obj.DeepCopy()
+	}
+	return updated
+}
@@ -0,0 +1,284 @@
+package orders
+
+import (
+	"context"
+	cmacme "github.com/cert-manager/cert-manager/pkg/apis/acme/v1"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-sdk/syncer/translator"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+const (
+	targetNamespace = "vcluster"
+	orderNamespace  = "default"
+)
+
+var (
+	vCertificateRequestName = types.NamespacedName{Namespace: orderNamespace, Name: "example-1"}
+	pCertificateRequestName = types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName("example", orderNamespace) + "-5fj2k"}
+)
+
+// indexedClient filters lists by the given field indices, which the fake client ignores
+type indexedClient struct {
+	client.Client
+
+	indices map[string]client.IndexerFunc
+}
+
+func (c *indexedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	err := c.Client.List(ctx, list)
+	if err != nil || listOpts.FieldSelector == nil {
+		return err
+	}
+
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	filtered := []runtime.Object{}
+	for _, obj := range objs {
+		matches := true
+		for _, requirement := range listOpts.FieldSelector.Requirements() {
+			found := false
+			for _, value := range c.indices[requirement.Field](obj.(client.Object)) {
+				found = found || value == requirement.Value
+			}
+			matches = matches && found
+		}
+		if matches {
+			filtered = append(filtered, obj)
+		}
+	}
+
+	return meta.SetList(list, filtered)
+}
+
+// testCertificateRequestTranslator only translates the names of the test certificate request
+type testCertificateRequestTranslator struct{}
+
+func (t *testCertificateRequestTranslator) IsManaged(pObj client.Object) (bool, error) {
+	return pObj.GetName() == pCertificateRequestName.Name, nil
+}
+
+func (t *testCertificateRequestTranslator) VirtualToPhysical(req types.NamespacedName, vObj client.Object) types.NamespacedName {
+	if req != vCertificateRequestName {
+		return types.NamespacedName{}
+	}
+
+	return pCertificateRequestName
+}
+
+func (t *testCertificateRequestTranslator) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
+	if pObj.GetNamespace() != pCertificateRequestName.Namespace || pObj.GetName() != pCertificateRequestName.Name {
+		return types.NamespacedName{}
+	}
+
+	return vCertificateRequestName
+}
+
+func newTestSyncer() *orderSyncer {
+	scheme := runtime.NewScheme()
+	_ = certmanagerv1.AddToScheme(scheme)
+	_ = cmacme.AddToScheme(scheme)
+
+	vCertificateRequest := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: vCertificateRequestName.Namespace, Name: vCertificateRequestName.Name, UID: "virtual", Labels: map[string]string{"app": "web"}},
+		Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: "letsencrypt"}},
+	}
+	pCertificateRequest := &certmanagerv1.CertificateRequest{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pCertificateRequestName.Namespace, Name: pCertificateRequestName.Name, UID: "physical"},
+		Spec:       certmanagerv1.CertificateRequestSpec{IssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("letsencrypt", orderNamespace)}},
+	}
+
+	return &orderSyncer{
+		Translator:          translator.NewMirrorPhysicalTranslator("order", &cmacme.Order{}),
+		certificateRequests: &testCertificateRequestTranslator{},
+		virtualClient:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(vCertificateRequest).Build(),
+		physicalClient: &indexedClient{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(pCertificateRequest, physicalOrder(pCertificateRequestName.Name+"-1234567", "physical")).Build(),
+			indices: map[string]client.IndexerFunc{IndexByCertificateRequest: func(obj client.Object) []string {
+				return []string{certificateRequestSuffix(obj)}
+			}},
+		},
+		targetNamespace: targetNamespace,
+	}
+}
+
+// physicalOrder returns an order created by cert-manager for the physical certificate request with the given uid
+func physicalOrder(name string, uid types.UID) *cmacme.Order {
+	return &cmacme.Order{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: targetNamespace,
+			Name:      name,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: certmanagerv1.SchemeGroupVersion.String(),
+				Kind:       "CertificateRequest",
+				Name:       pCertificateRequestName.Name,
+				UID:        uid,
+			}},
+		},
+		Spec: cmacme.OrderSpec{
+			IssuerRef: cmmeta.ObjectReference{Name: translate.PhysicalName("letsencrypt", orderNamespace)},
+			DNSNames:  []string{"example.com"},
+		},
+	}
+}
+
+func TestPhysicalToVirtual(t *testing.T) {
+	otherNamespace := physicalOrder(pCertificateRequestName.Name+"-1234567", "physical")
+	otherNamespace.Namespace = "other"
+	withoutOwner := physicalOrder(pCertificateRequestName.Name+"-1234567", "physical")
+	withoutOwner.OwnerReferences = nil
+
+	testCases := []struct {
+		name     string
+		pObj     *cmacme.Order
+		expected types.NamespacedName
+	}{
+		{
+			name:     "Order of a synced certificate request",
+			pObj:     physicalOrder(pCertificateRequestName.Name+"-1234567", "physical"),
+			expected: types.NamespacedName{Namespace: orderNamespace, Name: "example-1-1234567"},
+		},
+		{
+			name: "Order of a recreated certificate request",
+			pObj: physicalOrder(pCertificateRequestName.Name+"-1234567", "other"),
+		},
+		{
+			name: "Order within another namespace",
+			pObj: otherNamespace,
+		},
+		{
+			name: "Order without certificate request",
+			pObj: withoutOwner,
+		},
+	}
+
+	s := newTestSyncer()
+	for _, testCase := range testCases {
+		name := s.PhysicalToVirtual(testCase.pObj)
+		if name != testCase.expected {
+			t.Errorf("Test case %s: expected %s, got %s", testCase.name, testCase.expected.String(), name.String())
+		}
+	}
+}
+
+func TestVirtualToPhysical(t *testing.T) {
+	testCases := []struct {
+		name     string
+		vName    string
+		expected types.NamespacedName
+	}{
+		{
+			name:     "Order of a synced certificate request",
+			vName:    "example-1-1234567",
+			expected: types.NamespacedName{Namespace: targetNamespace, Name: pCertificateRequestName.Name + "-1234567"},
+		},
+		{
+			name:  "Unknown order hash",
+			vName: "example-1-7654321",
+		},
+		{
+			name:  "Missing certificate request",
+			vName: "other-1-1234567",
+		},
+		{
+			name:  "Name without hash",
+			vName: "example",
+		},
+	}
+
+	s := newTestSyncer()
+	for _, testCase := range testCases {
+		name := s.VirtualToPhysical(types.NamespacedName{Namespace: orderNamespace, Name: testCase.vName}, nil)
+		if name != testCase.expected {
+			t.Errorf("Test case %s: expected %s, got %s", testCase.name, testCase.expected.String(), name.String())
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	s := newTestSyncer()
+	pOrder := physicalOrder(pCertificateRequestName.Name+"-1234567", "physical")
+	vCertificateRequest := s.certificateRequestByOrder(pOrder)
+	if vCertificateRequest == nil {
+		t.Fatalf("Expected virtual certificate request of the order")
+	}
+
+	vOrder := s.translate(pOrder, vCertificateRequest)
+	if vOrder.Namespace != orderNamespace || vOrder.Name != "example-1-1234567" {
+		t.Errorf("Expected virtual order %s/example-1-1234567, got %s/%s", orderNamespace, vOrder.Namespace, vOrder.Name)
+	}
+	if !reflect.DeepEqual(vOrder.Labels, vCertificateRequest.Labels) {
+		t.Errorf("Expected labels %v of the certificate request, got %v", vCertificateRequest.Labels, vOrder.Labels)
+	}
+	if !isBackward(vOrder) {
+		t.Errorf("Expected virtual order to be marked as backward synced")
+	}
+	if len(vOrder.OwnerReferences) != 1 || vOrder.OwnerReferences[0].Name != vCertificateRequest.Name || vOrder.OwnerReferences[0].UID != vCertificateRequest.UID {
+		t.Errorf("Expected the virtual certificate request as owner, got %v", vOrder.OwnerReferences)
+	}
+	if !reflect.DeepEqual(vOrder.Spec.IssuerRef, vCertificateRequest.Spec.IssuerRef) || !reflect.DeepEqual(vOrder.Spec.DNSNames, pOrder.Spec.DNSNames) {
+		t.Errorf("Expected spec with the virtual issuer, got %v", vOrder.Spec)
+	}
+
+	// the virtual order is in sync
+	if updated := s.translateUpdate(pOrder, vOrder, vCertificateRequest); updated != nil {
+		t.Errorf("Expected no update, got %v", updated)
+	}
+
+	// changes within the vcluster are reverted
+	changed := vOrder.DeepCopy()
+	changed.Labels = nil
+	changed.Annotations = nil
+	changed.Spec.DNSNames = []string{"other.com"}
+	updated := s.translateUpdate(pOrder, changed, vCertificateRequest)
+	if updated == nil || !reflect.DeepEqual(updated.Labels, vOrder.Labels) || !reflect.DeepEqual(updated.Annotations, vOrder.Annotations) || !reflect.DeepEqual(updated.Spec, vOrder.Spec) {
+		t.Errorf("Expected changes to be reverted, got %v", updated)
+	}
+}
+
+func TestRewriteStatus(t *testing.T) {
+	pOrder := physicalOrder(pCertificateRequestName.Name+"-1234567", "physical")
+	vCertificateRequest := &certmanagerv1.CertificateRequest{ObjectMeta: metav1.ObjectMeta{Namespace: vCertificateRequestName.Namespace, Name: vCertificateRequestName.Name}}
+
+	testCases := []struct {
+		name     string
+		reason   string
+		expected string
+	}{
+		{
+			name:     "Order name",
+			reason:   "Failed to finalize order " + targetNamespace + "/" + pOrder.Name,
+			expected: "Failed to finalize order " + orderNamespace + "/example-1-1234567",
+		},
+		{
+			name:     "Order and certificate request name",
+			reason:   "Order " + pOrder.Name + " of certificate request " + pCertificateRequestName.Name + " failed",
+			expected: "Order example-1-1234567 of certificate request example-1 failed",
+		},
+		{
+			name: "Empty reason",
+		},
+	}
+
+	for _, testCase := range testCases {
+		pStatus := &cmacme.OrderStatus{State: cmacme.Errored, Reason: testCase.reason}
+		vStatus := rewriteStatus(pStatus, pOrder, "example-1-1234567", vCertificateRequest)
+		if vStatus.Reason != testCase.expected || vStatus.State != cmacme.Errored {
+			t.Errorf("Test case %s: expected reason %q, got %q", testCase.name, testCase.expected, vStatus.Reason)
+		}
+	}
+}
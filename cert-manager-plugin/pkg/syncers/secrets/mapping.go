@@ -4,13 +4,19 @@ import (
 	context2 "context"
 	"fmt"
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/gc"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/externalissuers"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/syncers/issuers"
 	"github.com/loft-sh/vcluster-sdk/clienthelper"
 	"github.com/loft-sh/vcluster-sdk/syncer"
 	"github.com/loft-sh/vcluster-sdk/syncer/context"
 	"github.com/loft-sh/vcluster-sdk/translate"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -18,12 +24,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sort"
 	"strings"
 )
 
 var (
-	IndexByCertificateSecret = "indexbycertificatesecret"
-	IndexByIssuerSecret      = "indexbyissuersecret"
+	IndexByCertificateSecret    = "indexbycertificatesecret"
+	IndexByIssuerSecret         = "indexbyissuersecret"
+	IndexByClusterIssuerSecret  = "indexbyclusterissuersecret"
+	IndexByExternalIssuerSecret = "indexbyexternalissuersecret"
+	IndexByPodSecret            = "indexbypodsecret"
+	IndexByIngressSecret        = "indexbyingresssecret"
 )
 
 var _ syncer.IndicesRegisterer = &secretSyncer{}
@@ -36,7 +47,37 @@ func (s *secretSyncer) RegisterIndices(ctx *context.RegisterContext) error {
 		return err
 	}
 	err = ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, &certmanagerv1.Issuer{}, IndexByIssuerSecret, func(rawObj client.Object) []string {
-		return secretNamesFromIssuer(rawObj.(*certmanagerv1.Issuer))
+		return s.secretNamesFromIssuer(rawObj.(*certmanagerv1.Issuer))
+	})
+	if err != nil {
+		return err
+	}
+	err = ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, &certmanagerv1.ClusterIssuer{}, IndexByClusterIssuerSecret, func(rawObj client.Object) []string {
+		return s.secretNamesFromClusterIssuer(rawObj.(*certmanagerv1.ClusterIssuer))
+	})
+	if err != nil {
+		return err
+	}
+	for _, externalIssuer := range s.externalIssuers {
+		externalIssuer := externalIssuer
+		err = ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, externalissuers.NewObject(externalIssuer), IndexByExternalIssuerSecret, func(rawObj client.Object) []string {
+			return s.secretNamesFromExternalIssuer(rawObj.(*unstructured.Unstructured), externalIssuer)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// secrets of pods and ingresses are synced by vcluster itself, but must not be removed from the
+	// host cluster while they are still used
+	err = ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, &corev1.Pod{}, IndexByPodSecret, func(rawObj client.Object) []string {
+		return secretNamesFromPod(rawObj.(*corev1.Pod))
+	})
+	if err != nil {
+		return err
+	}
+	err = ctx.VirtualManager.GetFieldIndexer().IndexField(ctx.Context, &networkingv1.Ingress{}, IndexByIngressSecret, func(rawObj client.Object) []string {
+		return secretNamesFromIngress(rawObj.(*networkingv1.Ingress))
 	})
 	if err != nil {
 		return err
@@ -49,7 +90,13 @@ var _ syncer.ControllerModifier = &secretSyncer{}
 
 func (s *secretSyncer) ModifyController(ctx *context.RegisterContext, builder *builder.Builder) (*builder.Builder, error) {
 	builder = builder.Watches(&source.Kind{Type: &certmanagerv1.Certificate{}}, handler.EnqueueRequestsFromMapFunc(mapCertificates))
-	builder = builder.Watches(&source.Kind{Type: &certmanagerv1.Issuer{}}, handler.EnqueueRequestsFromMapFunc(mapIssuers))
+	builder = builder.Watches(&source.Kind{Type: &certmanagerv1.Issuer{}}, handler.EnqueueRequestsFromMapFunc(s.mapIssuers))
+	builder = builder.Watches(&source.Kind{Type: &certmanagerv1.ClusterIssuer{}}, handler.EnqueueRequestsFromMapFunc(s.mapClusterIssuers))
+	for _, externalIssuer := range s.externalIssuers {
+		builder = builder.Watches(&source.Kind{Type: externalissuers.NewObject(externalIssuer)}, handler.EnqueueRequestsFromMapFunc(s.mapExternalIssuers(externalIssuer)))
+	}
+	builder = builder.Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(mapPods))
+	builder = builder.Watches(&source.Kind{Type: &networkingv1.Ingress{}}, handler.EnqueueRequestsFromMapFunc(mapIngresses))
 	return builder, nil
 }
 
@@ -69,6 +116,11 @@ func (s *secretSyncer) shouldSyncBackwards(pSecret, vSecret *corev1.Secret) (boo
 		return true, name
 	}
 
+	name = s.nameByClusterIssuer(pSecret)
+	if name.Name != "" {
+		return true, name
+	}
+
 	name = s.nameByCertificate(pSecret)
 	if name.Name != "" {
 		return true, name
@@ -77,29 +129,108 @@ func (s *secretSyncer) shouldSyncBackwards(pSecret, vSecret *corev1.Secret) (boo
 	return false, types.NamespacedName{}
 }
 
-func (s *secretSyncer) shouldSyncForward(ctx *context.SyncContext, vObj runtime.Object) (bool, error) {
+func (s *secretSyncer) shouldSyncForward(ctx *context.SyncContext, vObj runtime.Object) ([]string, error) {
+	secret, ok := vObj.(*corev1.Secret)
+	if !ok || secret == nil {
+		return nil, fmt.Errorf("%#v is not a secret", vObj)
+	}
+
+	return s.references(ctx.Context, secret)
+}
+
+var _ gc.UsageChecker = &secretSyncer{}
+
+// IsUsed returns true if the virtual secret is referenced by a certificate or issuer within the vcluster,
+// or used by a pod or ingress, which vcluster syncs the secret for
+func (s *secretSyncer) IsUsed(ctx context2.Context, vObj client.Object) (bool, error) {
 	secret, ok := vObj.(*corev1.Secret)
 	if !ok || secret == nil {
 		return false, fmt.Errorf("%#v is not a secret", vObj)
 	}
 
-	certificateList := &certmanagerv1.CertificateList{}
-	err := ctx.VirtualClient.List(ctx.Context, certificateList, client.MatchingFields{IndexByCertificateSecret: secret.Namespace + "/" + secret.Name})
+	references, err := s.references(ctx, secret)
 	if err != nil {
 		return false, err
-	} else if meta.LenList(certificateList) > 0 {
+	} else if len(references) > 0 {
 		return true, nil
 	}
 
+	return s.isUsedByWorkloads(ctx, secret)
+}
+
+var _ gc.OwnerChecker = &secretSyncer{}
+
+// IsOwned returns true if the physical secret was created by the plugin. Secrets of pods and ingresses
+// are synced by vcluster itself and must not be collected by the plugin.
+func (s *secretSyncer) IsOwned(pObj client.Object) bool {
+	return pObj.GetLabels()[constants.SyncedLabel] == "true"
+}
+
+// references returns the certificates and issuers within the vcluster that reference the virtual
+// secret as sorted <kind> <namespace>/<name> strings
+func (s *secretSyncer) references(ctx context2.Context, secret *corev1.Secret) ([]string, error) {
+	key := secret.Namespace + "/" + secret.Name
+	references := []string{}
+
+	certificateList := &certmanagerv1.CertificateList{}
+	err := s.virtualClient.List(ctx, certificateList, client.MatchingFields{IndexByCertificateSecret: key})
+	if err != nil {
+		return nil, err
+	}
+	for _, certificate := range certificateList.Items {
+		references = append(references, "Certificate "+certificate.Namespace+"/"+certificate.Name)
+	}
+
 	issuerList := &certmanagerv1.IssuerList{}
-	err = ctx.VirtualClient.List(ctx.Context, issuerList, client.MatchingFields{IndexByIssuerSecret: secret.Namespace + "/" + secret.Name})
+	err = s.virtualClient.List(ctx, issuerList, client.MatchingFields{IndexByIssuerSecret: key})
+	if err != nil {
+		return nil, err
+	}
+	for _, issuer := range issuerList.Items {
+		references = append(references, "Issuer "+issuer.Namespace+"/"+issuer.Name)
+	}
+
+	clusterIssuerList := &certmanagerv1.ClusterIssuerList{}
+	err = s.virtualClient.List(ctx, clusterIssuerList, client.MatchingFields{IndexByClusterIssuerSecret: key})
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterIssuer := range clusterIssuerList.Items {
+		references = append(references, "ClusterIssuer "+clusterIssuer.Name)
+	}
+
+	for _, externalIssuer := range s.externalIssuers {
+		externalIssuerList := externalissuers.NewList(externalIssuer)
+		err = s.virtualClient.List(ctx, externalIssuerList, client.MatchingFields{IndexByExternalIssuerSecret: key})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range externalIssuerList.Items {
+			references = append(references, externalIssuer.Kind+" "+item.GetNamespace()+"/"+item.GetName())
+		}
+	}
+
+	sort.Strings(references)
+	return references, nil
+}
+
+// isUsedByWorkloads returns true if the virtual secret is used by a pod or ingress within the vcluster
+func (s *secretSyncer) isUsedByWorkloads(ctx context2.Context, secret *corev1.Secret) (bool, error) {
+	podList := &corev1.PodList{}
+	err := s.virtualClient.List(ctx, podList, client.MatchingFields{IndexByPodSecret: secret.Namespace + "/" + secret.Name})
 	if err != nil {
 		return false, err
-	} else if meta.LenList(issuerList) > 0 {
+	} else if meta.LenList(podList) > 0 {
 		return true, nil
 	}
 
-	return false, nil
+	ingressList := &networkingv1.IngressList{}
+	err = s.virtualClient.List(ctx, ingressList, client.MatchingFields{IndexByIngressSecret: secret.Namespace + "/" + secret.Name})
+	if err != nil {
+		return false, err
+	}
+
+	return meta.LenList(ingressList) > 0, nil
 }
 
 func (s *secretSyncer) nameByCertificate(pObj client.Object) types.NamespacedName {
@@ -120,6 +251,17 @@ func (s *secretSyncer) nameByCertificate(pObj client.Object) types.NamespacedNam
 	return types.NamespacedName{}
 }
 
+// certificateBySecret returns the virtual certificate the given virtual secret was issued for
+func (s *secretSyncer) certificateBySecret(ctx *context.SyncContext, vName types.NamespacedName) *certmanagerv1.Certificate {
+	vCertificate := &certmanagerv1.Certificate{}
+	err := clienthelper.GetByIndex(ctx.Context, ctx.VirtualClient, vCertificate, IndexByCertificateSecret, vName.Namespace+"/"+vName.Name)
+	if err != nil || vCertificate.Name == "" {
+		return nil
+	}
+
+	return vCertificate
+}
+
 func (s *secretSyncer) nameByIssuer(pObj client.Object) types.NamespacedName {
 	vIssuer := &certmanagerv1.Issuer{}
 	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vIssuer, IndexByIssuerSecret, pObj.GetName())
@@ -138,6 +280,24 @@ func (s *secretSyncer) nameByIssuer(pObj client.Object) types.NamespacedName {
 	return types.NamespacedName{}
 }
 
+func (s *secretSyncer) nameByClusterIssuer(pObj client.Object) types.NamespacedName {
+	vClusterIssuer := &certmanagerv1.ClusterIssuer{}
+	err := clienthelper.GetByIndex(context2.TODO(), s.virtualClient, vClusterIssuer, IndexByClusterIssuerSecret, pObj.GetName())
+	if err == nil && vClusterIssuer.Name != "" {
+		name := vClusterIssuer.Name
+		if vClusterIssuer.Spec.ACME != nil && vClusterIssuer.Spec.ACME.PrivateKey.Name != "" {
+			name = vClusterIssuer.Spec.ACME.PrivateKey.Name
+		}
+
+		return types.NamespacedName{
+			Name:      name,
+			Namespace: s.clusterResourceNamespace,
+		}
+	}
+
+	return types.NamespacedName{}
+}
+
 func (s *secretSyncer) PhysicalToVirtual(pObj client.Object) types.NamespacedName {
 	namespacedName := s.NamespacedTranslator.PhysicalToVirtual(pObj)
 	if namespacedName.Name != "" {
@@ -149,12 +309,18 @@ func (s *secretSyncer) PhysicalToVirtual(pObj client.Object) types.NamespacedNam
 		return namespacedName
 	}
 
-	return s.nameByIssuer(pObj)
+	namespacedName = s.nameByIssuer(pObj)
+	if namespacedName.Name != "" {
+		return namespacedName
+	}
+
+	return s.nameByClusterIssuer(pObj)
 }
 
 func secretNamesFromCertificate(certificate *certmanagerv1.Certificate) []string {
 	secrets := []string{}
-	// Do not include certificate.Spec.SecretName here as this will be handled separately by a different controller
+	// the secret cert-manager creates is indexed by its physical name, so that it can be synced back,
+	// and by its virtual name, so that the virtual secret can be mapped to its certificate
 	if certificate.Spec.SecretName != "" {
 		secrets = append(secrets, translate.PhysicalName(certificate.Spec.SecretName, certificate.Namespace))
 		secrets = append(secrets, certificate.Namespace+"/"+certificate.Spec.SecretName)
@@ -177,55 +343,167 @@ func mapCertificates(obj client.Object) []reconcile.Request {
 		return nil
 	}
 
-	requests := []reconcile.Request{}
-	names := secretNamesFromCertificate(certificate)
-	for _, name := range names {
-		splitted := strings.Split(name, "/")
-		if len(splitted) == 2 {
-			requests = append(requests, reconcile.Request{
-				NamespacedName: types.NamespacedName{
-					Namespace: splitted[0],
-					Name:      splitted[1],
-				},
-			})
-		}
+	return mapSecretNames(secretNamesFromCertificate(certificate))
+}
+
+// secretNamesFromIssuer returns the secrets of the issuer, unless it is denied by the issuer policy
+func (s *secretSyncer) secretNamesFromIssuer(issuer *certmanagerv1.Issuer) []string {
+	if s.issuerPolicy.Check(&issuer.Spec) != "" {
+		return []string{}
 	}
 
-	return requests
+	return secretNamesFromIssuerSpec(&issuer.Spec, issuer.Name, issuer.Namespace)
+}
+
+// secretNamesFromClusterIssuer returns the secrets of the cluster issuer created within the vcluster,
+// unless it is denied by the issuer policy
+func (s *secretSyncer) secretNamesFromClusterIssuer(clusterIssuer *certmanagerv1.ClusterIssuer) []string {
+	// cluster issuers mirrored from the host cluster reference host secrets
+	if clusterIssuer.Annotations != nil && clusterIssuer.Annotations[constants.BackwardSyncAnnotation] == "true" {
+		return []string{}
+	} else if s.issuerPolicy.Check(&clusterIssuer.Spec) != "" {
+		return []string{}
+	}
+
+	return secretNamesFromIssuerSpec(&clusterIssuer.Spec, clusterIssuer.Name, s.clusterResourceNamespace)
 }
 
-func secretNamesFromIssuer(issuer *certmanagerv1.Issuer) []string {
+func secretNamesFromIssuerSpec(spec *certmanagerv1.IssuerSpec, name, namespace string) []string {
 	secrets := []string{}
-	if issuer.Spec.ACME != nil && issuer.Spec.ACME.PrivateKey.Name != "" {
-		secrets = append(secrets, translate.PhysicalName(issuer.Spec.ACME.PrivateKey.Name, issuer.Namespace))
-		secrets = append(secrets, issuer.Namespace+"/"+issuer.Spec.ACME.PrivateKey.Name)
-	} else if issuer.Spec.ACME != nil {
-		secrets = append(secrets, translate.PhysicalName(issuer.Name, issuer.Namespace))
-		secrets = append(secrets, issuer.Namespace+"/"+issuer.Name)
+	if spec.ACME != nil && spec.ACME.PrivateKey.Name != "" {
+		secrets = append(secrets, translate.PhysicalName(spec.ACME.PrivateKey.Name, namespace))
+		secrets = append(secrets, namespace+"/"+spec.ACME.PrivateKey.Name)
+	} else if spec.ACME != nil {
+		secrets = append(secrets, translate.PhysicalName(name, namespace))
+		secrets = append(secrets, namespace+"/"+name)
 	}
-	if issuer.Spec.CA != nil && issuer.Spec.CA.SecretName != "" {
-		secrets = append(secrets, issuer.Namespace+"/"+issuer.Spec.CA.SecretName)
+	for _, secretName := range issuers.SecretNames(spec) {
+		secrets = append(secrets, namespace+"/"+secretName)
 	}
-	if issuer.Spec.Vault != nil && issuer.Spec.Vault.Auth.TokenSecretRef != nil && issuer.Spec.Vault.Auth.TokenSecretRef.Name != "" {
-		secrets = append(secrets, issuer.Namespace+"/"+issuer.Spec.Vault.Auth.TokenSecretRef.Name)
+	return secrets
+}
+
+func (s *secretSyncer) mapIssuers(obj client.Object) []reconcile.Request {
+	issuer, ok := obj.(*certmanagerv1.Issuer)
+	if !ok {
+		return nil
 	}
-	if issuer.Spec.Venafi != nil && issuer.Spec.Venafi.TPP != nil && issuer.Spec.Venafi.TPP.CredentialsRef.Name != "" {
-		secrets = append(secrets, issuer.Namespace+"/"+issuer.Spec.Venafi.TPP.CredentialsRef.Name)
+
+	// secrets of issuers that are denied now have to be released as well
+	return mapSecretNames(secretNamesFromIssuerSpec(&issuer.Spec, issuer.Name, issuer.Namespace))
+}
+
+func (s *secretSyncer) mapClusterIssuers(obj client.Object) []reconcile.Request {
+	clusterIssuer, ok := obj.(*certmanagerv1.ClusterIssuer)
+	if !ok {
+		return nil
 	}
-	if issuer.Spec.Venafi != nil && issuer.Spec.Venafi.Cloud != nil && issuer.Spec.Venafi.Cloud.APITokenSecretRef.Name != "" {
-		secrets = append(secrets, issuer.Namespace+"/"+issuer.Spec.Venafi.Cloud.APITokenSecretRef.Name)
+
+	// secrets of cluster issuers that are denied now have to be released as well
+	if clusterIssuer.Annotations != nil && clusterIssuer.Annotations[constants.BackwardSyncAnnotation] == "true" {
+		return nil
+	}
+	return mapSecretNames(secretNamesFromIssuerSpec(&clusterIssuer.Spec, clusterIssuer.Name, s.clusterResourceNamespace))
+}
+
+// secretNamesFromExternalIssuer returns the secrets of the external issuer, unless its kind is denied by
+// the issuer policy
+func (s *secretSyncer) secretNamesFromExternalIssuer(obj *unstructured.Unstructured, externalIssuer config.ExternalIssuer) []string {
+	if s.issuerPolicy.CheckType(externalIssuer.Kind) != "" {
+		return []string{}
+	}
+
+	return externalIssuerSecretNames(obj, externalIssuer)
+}
+
+func externalIssuerSecretNames(obj *unstructured.Unstructured, externalIssuer config.ExternalIssuer) []string {
+	secrets := []string{}
+	for _, secretName := range externalissuers.SecretNames(obj, externalIssuer.SecretRefs) {
+		secrets = append(secrets, obj.GetNamespace()+"/"+secretName)
 	}
 	return secrets
 }
 
-func mapIssuers(obj client.Object) []reconcile.Request {
-	issuer, ok := obj.(*certmanagerv1.Issuer)
+func (s *secretSyncer) mapExternalIssuers(externalIssuer config.ExternalIssuer) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		unstructuredObj, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil
+		}
+
+		return mapSecretNames(externalIssuerSecretNames(unstructuredObj, externalIssuer))
+	}
+}
+
+func secretNamesFromPod(pod *corev1.Pod) []string {
+	names := []string{}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName != "" {
+			names = append(names, volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name != "" {
+					names = append(names, source.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, imagePullSecret := range pod.Spec.ImagePullSecrets {
+		names = append(names, imagePullSecret.Name)
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil && envFrom.SecretRef.Name != "" {
+				names = append(names, envFrom.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name != "" {
+				names = append(names, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+
+	secrets := []string{}
+	for _, name := range names {
+		secrets = append(secrets, pod.Namespace+"/"+name)
+	}
+	return secrets
+}
+
+func mapPods(obj client.Object) []reconcile.Request {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+
+	return mapSecretNames(secretNamesFromPod(pod))
+}
+
+func secretNamesFromIngress(ingress *networkingv1.Ingress) []string {
+	secrets := []string{}
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName != "" {
+			secrets = append(secrets, ingress.Namespace+"/"+tls.SecretName)
+		}
+	}
+	return secrets
+}
+
+func mapIngresses(obj client.Object) []reconcile.Request {
+	ingress, ok := obj.(*networkingv1.Ingress)
 	if !ok {
 		return nil
 	}
 
+	return mapSecretNames(secretNamesFromIngress(ingress))
+}
+
+func mapSecretNames(names []string) []reconcile.Request {
 	requests := []reconcile.Request{}
-	names := secretNamesFromIssuer(issuer)
 	for _, name := range names {
 		splitted := strings.Split(name, "/")
 		if len(splitted) == 2 {
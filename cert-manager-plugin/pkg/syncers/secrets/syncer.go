@@ -1,24 +1,34 @@
 package secrets
 
 import (
+	"bytes"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
 	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/metrics"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/policy"
 	"github.com/loft-sh/vcluster-sdk/syncer"
 	"github.com/loft-sh/vcluster-sdk/syncer/context"
 	"github.com/loft-sh/vcluster-sdk/syncer/translator"
 	"github.com/loft-sh/vcluster-sdk/translate"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/equality"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
 )
 
-func New(ctx *context.RegisterContext) syncer.Base {
+func New(ctx *context.RegisterContext, cfg *config.Config) syncer.Syncer {
 	return &secretSyncer{
 		NamespacedTranslator: translator.NewNamespacedTranslator(ctx, "secret", &corev1.Secret{}),
 
 		virtualClient:  ctx.VirtualManager.GetClient(),
 		physicalClient: ctx.PhysicalManager.GetClient(),
+
+		clusterResourceNamespace: cfg.ClusterIssuers.ClusterResourceNamespace,
+		externalIssuers:          cfg.ExternalIssuers,
+		issuerPolicy:             policy.NewIssuerPolicy(cfg),
 	}
 }
 
@@ -27,6 +37,12 @@ type secretSyncer struct {
 
 	virtualClient  client.Client
 	physicalClient client.Client
+
+	clusterResourceNamespace string
+	externalIssuers          config.ExternalIssuers
+
+	// issuerPolicy excludes the secrets of denied issuers, as they are not synced to the host cluster
+	issuerPolicy *policy.IssuerPolicy
 }
 
 func (s *secretSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (ctrl.Result, error) {
@@ -37,14 +53,16 @@ func (s *secretSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (c
 	if shouldSync {
 		// delete here as secret is no longer needed
 		ctx.Log.Infof("delete virtual secret %s/%s, because physical got deleted", vObj.GetNamespace(), vObj.GetName())
-		return ctrl.Result{}, ctx.VirtualClient.Delete(ctx.Context, vObj)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+			return ctx.VirtualClient.Delete(ctx.Context, vObj)
+		})
 	}
 
 	// is secret used by an issuer or certificate?
-	createNeeded, err := s.shouldSyncForward(ctx, vObj)
+	references, err := s.shouldSyncForward(ctx, vObj)
 	if err != nil {
 		return ctrl.Result{}, err
-	} else if !createNeeded {
+	} else if len(references) == 0 {
 		return ctrl.Result{}, s.removeController(ctx, vSecret)
 	}
 
@@ -56,8 +74,16 @@ func (s *secretSyncer) SyncDown(ctx *context.SyncContext, vObj client.Object) (c
 		return ctrl.Result{}, nil
 	}
 
+	// shared secrets are created by their controlling party
+	if !isController(vSecret) {
+		return ctrl.Result{}, nil
+	}
+
 	// create the secret if it's needed
-	return s.SyncDownCreate(ctx, vObj, s.translate(vObj.(*corev1.Secret)))
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationCreate, func() error {
+		_, err := s.SyncDownCreate(ctx, vObj, s.translate(vSecret, references))
+		return err
+	})
 }
 
 func (s *secretSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj client.Object) (ctrl.Result, error) {
@@ -67,24 +93,15 @@ func (s *secretSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj c
 	// was secret created by certificate or issuer?
 	shouldSyncBackwards, _ := s.shouldSyncBackwards(pSecret, vSecret)
 	if shouldSyncBackwards {
-		// delete here as secret is no longer needed
-		if equality.Semantic.DeepEqual(pSecret.Data, vSecret.Data) && vSecret.Type == pSecret.Type {
-			return ctrl.Result{}, nil
-		}
-
-		// update secret if necessary
-		vSecret.Data = pSecret.Data
-		vSecret.Type = pSecret.Type
-		ctx.Log.Infof("update virtual secret %s/%s because physical secret has changed", vSecret.Namespace, vSecret.Name)
-		return ctrl.Result{}, ctx.VirtualClient.Delete(ctx.Context, vObj)
+		return s.syncBackwards(ctx, pSecret, vSecret)
 	}
 
 	// is secret used by an issuer or certificate?
-	used, err := s.shouldSyncForward(ctx, vObj)
+	references, err := s.shouldSyncForward(ctx, vObj)
 	if err != nil {
 		return ctrl.Result{}, err
-	} else if !used {
-		return ctrl.Result{}, s.removeController(ctx, vSecret)
+	} else if len(references) == 0 {
+		return ctrl.Result{}, s.release(ctx, pSecret, vSecret)
 	}
 
 	// switch controller
@@ -96,7 +113,61 @@ func (s *secretSyncer) Sync(ctx *context.SyncContext, pObj client.Object, vObj c
 	}
 
 	// update secret if necessary
-	return s.SyncDownUpdate(ctx, vObj, s.translateUpdate(pObj.(*corev1.Secret), vObj.(*corev1.Secret)))
+	updated := s.translateUpdate(pSecret, vSecret, references)
+	if updated == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+		_, err := s.SyncDownUpdate(ctx, vObj, updated)
+		return err
+	})
+}
+
+func (s *secretSyncer) syncBackwards(ctx *context.SyncContext, pSecret, vSecret *corev1.Secret) (ctrl.Result, error) {
+	// the secret type is immutable, so we have to recreate the secret
+	if vSecret.Type != pSecret.Type {
+		ctx.Log.Infof("delete virtual secret %s/%s because physical secret type has changed", vSecret.Namespace, vSecret.Name)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationDelete, func() error {
+			return ctx.VirtualClient.Delete(ctx.Context, vSecret)
+		})
+	}
+
+	vCertificate := s.certificateBySecret(ctx, types.NamespacedName{Namespace: vSecret.Namespace, Name: vSecret.Name})
+	updated := translateUpdateBackwards(pSecret, vSecret, vCertificate)
+	if updated == nil {
+		return ctrl.Result{}, nil
+	}
+	s.checkSecretKeys(pSecret, vCertificate)
+
+	// update secret in place, so that the secret is never missing within the vcluster
+	ctx.Log.Infof("update virtual secret %s/%s because physical secret has changed", vSecret.Namespace, vSecret.Name)
+	err := metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+		return ctx.VirtualClient.Update(ctx.Context, updated)
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// was the certificate renewed?
+	if vCertificate != nil && len(vSecret.Data[corev1.TLSCertKey]) > 0 && !bytes.Equal(vSecret.Data[corev1.TLSCertKey], updated.Data[corev1.TLSCertKey]) {
+		s.EventRecorder().Eventf(vCertificate, "Normal", "CertificateRenewed", "Certificate was renewed and secret %s was updated", vSecret.Name)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// checkSecretKeys records a warning event on the virtual certificate if the physical secret
+// is missing keys the certificate asks for
+func (s *secretSyncer) checkSecretKeys(pSecret *corev1.Secret, vCertificate *certmanagerv1.Certificate) {
+	if vCertificate == nil {
+		return
+	}
+
+	missing := missingSecretKeys(pSecret, vCertificate)
+	if len(missing) > 0 {
+		s.EventRecorder().Eventf(vCertificate, "Warning", "SecretKeysMissing", "Secret %s is missing the keys %s", vCertificate.Spec.SecretName, strings.Join(missing, ", "))
+	}
 }
 
 var _ syncer.UpSyncer = &secretSyncer{}
@@ -107,43 +178,69 @@ func (s *secretSyncer) SyncUp(ctx *context.SyncContext, pObj client.Object) (ctr
 	// was secret created by certificate or issuer?
 	shouldSyncBackwards, vName := s.shouldSyncBackwards(pSecret, nil)
 	if shouldSyncBackwards {
-		vSecret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:        vName.Name,
-				Namespace:   vName.Namespace,
-				Annotations: map[string]string{},
-				Labels:      map[string]string{},
-			},
-			Data: pSecret.Data,
-			Type: pSecret.Type,
-		}
-		for k, v := range pSecret.Annotations {
-			vSecret.Annotations[k] = v
-		}
-		for k, v := range pSecret.Labels {
-			vSecret.Labels[k] = v
-		}
-		vSecret.Annotations[constants.BackwardSyncAnnotation] = "true"
-		vSecret.Labels[translate.ControllerLabel] = constants.PluginName
+		vCertificate := s.certificateBySecret(ctx, vName)
+		s.checkSecretKeys(pSecret, vCertificate)
+		vSecret := translateBackwards(pSecret, vName, vCertificate)
 		ctx.Log.Infof("create virtual secret %s/%s because physical secret exists", vSecret.Namespace, vSecret.Name)
-		return ctrl.Result{}, ctx.VirtualClient.Create(ctx.Context, vSecret)
+		return ctrl.Result{}, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationCreate, func() error {
+			return ctx.VirtualClient.Create(ctx.Context, vSecret)
+		})
 	}
 
 	// don't do anything here
 	return ctrl.Result{}, nil
 }
 
+// release removes the physical secret if we are the controlling party and it is not used by a pod or
+// ingress anymore. Secrets still used by pods or ingresses are handed over to vcluster by removing us as
+// controlling party, so that vcluster syncs them from then on. Shared secrets are left to their owner.
+func (s *secretSyncer) release(ctx *context.SyncContext, pSecret, vSecret *corev1.Secret) error {
+	if !isController(vSecret) {
+		return nil
+	}
+
+	used, err := s.isUsedByWorkloads(ctx.Context, vSecret)
+	if err != nil {
+		return err
+	} else if !used {
+		ctx.Log.Infof("delete physical secret %s/%s, because it is not referenced by a certificate or issuer anymore", pSecret.Namespace, pSecret.Name)
+		err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationDelete, func() error {
+			return ctx.PhysicalClient.Delete(ctx.Context, pSecret)
+		})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return err
+		}
+	} else if pSecret.Annotations[constants.ReferencesAnnotation] != "" || pSecret.Labels[constants.SyncedLabel] != "" {
+		// the secret is handed over to vcluster, so the garbage collector must not collect it anymore
+		delete(pSecret.Labels, constants.SyncedLabel)
+		delete(pSecret.Annotations, constants.ReferencesAnnotation)
+		ctx.Log.Infof("update physical secret %s/%s, because it is handed over to vcluster", pSecret.Namespace, pSecret.Name)
+		err = metrics.Write(s.Name(), metrics.ClusterHost, metrics.OperationUpdate, func() error {
+			return ctx.PhysicalClient.Update(ctx.Context, pSecret)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.removeController(ctx, vSecret)
+}
+
 func (s *secretSyncer) removeController(ctx *context.SyncContext, vSecret *corev1.Secret) error {
 	// remove us as owner
-	if vSecret.Labels != nil && vSecret.Labels[translate.ControllerLabel] == constants.PluginName {
+	if isController(vSecret) {
 		delete(vSecret.Labels, translate.ControllerLabel)
 		ctx.Log.Infof("update secret %s/%s because we the controlling party, but secret is not needed anymore", vSecret.Namespace, vSecret.Name)
-		return ctx.VirtualClient.Update(ctx.Context, vSecret)
+		return metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Update(ctx.Context, vSecret)
+		})
 	}
 
 	return nil
 }
 
+// switchController makes us the controlling party of the virtual secret, so that vcluster does not sync
+// it as well. Secrets controlled by another party are shared, which means only their data is synced.
 func (s *secretSyncer) switchController(ctx *context.SyncContext, vSecret *corev1.Secret) (bool, error) {
 	// check if we own the secret
 	if vSecret.Labels == nil || vSecret.Labels[translate.ControllerLabel] == "" {
@@ -152,10 +249,15 @@ func (s *secretSyncer) switchController(ctx *context.SyncContext, vSecret *corev
 		}
 		vSecret.Labels[translate.ControllerLabel] = constants.PluginName
 		ctx.Log.Infof("update secret %s/%s because we are not the controlling party", vSecret.Namespace, vSecret.Name)
-		return true, ctx.VirtualClient.Update(ctx.Context, vSecret)
-	} else if vSecret.Labels[translate.ControllerLabel] != constants.PluginName {
-		return true, nil
+		return true, metrics.Write(s.Name(), metrics.ClusterVirtual, metrics.OperationUpdate, func() error {
+			return ctx.VirtualClient.Update(ctx.Context, vSecret)
+		})
 	}
 
 	return false, nil
 }
+
+// isController returns true if we are the controlling party of the virtual secret
+func isController(vSecret *corev1.Secret) bool {
+	return vSecret.Labels != nil && vSecret.Labels[translate.ControllerLabel] == constants.PluginName
+}
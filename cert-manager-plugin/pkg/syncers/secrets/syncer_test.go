@@ -0,0 +1,313 @@
+package secrets
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/config"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/testingutil"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const (
+	targetNamespace = testingutil.TargetNamespace
+	secretName      = "ca"
+	secretNamespace = "default"
+)
+
+// newTestSyncer returns a secret syncer and a sync context with fake clients. The fake client ignores
+// field selectors, so every listed object references or uses the secret.
+func newTestSyncer(t *testing.T, vObjs, pObjs []client.Object) (*secretSyncer, *context.SyncContext) {
+	ctx := testingutil.NewSyncContext(t, vObjs, pObjs)
+	return New(testingutil.NewRegisterContext(ctx.VirtualClient, ctx.PhysicalClient), &config.Config{}).(*secretSyncer), ctx
+}
+
+func virtualSecret(controller string) *corev1.Secret {
+	vSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: secretName},
+		Data:       map[string][]byte{"tls.crt": []byte("virtual")},
+	}
+	if controller != "" {
+		vSecret.Labels = map[string]string{translate.ControllerLabel: controller}
+	}
+	return vSecret
+}
+
+func physicalSecret(annotations, labels map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   targetNamespace,
+			Name:        translate.PhysicalName(secretName, secretNamespace),
+			Annotations: annotations,
+			Labels:      labels,
+		},
+		Data: map[string][]byte{"tls.crt": []byte("physical")},
+	}
+}
+
+func TestSyncDownShared(t *testing.T) {
+	certificate := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: "tls"},
+		Spec:       certmanagerv1.CertificateSpec{SecretName: "tls"},
+	}
+
+	testCases := []struct {
+		name       string
+		controller string
+		pObjs      []client.Object
+
+		expected      string
+		expectSynced  bool
+		expectMissing bool
+	}{
+		{
+			name:          "missing physical secret of another controller",
+			controller:    "other-controller",
+			expectMissing: true,
+		},
+		{
+			name:       "physical secret created by the owner",
+			controller: "other-controller",
+			pObjs:      []client.Object{physicalSecret(nil, nil)},
+			expected:   "physical",
+		},
+		{
+			name:         "missing physical secret controlled by the plugin",
+			controller:   constants.PluginName,
+			expected:     "virtual",
+			expectSynced: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		vSecret := virtualSecret(testCase.controller)
+		s, ctx := newTestSyncer(t, []client.Object{vSecret, certificate}, testCase.pObjs)
+		_, err := s.SyncDown(ctx, vSecret)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		pSecret := &corev1.Secret{}
+		err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: targetNamespace, Name: translate.PhysicalName(secretName, secretNamespace)}, pSecret)
+		if kerrors.IsNotFound(err) && testCase.expectMissing {
+			continue
+		} else if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if testCase.expectMissing {
+			t.Fatalf("Test case %s: expected the plugin not to create the shared physical secret", testCase.name)
+		}
+		if string(pSecret.Data["tls.crt"]) != testCase.expected {
+			t.Errorf("Test case %s: expected data %q, got %q", testCase.name, testCase.expected, string(pSecret.Data["tls.crt"]))
+		}
+		if synced := pSecret.Annotations[constants.ReferencesAnnotation] != "" && pSecret.Labels[constants.SyncedLabel] == "true"; synced != testCase.expectSynced {
+			t.Errorf("Test case %s: expected physical secret to be owned by the plugin %t, got %v and %v", testCase.name, testCase.expectSynced, pSecret.Annotations, pSecret.Labels)
+		}
+	}
+}
+
+func TestTranslateUpdateShared(t *testing.T) {
+	s, _ := newTestSyncer(t, nil, nil)
+	pSecret := physicalSecret(nil, nil)
+
+	updated := s.translateUpdate(pSecret, virtualSecret("other-controller"), []string{"Issuer default/ca"})
+	if updated != nil {
+		t.Errorf("Expected shared secret not to be updated, got %#+v", updated)
+	}
+
+	updated = s.translateUpdate(pSecret, virtualSecret(constants.PluginName), []string{"Issuer default/ca"})
+	if updated == nil {
+		t.Fatalf("Expected controlled secret to be updated")
+	}
+	if string(updated.Data["tls.crt"]) != "virtual" || updated.Annotations[constants.ReferencesAnnotation] != "Issuer default/ca" || updated.Labels[constants.SyncedLabel] != "true" {
+		t.Errorf("Expected data, references and synced label to be updated, got %#+v", updated)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	recorded := map[string]string{constants.ReferencesAnnotation: "Issuer default/ca"}
+	synced := map[string]string{constants.SyncedLabel: "true"}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: "app"}}
+
+	testCases := []struct {
+		name string
+		vObj *corev1.Secret
+		pObj *corev1.Secret
+		used bool
+
+		expectDeleted   bool
+		expectHandedOff bool
+	}{
+		{
+			name:          "unused secret synced by the plugin",
+			vObj:          virtualSecret(constants.PluginName),
+			pObj:          physicalSecret(recorded, synced),
+			expectDeleted: true,
+		},
+		{
+			name:            "secret synced by the plugin and used by a pod",
+			vObj:            virtualSecret(constants.PluginName),
+			pObj:            physicalSecret(recorded, synced),
+			used:            true,
+			expectHandedOff: true,
+		},
+		{
+			name:            "secret controlled by the plugin whose references were removed from the host",
+			vObj:            virtualSecret(constants.PluginName),
+			pObj:            physicalSecret(nil, nil),
+			used:            true,
+			expectHandedOff: true,
+		},
+		{
+			name: "secret shared with another controller",
+			vObj: virtualSecret("other-controller"),
+			pObj: physicalSecret(recorded, synced),
+		},
+	}
+
+	for _, testCase := range testCases {
+		vObjs := []client.Object{testCase.vObj}
+		if testCase.used {
+			vObjs = append(vObjs, pod)
+		}
+		s, ctx := newTestSyncer(t, vObjs, []client.Object{testCase.pObj})
+
+		err := s.release(ctx, testCase.pObj, testCase.vObj)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		pSecret := &corev1.Secret{}
+		err = ctx.PhysicalClient.Get(ctx.Context, types.NamespacedName{Namespace: targetNamespace, Name: testCase.pObj.Name}, pSecret)
+		if err != nil && !kerrors.IsNotFound(err) {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if kerrors.IsNotFound(err) != testCase.expectDeleted {
+			t.Errorf("Test case %s: expected physical secret deleted %t", testCase.name, testCase.expectDeleted)
+		}
+		if testCase.expectHandedOff && (pSecret.Annotations[constants.ReferencesAnnotation] != "" || pSecret.Labels[constants.SyncedLabel] != "") {
+			t.Errorf("Test case %s: expected references and synced label to be removed, got %v and %v", testCase.name, pSecret.Annotations, pSecret.Labels)
+		}
+
+		vSecret := &corev1.Secret{}
+		err = ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Namespace: secretNamespace, Name: secretName}, vSecret)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+		if isController(vSecret) {
+			t.Errorf("Test case %s: expected the plugin not to control the virtual secret anymore", testCase.name)
+		}
+	}
+}
+
+func TestSyncBackwards(t *testing.T) {
+	certificate := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: "tls"},
+		Spec:       certmanagerv1.CertificateSpec{SecretName: secretName},
+	}
+	issued := map[string][]byte{corev1.TLSCertKey: []byte("issued"), corev1.TLSPrivateKeyKey: []byte("key")}
+	renewed := map[string][]byte{corev1.TLSCertKey: []byte("renewed"), corev1.TLSPrivateKeyKey: []byte("key")}
+
+	testCases := []struct {
+		name        string
+		vData       map[string][]byte
+		vType       corev1.SecretType
+		pData       map[string][]byte
+		certificate bool
+
+		expectDeleted bool
+		expectedData  string
+		expectedEvent string
+	}{
+		{
+			name:         "unchanged secret",
+			vData:        issued,
+			pData:        issued,
+			certificate:  true,
+			expectedData: "issued",
+		},
+		{
+			name:         "first issuance",
+			vData:        map[string][]byte{},
+			pData:        issued,
+			certificate:  true,
+			expectedData: "issued",
+		},
+		{
+			name:          "renewed certificate",
+			vData:         issued,
+			pData:         renewed,
+			certificate:   true,
+			expectedData:  "renewed",
+			expectedEvent: "Normal CertificateRenewed Certificate was renewed and secret " + secretName + " was updated",
+		},
+		{
+			name:         "changed secret of an issuer",
+			vData:        issued,
+			pData:        renewed,
+			expectedData: "renewed",
+		},
+		{
+			name:          "changed secret type",
+			vData:         issued,
+			vType:         corev1.SecretTypeOpaque,
+			pData:         issued,
+			certificate:   true,
+			expectDeleted: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		pSecret := physicalSecret(nil, nil)
+		pSecret.Type = corev1.SecretTypeTLS
+		pSecret.Data = testCase.pData
+
+		var vCertificate *certmanagerv1.Certificate
+		vObjs := []client.Object{}
+		if testCase.certificate {
+			vCertificate = certificate
+			vObjs = append(vObjs, certificate)
+		}
+		vSecret := translateBackwards(pSecret, types.NamespacedName{Namespace: secretNamespace, Name: secretName}, vCertificate)
+		vSecret.Data = testCase.vData
+		if testCase.vType != "" {
+			vSecret.Type = testCase.vType
+		}
+		vObjs = append(vObjs, vSecret)
+
+		ctx := testingutil.NewSyncContext(t, vObjs, []client.Object{pSecret})
+		registerCtx := testingutil.NewRegisterContext(ctx.VirtualClient, ctx.PhysicalClient)
+		recorder := record.NewFakeRecorder(10)
+		registerCtx.VirtualManager.(*testingutil.Manager).Recorder = recorder
+		s := New(registerCtx, &config.Config{}).(*secretSyncer)
+
+		_, err := s.syncBackwards(ctx, pSecret, vSecret)
+		if err != nil {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		}
+
+		updated := &corev1.Secret{}
+		err = ctx.VirtualClient.Get(ctx.Context, types.NamespacedName{Namespace: secretNamespace, Name: secretName}, updated)
+		if err != nil && !kerrors.IsNotFound(err) {
+			t.Fatalf("Test case %s: unexpected error: %v", testCase.name, err)
+		} else if kerrors.IsNotFound(err) != testCase.expectDeleted {
+			t.Errorf("Test case %s: expected virtual secret deleted %t", testCase.name, testCase.expectDeleted)
+		} else if !testCase.expectDeleted && string(updated.Data[corev1.TLSCertKey]) != testCase.expectedData {
+			t.Errorf("Test case %s: expected virtual secret to be updated in place with %s, got %s", testCase.name, testCase.expectedData, string(updated.Data[corev1.TLSCertKey]))
+		}
+
+		event := ""
+		select {
+		case event = <-recorder.Events:
+		default:
+		}
+		if event != testCase.expectedEvent {
+			t.Errorf("Test case %s: expected event %q, got %q", testCase.name, testCase.expectedEvent, event)
+		}
+	}
+}
@@ -1,20 +1,55 @@
 package secrets
 
 import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/translate"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"strings"
 )
 
-func (s *secretSyncer) translate(vObj *corev1.Secret) *corev1.Secret {
+const (
+	// keys of these domains and their subdomains, e.g. cert-manager.vcluster.loft.sh or
+	// controller.cert-manager.io, are written by vcluster and cert-manager
+	vclusterKeyDomain    = "vcluster.loft.sh"
+	certManagerKeyDomain = "cert-manager.io"
+
+	jksKeystoreKey      = "keystore.jks"
+	jksTruststoreKey    = "truststore.jks"
+	pkcs12KeystoreKey   = "keystore.p12"
+	pkcs12TruststoreKey = "truststore.p12"
+)
+
+func (s *secretSyncer) translate(vObj *corev1.Secret, references []string) *corev1.Secret {
 	newSecret := s.TranslateMetadata(vObj).(*corev1.Secret)
 	if newSecret.Type == corev1.SecretTypeServiceAccountToken {
 		newSecret.Type = corev1.SecretTypeOpaque
 	}
+	if newSecret.Annotations == nil {
+		newSecret.Annotations = map[string]string{}
+	}
+	newSecret.Annotations[constants.ReferencesAnnotation] = strings.Join(references, ",")
+	if newSecret.Labels == nil {
+		newSecret.Labels = map[string]string{}
+	}
+	newSecret.Labels[constants.SyncedLabel] = "true"
 
 	return newSecret
 }
 
-func (s *secretSyncer) translateUpdate(pObj, vObj *corev1.Secret) *corev1.Secret {
+// translateUpdate returns an updated physical secret if the virtual secret or its references have
+// changed. If the secret is shared with another controlling party, it is left to that party, so that
+// the physical secret is not written by two controllers.
+func (s *secretSyncer) translateUpdate(pObj, vObj *corev1.Secret, references []string) *corev1.Secret {
+	if !isController(vObj) {
+		return nil
+	}
+
 	var updated *corev1.Secret
 
 	// check data
@@ -31,7 +66,15 @@ func (s *secretSyncer) translateUpdate(pObj, vObj *corev1.Secret) *corev1.Secret
 
 	// check annotations
 	changed, updatedAnnotations, updatedLabels := s.TranslateMetadataUpdate(vObj, pObj)
-	if changed {
+	if updatedAnnotations == nil {
+		updatedAnnotations = map[string]string{}
+	}
+	updatedAnnotations[constants.ReferencesAnnotation] = strings.Join(references, ",")
+	if updatedLabels == nil {
+		updatedLabels = map[string]string{}
+	}
+	updatedLabels[constants.SyncedLabel] = "true"
+	if changed || pObj.Annotations[constants.ReferencesAnnotation] != updatedAnnotations[constants.ReferencesAnnotation] || pObj.Labels[constants.SyncedLabel] != "true" {
 		updated = newIfNil(updated, pObj)
 		updated.Annotations = updatedAnnotations
 		updated.Labels = updatedLabels
@@ -40,6 +83,211 @@ func (s *secretSyncer) translateUpdate(pObj, vObj *corev1.Secret) *corev1.Secret
 	return updated
 }
 
+// translateBackwards creates the virtual secret for the given physical secret, which was created
+// by cert-manager within the host cluster
+func translateBackwards(pObj *corev1.Secret, vName types.NamespacedName, vCertificate *certmanagerv1.Certificate) *corev1.Secret {
+	annotations, labels := translateMetadataBackwards(pObj, vCertificate)
+	vObj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        vName.Name,
+			Namespace:   vName.Namespace,
+			Annotations: annotations,
+			Labels:      labels,
+		},
+		Data: pObj.Data,
+		Type: pObj.Type,
+	}
+	vObj.Annotations[constants.BackwardSyncAnnotation] = "true"
+	vObj.Labels[translate.ControllerLabel] = constants.PluginName
+	return vObj
+}
+
+// translateUpdateBackwards returns an updated virtual secret if the physical secret has changed. The
+// keys managed by the plugin and cert-manager are replaced, so keys cert-manager removed from the
+// physical secret are removed as well. Keys dropped from the secret template of the certificate are
+// removed, while other labels and annotations added within the vcluster are preserved.
+func translateUpdateBackwards(pObj, vObj *corev1.Secret, vCertificate *certmanagerv1.Certificate) *corev1.Secret {
+	var updated *corev1.Secret
+
+	// check data
+	if !equality.Semantic.DeepEqual(vObj.Data, pObj.Data) {
+		updated = newIfNil(updated, vObj)
+		updated.Data = pObj.Data
+	}
+
+	// check annotations
+	annotations, labels := translateMetadataBackwards(pObj, vCertificate)
+	annotations[constants.BackwardSyncAnnotation] = "true"
+	newAnnotations := replaceManagedKeys(vObj.Annotations, annotations, templateKeys(vObj, constants.TemplateAnnotationsAnnotation))
+	if !equality.Semantic.DeepEqual(newAnnotations, vObj.Annotations) {
+		updated = newIfNil(updated, vObj)
+		updated.Annotations = newAnnotations
+	}
+
+	// check labels
+	labels[translate.ControllerLabel] = constants.PluginName
+	newLabels := replaceManagedKeys(vObj.Labels, labels, templateKeys(vObj, constants.TemplateLabelsAnnotation))
+	if !equality.Semantic.DeepEqual(newLabels, vObj.Labels) {
+		updated = newIfNil(updated, vObj)
+		updated.Labels = newLabels
+	}
+
+	return updated
+}
+
+// translateMetadataBackwards returns the annotations and labels of the physical secret without the
+// ones only meaningful within the host cluster. The secret template of the virtual certificate
+// is applied verbatim on top and its keys are recorded, so they can be removed once they are
+// dropped from the template.
+func translateMetadataBackwards(pObj *corev1.Secret, vCertificate *certmanagerv1.Certificate) (map[string]string, map[string]string) {
+	annotations := stripHostKeys(pObj.Annotations)
+	labels := stripHostKeys(pObj.Labels)
+	if vCertificate == nil {
+		return annotations, labels
+	}
+
+	// cert-manager points to the physical certificate and issuer
+	if annotations[certmanagerv1.CertificateNameKey] != "" {
+		annotations[certmanagerv1.CertificateNameKey] = vCertificate.Name
+	}
+	if _, ok := annotations[certmanagerv1.IssuerNameAnnotationKey]; ok {
+		annotations[certmanagerv1.IssuerNameAnnotationKey] = vCertificate.Spec.IssuerRef.Name
+	}
+	if _, ok := annotations[certmanagerv1.IssuerKindAnnotationKey]; ok {
+		annotations[certmanagerv1.IssuerKindAnnotationKey] = vCertificate.Spec.IssuerRef.Kind
+	}
+	if _, ok := annotations[certmanagerv1.IssuerGroupAnnotationKey]; ok {
+		annotations[certmanagerv1.IssuerGroupAnnotationKey] = vCertificate.Spec.IssuerRef.Group
+	}
+
+	if vCertificate.Spec.SecretTemplate != nil {
+		for k, v := range vCertificate.Spec.SecretTemplate.Annotations {
+			annotations[k] = v
+		}
+		for k, v := range vCertificate.Spec.SecretTemplate.Labels {
+			labels[k] = v
+		}
+		if len(vCertificate.Spec.SecretTemplate.Annotations) > 0 {
+			annotations[constants.TemplateAnnotationsAnnotation] = joinKeys(vCertificate.Spec.SecretTemplate.Annotations)
+		}
+		if len(vCertificate.Spec.SecretTemplate.Labels) > 0 {
+			annotations[constants.TemplateLabelsAnnotation] = joinKeys(vCertificate.Spec.SecretTemplate.Labels)
+		}
+	}
+
+	return annotations, labels
+}
+
+// stripHostKeys returns a copy of the given labels or annotations without the vcluster and plugin
+// marker keys of the host cluster
+func stripHostKeys(m map[string]string) map[string]string {
+	stripped := map[string]string{}
+	for k, v := range m {
+		if hasKeyDomain(k, vclusterKeyDomain) {
+			continue
+		}
+
+		stripped[k] = v
+	}
+	return stripped
+}
+
+// missingSecretKeys returns the data keys the virtual certificate asks for which are not present
+// within the physical secret
+func missingSecretKeys(pObj *corev1.Secret, vCertificate *certmanagerv1.Certificate) []string {
+	keys := []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey}
+	for _, format := range vCertificate.Spec.AdditionalOutputFormats {
+		switch format.Type {
+		case certmanagerv1.CertificateOutputFormatDER:
+			keys = append(keys, certmanagerv1.CertificateOutputFormatDERKey)
+		case certmanagerv1.CertificateOutputFormatCombinedPEM:
+			keys = append(keys, certmanagerv1.CertificateOutputFormatCombinedPEMKey)
+		}
+	}
+
+	// truststores are only created if the issuer returns a ca
+	hasCA := len(pObj.Data[cmmeta.TLSCAKey]) > 0
+	if vCertificate.Spec.Keystores != nil {
+		if vCertificate.Spec.Keystores.JKS != nil && vCertificate.Spec.Keystores.JKS.Create {
+			keys = append(keys, jksKeystoreKey)
+			if hasCA {
+				keys = append(keys, jksTruststoreKey)
+			}
+		}
+		if vCertificate.Spec.Keystores.PKCS12 != nil && vCertificate.Spec.Keystores.PKCS12.Create {
+			keys = append(keys, pkcs12KeystoreKey)
+			if hasCA {
+				keys = append(keys, pkcs12TruststoreKey)
+			}
+		}
+	}
+
+	missing := []string{}
+	for _, key := range keys {
+		if _, ok := pObj.Data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// replaceManagedKeys returns the given labels or annotations of the virtual secret with the keys of
+// cert-manager and the keys the secret template applied before replaced by the desired ones
+func replaceManagedKeys(vMap, desired map[string]string, templated map[string]bool) map[string]string {
+	replaced := map[string]string{}
+	for k, v := range vMap {
+		if _, ok := desired[k]; ok || templated[k] || hasKeyDomain(k, certManagerKeyDomain) {
+			continue
+		}
+
+		replaced[k] = v
+	}
+	for k, v := range desired {
+		replaced[k] = v
+	}
+	return replaced
+}
+
+// templateKeys returns the keys the secret template applied to the virtual secret, which are recorded
+// within the given annotation. The annotation is part of the keys, so it is removed together with the
+// template.
+func templateKeys(vObj *corev1.Secret, annotation string) map[string]bool {
+	keys := map[string]bool{
+		constants.TemplateAnnotationsAnnotation: true,
+		constants.TemplateLabelsAnnotation:      true,
+	}
+	if vObj.Annotations == nil || vObj.Annotations[annotation] == "" {
+		return keys
+	}
+
+	for _, key := range strings.Split(vObj.Annotations[annotation], ",") {
+		keys[key] = true
+	}
+	return keys
+}
+
+// joinKeys returns the sorted keys of the given labels or annotations separated by commas, which
+// keys cannot contain
+func joinKeys(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// hasKeyDomain returns true if the prefix of the label or annotation key is the given domain or
+// one of its subdomains
+func hasKeyDomain(key, domain string) bool {
+	i := strings.Index(key, "/")
+	if i == -1 {
+		return false
+	}
+
+	return key[:i] == domain || strings.HasSuffix(key[:i], "."+domain)
+}
+
 func newIfNil(updated *corev1.Secret, pObj *corev1.Secret) *corev1.Secret {
 	if updated == nil {
 		return pObj.DeepCopy()
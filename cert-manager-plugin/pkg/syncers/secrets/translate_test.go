@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	"github.com/loft-sh/vcluster-cert-manager-plugin/pkg/constants"
+	"github.com/loft-sh/vcluster-sdk/translate"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"testing"
+)
+
+func TestTranslateUpdateBackwards(t *testing.T) {
+	vCertificate := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: secretNamespace, Name: "tls"},
+		Spec: certmanagerv1.CertificateSpec{
+			SecretName:     "tls",
+			IssuerRef:      cmmeta.ObjectReference{Name: "letsencrypt", Kind: "ClusterIssuer", Group: "cert-manager.io"},
+			SecretTemplate: &certmanagerv1.CertificateSecretTemplate{Labels: map[string]string{"team": "a"}},
+		},
+	}
+	pSecret := physicalSecret(map[string]string{
+		certmanagerv1.CertificateNameKey:       translate.PhysicalName("tls", secretNamespace),
+		certmanagerv1.IssuerNameAnnotationKey:  translate.PhysicalNameClusterScoped("letsencrypt", targetNamespace),
+		certmanagerv1.IssuerKindAnnotationKey:  "Issuer",
+		certmanagerv1.IssuerGroupAnnotationKey: "cert-manager.io",
+		certmanagerv1.AltNamesAnnotationKey:    "example.com",
+		"vcluster.loft.sh/object-name":         "tls",
+	}, map[string]string{
+		"controller.cert-manager.io/fao": "true",
+		"vcluster.loft.sh/managed-by":    "vcluster",
+		"team":                           "b",
+	})
+
+	expectedAnnotations := map[string]string{
+		certmanagerv1.CertificateNameKey:       "tls",
+		certmanagerv1.IssuerNameAnnotationKey:  "letsencrypt",
+		certmanagerv1.IssuerKindAnnotationKey:  "ClusterIssuer",
+		certmanagerv1.IssuerGroupAnnotationKey: "cert-manager.io",
+		certmanagerv1.AltNamesAnnotationKey:    "example.com",
+		constants.BackwardSyncAnnotation:       "true",
+		constants.TemplateLabelsAnnotation:     "team",
+	}
+	expectedLabels := map[string]string{
+		"controller.cert-manager.io/fao": "true",
+		translate.ControllerLabel:        constants.PluginName,
+		"team":                           "a",
+	}
+
+	vSecret := translateBackwards(pSecret, types.NamespacedName{Namespace: secretNamespace, Name: "tls"}, vCertificate)
+	if !reflect.DeepEqual(vSecret.Annotations, expectedAnnotations) || !reflect.DeepEqual(vSecret.Labels, expectedLabels) {
+		t.Errorf("Expected annotations %v and labels %v, got %v and %v", expectedAnnotations, expectedLabels, vSecret.Annotations, vSecret.Labels)
+	}
+
+	// the virtual secret is in sync
+	if updated := translateUpdateBackwards(pSecret, vSecret, vCertificate); updated != nil {
+		t.Errorf("Expected no update, got %v", updated)
+	}
+
+	// keys added within the vcluster are kept, keys cert-manager removed are removed
+	changed := vSecret.DeepCopy()
+	changed.Annotations["note"] = "added within the vcluster"
+	changed.Annotations[certmanagerv1.IPSANAnnotationKey] = "10.0.0.1"
+	changed.Labels["app"] = "web"
+	delete(changed.Labels, translate.ControllerLabel)
+	updated := translateUpdateBackwards(pSecret, changed, vCertificate)
+	if updated == nil {
+		t.Fatalf("Expected virtual secret to be updated")
+	}
+	expectedAnnotations["note"] = "added within the vcluster"
+	expectedLabels["app"] = "web"
+	if !reflect.DeepEqual(updated.Annotations, expectedAnnotations) || !reflect.DeepEqual(updated.Labels, expectedLabels) {
+		t.Errorf("Expected annotations %v and labels %v, got %v and %v", expectedAnnotations, expectedLabels, updated.Annotations, updated.Labels)
+	}
+
+	// keys dropped from the secret template are removed, as cert-manager removes them from the physical secret
+	templateChanged := vCertificate.DeepCopy()
+	templateChanged.Spec.SecretTemplate = &certmanagerv1.CertificateSecretTemplate{Annotations: map[string]string{"owner": "team-a"}}
+	pTemplateChanged := pSecret.DeepCopy()
+	pTemplateChanged.Annotations["owner"] = "team-a"
+	delete(pTemplateChanged.Labels, "team")
+	updated = translateUpdateBackwards(pTemplateChanged, updated, templateChanged)
+	if updated == nil {
+		t.Fatalf("Expected virtual secret to be updated")
+	}
+	expectedAnnotations["owner"] = "team-a"
+	expectedAnnotations[constants.TemplateAnnotationsAnnotation] = "owner"
+	delete(expectedAnnotations, constants.TemplateLabelsAnnotation)
+	delete(expectedLabels, "team")
+	if !reflect.DeepEqual(updated.Annotations, expectedAnnotations) || !reflect.DeepEqual(updated.Labels, expectedLabels) {
+		t.Errorf("Expected annotations %v and labels %v, got %v and %v", expectedAnnotations, expectedLabels, updated.Annotations, updated.Labels)
+	}
+
+	// the secret template is removed entirely
+	templateChanged.Spec.SecretTemplate = nil
+	updated = translateUpdateBackwards(pSecret, updated, templateChanged)
+	if updated == nil {
+		t.Fatalf("Expected virtual secret to be updated")
+	}
+	if _, ok := updated.Annotations["owner"]; ok || updated.Annotations[constants.TemplateAnnotationsAnnotation] != "" {
+		t.Errorf("Expected templated annotations to be removed, got %v", updated.Annotations)
+	}
+
+	// data changes are synced
+	pSecret.Data = map[string][]byte{corev1.TLSCertKey: []byte("renewed")}
+	updated = translateUpdateBackwards(pSecret, vSecret, vCertificate)
+	if updated == nil || string(updated.Data[corev1.TLSCertKey]) != "renewed" {
+		t.Errorf("Expected renewed data, got %v", updated)
+	}
+}
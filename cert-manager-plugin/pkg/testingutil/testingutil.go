@@ -0,0 +1,80 @@
+package testingutil
+
+import (
+	context2 "context"
+	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/loft-sh/vcluster-sdk/log"
+	"github.com/loft-sh/vcluster-sdk/syncer/context"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	"testing"
+)
+
+const (
+	// TargetNamespace is the host namespace the vcluster syncs its objects to
+	TargetNamespace = "vcluster"
+
+	// Namespace is the virtual namespace objects are created in
+	Namespace = "team-a"
+)
+
+// Manager only provides the client and event recorder the syncers and hooks need. Events are
+// recorded to Recorder, if it is set.
+type Manager struct {
+	ctrl.Manager
+
+	Client   client.Client
+	Recorder record.EventRecorder
+}
+
+func (m *Manager) GetClient() client.Client {
+	return m.Client
+}
+
+func (m *Manager) GetEventRecorderFor(name string) record.EventRecorder {
+	if m.Recorder != nil {
+		return m.Recorder
+	}
+
+	return record.NewFakeRecorder(10)
+}
+
+// NewScheme returns a scheme with the kubernetes, cert-manager and gateway api types
+func NewScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = certmanagerv1.AddToScheme(scheme)
+	_ = gatewayv1alpha2.AddToScheme(scheme)
+	return scheme
+}
+
+// NewFakeClient returns a fake client holding the given objects
+func NewFakeClient(objs ...client.Object) client.Client {
+	return fake.NewClientBuilder().WithScheme(NewScheme()).WithObjects(objs...).Build()
+}
+
+// NewRegisterContext returns a register context whose managers provide the given clients
+func NewRegisterContext(virtualClient, physicalClient client.Client) *context.RegisterContext {
+	return &context.RegisterContext{
+		Context:         context2.TODO(),
+		TargetNamespace: TargetNamespace,
+		VirtualManager:  &Manager{Client: virtualClient},
+		PhysicalManager: &Manager{Client: physicalClient},
+	}
+}
+
+// NewSyncContext returns a sync context with fake clients holding the given virtual and physical objects
+func NewSyncContext(t *testing.T, vObjs, pObjs []client.Object) *context.SyncContext {
+	return &context.SyncContext{
+		Context:         context2.TODO(),
+		VirtualClient:   NewFakeClient(vObjs...),
+		PhysicalClient:  NewFakeClient(pObjs...),
+		TargetNamespace: TargetNamespace,
+		Log:             log.New(t.Name()),
+	}
+}